@@ -1,6 +1,12 @@
 package fs
 
-import "time"
+import (
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+)
 
 const (
 	// FileSystemConnectionErrorTimeoutDefault is a default timeout value of connection error
@@ -41,6 +47,42 @@ type FileSystemConfig struct {
 	// at subdir/file creation/deletion
 	// turn to false to allow short cache inconsistency
 	InvalidateParentEntryCacheImmediately bool
+	// how long to wait for a pooled connection when all connections are in use
+	// 0 shares in-use connections instead of waiting
+	ConnectionWaitTimeout time.Duration
+	// max connections a single caller id can hold, 0 means no limit
+	ConnectionMaxPerCaller int
+	// check idle connections with a no-op request before use
+	ConnectionValidateOnBorrow bool
+	// interval to check idle connections in background, 0 disables it
+	ConnectionHealthCheckInterval time.Duration
+	// retry policy for read-only catalog operations (stat, list, query), nil (default) disables retry
+	RetryPolicy *session.RetryPolicy
+	// circuit breaker to stop connection attempts after failures, nil opens at the first failure
+	// and allows a probe after ConnectionErrorTimeout
+	CircuitBreaker *session.CircuitBreakerConfig
+	// catalog service providers to spread connections over, the account's host is used if empty
+	Endpoints []session.Endpoint
+	// how an endpoint is selected for a new connection
+	LoadBalancingStrategy session.LoadBalancingStrategy
+	// how long a failed endpoint is skipped before it is tried again
+	EndpointRetryInterval time.Duration
+	// dialer to connect to servers through proxies or tunnels, nil connects directly
+	Dialer connection.Dialer
+	// TCP keepalive period of connections, 0 uses the OS default
+	ConnectionTCPKeepAlivePeriod time.Duration
+	// interval to send heartbeats on idle connections, e.g., control connections during long transfers, 0 disables it
+	ConnectionHeartbeatInterval time.Duration
+	// buffering of files opened by OpenFile and CreateFile, nil disables it
+	FileBuffer *FileBufferConfig
+	// bytes per second uploaded by the file system, 0 means no limit, can be changed by SetBandwidthLimit
+	UploadBandwidthLimit int64
+	// bytes per second downloaded by the file system, 0 means no limit, can be changed by SetBandwidthLimit
+	DownloadBandwidthLimit int64
+	// number of tasks and block size of parallel transfers, and adaptive tuning of them, nil uses fixed default limits
+	ParallelTransfer *util.ParallelTransferConfig
+	// directory of transfer status files of resumable uploads, empty uses the user cache directory
+	UploadTransferStatusDir string
 }
 
 // NewFileSystemConfig create a FileSystemConfig
@@ -64,6 +106,23 @@ func NewFileSystemConfig(applicationName string, connectionErrorTimeout time.Dur
 		CacheTimeoutSettings:                  cacheTimeoutSettings,
 		StartNewTransaction:                   startNewTransaction,
 		InvalidateParentEntryCacheImmediately: invalidateParentEntryCacheImmediately,
+		ConnectionWaitTimeout:                 0,
+		ConnectionMaxPerCaller:                0,
+		ConnectionValidateOnBorrow:            false,
+		ConnectionHealthCheckInterval:         0,
+		RetryPolicy:                           nil,
+		CircuitBreaker:                        nil,
+		Endpoints:                             nil,
+		LoadBalancingStrategy:                 session.LoadBalancingRoundRobin,
+		EndpointRetryInterval:                 session.EndpointRetryIntervalDefault,
+		Dialer:                                nil,
+		ConnectionTCPKeepAlivePeriod:          0,
+		ConnectionHeartbeatInterval:           0,
+		FileBuffer:                            nil,
+		UploadBandwidthLimit:                  0,
+		DownloadBandwidthLimit:                0,
+		ParallelTransfer:                      nil,
+		UploadTransferStatusDir:               "",
 	}
 }
 
@@ -83,5 +142,65 @@ func NewFileSystemConfigWithDefault(applicationName string) *FileSystemConfig {
 		CacheCleanupTime:                      FileSystemTimeoutDefault,
 		StartNewTransaction:                   true,
 		InvalidateParentEntryCacheImmediately: true,
+		ConnectionWaitTimeout:                 0,
+		ConnectionMaxPerCaller:                0,
+		ConnectionValidateOnBorrow:            false,
+		ConnectionHealthCheckInterval:         0,
+		RetryPolicy:                           nil,
+		CircuitBreaker:                        nil,
+		Endpoints:                             nil,
+		LoadBalancingStrategy:                 session.LoadBalancingRoundRobin,
+		EndpointRetryInterval:                 session.EndpointRetryIntervalDefault,
+		Dialer:                                nil,
+		ConnectionTCPKeepAlivePeriod:          0,
+		ConnectionHeartbeatInterval:           0,
+		FileBuffer:                            nil,
+		UploadBandwidthLimit:                  0,
+		DownloadBandwidthLimit:                0,
+		ParallelTransfer:                      nil,
+		UploadTransferStatusDir:               "",
+	}
+}
+
+// getIOSessionConfig returns a session config for IO operations
+func (config *FileSystemConfig) getIOSessionConfig(uploadRateLimiter *util.RateLimiter, downloadRateLimiter *util.RateLimiter) *session.IRODSSessionConfig {
+	// the metadata session shares the pool, io requests leave connections for metadata requests
+	sessConfig := config.getSessionConfig(config.getConnectionsPerFileSystem(), uploadRateLimiter, downloadRateLimiter)
+	sessConfig.ConnectionPriority = session.ConnectionPriorityIO
+	sessConfig.ConnectionMaxPerPriority = map[session.ConnectionPriority]int{
+		session.ConnectionPriorityIO: config.ConnectionMax,
 	}
+	return sessConfig
+}
+
+// getMetadataSessionConfig returns a session config for metadata operations, the session shares the pool of the IO session
+func (config *FileSystemConfig) getMetadataSessionConfig(uploadRateLimiter *util.RateLimiter, downloadRateLimiter *util.RateLimiter) *session.IRODSSessionConfig {
+	sessConfig := config.getSessionConfig(FileSystemConnectionMetaDefault, uploadRateLimiter, downloadRateLimiter)
+	sessConfig.ConnectionPriority = session.ConnectionPriorityMetadata
+	return sessConfig
+}
+
+// getConnectionsPerFileSystem returns the max number of connections a FileSystem may open
+func (config *FileSystemConfig) getConnectionsPerFileSystem() int {
+	return config.ConnectionMax + FileSystemConnectionMetaDefault
+}
+
+func (config *FileSystemConfig) getSessionConfig(connectionMax int, uploadRateLimiter *util.RateLimiter, downloadRateLimiter *util.RateLimiter) *session.IRODSSessionConfig {
+	sessConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, connectionMax, config.TCPBufferSize, config.StartNewTransaction)
+	sessConfig.ConnectionWaitTimeout = config.ConnectionWaitTimeout
+	sessConfig.ConnectionMaxPerCaller = config.ConnectionMaxPerCaller
+	sessConfig.ConnectionValidateOnBorrow = config.ConnectionValidateOnBorrow
+	sessConfig.ConnectionHealthCheckInterval = config.ConnectionHealthCheckInterval
+	sessConfig.RetryPolicy = config.RetryPolicy
+	sessConfig.CircuitBreaker = config.CircuitBreaker
+	sessConfig.Endpoints = config.Endpoints
+	sessConfig.LoadBalancingStrategy = config.LoadBalancingStrategy
+	sessConfig.EndpointRetryInterval = config.EndpointRetryInterval
+	sessConfig.Dialer = config.Dialer
+	sessConfig.ConnectionTCPKeepAlivePeriod = config.ConnectionTCPKeepAlivePeriod
+	sessConfig.ConnectionHeartbeatInterval = config.ConnectionHeartbeatInterval
+	sessConfig.SendRateLimiter = uploadRateLimiter
+	sessConfig.RecvRateLimiter = downloadRateLimiter
+	sessConfig.ParallelTransfer = config.ParallelTransfer
+	return sessConfig
 }
@@ -0,0 +1,508 @@
+package fs
+
+import (
+	"io"
+	"sync"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// FileBufferBlockSizeDefault is a default size of a block read from or written to the server at once
+	FileBufferBlockSizeDefault = 1024 * 1024 // 1MB
+	// FileBufferReadAheadBlocksDefault is a default number of blocks to read ahead
+	FileBufferReadAheadBlocksDefault = 2
+	// fileBufferSequentialReadsForReadAhead is the number of sequential reads to start read-ahead
+	fileBufferSequentialReadsForReadAhead = 2
+)
+
+// FileBufferConfig is a configuration of buffered file handles
+type FileBufferConfig struct {
+	// size of a block read from or written to the server at once
+	BlockSize int
+	// read next blocks in background on a second connection when reads are sequential, only for read-only files
+	ReadAhead bool
+	// number of blocks to read ahead
+	ReadAheadBlocks int
+}
+
+// NewFileBufferConfigWithDefault creates a FileBufferConfig with default settings
+func NewFileBufferConfigWithDefault() *FileBufferConfig {
+	return &FileBufferConfig{
+		BlockSize:       FileBufferBlockSizeDefault,
+		ReadAhead:       true,
+		ReadAheadBlocks: FileBufferReadAheadBlocksDefault,
+	}
+}
+
+// fileBuffer holds read and write buffers of a FileHandle
+// reads are served from the block containing the offset, writes are coalesced until a block is full
+type fileBuffer struct {
+	blockSize       int
+	readAhead       bool
+	readAheadBlocks int
+
+	// offset of the server-side file pointer, differs from the handle's offset while buffering
+	serverOffset int64
+
+	readBlockOffset int64
+	readBlockData   []byte
+	lastReadEnd     int64
+	sequentialReads int
+	readAheader     *fileReadAheader
+	readAheadFailed bool
+
+	writeOffset int64
+	writeData   []byte
+}
+
+func newFileBuffer(config *FileBufferConfig, offset int64) *fileBuffer {
+	blockSize := config.BlockSize
+	if blockSize <= 0 {
+		blockSize = FileBufferBlockSizeDefault
+	}
+
+	readAheadBlocks := config.ReadAheadBlocks
+	if readAheadBlocks <= 0 {
+		readAheadBlocks = FileBufferReadAheadBlocksDefault
+	}
+
+	return &fileBuffer{
+		blockSize:       blockSize,
+		readAhead:       config.ReadAhead,
+		readAheadBlocks: readAheadBlocks,
+		serverOffset:    offset,
+		readBlockOffset: 0,
+		readBlockData:   nil,
+		lastReadEnd:     offset,
+		sequentialReads: 0,
+		readAheader:     nil,
+		readAheadFailed: false,
+		writeOffset:     offset,
+		writeData:       nil,
+	}
+}
+
+// invalidateRead drops read data, must be called when the file content changes
+func (buffer *fileBuffer) invalidateRead() {
+	buffer.readBlockData = nil
+	if buffer.readAheader != nil {
+		buffer.readAheader.discardAll()
+	}
+}
+
+// readFileBlock reads a block from the current server-side offset, a short block means EOF
+func readFileBlock(conn *connection.IRODSConnection, handle *types.IRODSFileHandle, blockSize int) ([]byte, error) {
+	data := make([]byte, blockSize)
+	totalReadLen := 0
+	for totalReadLen < blockSize {
+		readLen, err := irods_fs.ReadDataObject(conn, handle, data[totalReadLen:])
+		totalReadLen += readLen
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if readLen == 0 {
+			break
+		}
+	}
+
+	return data[:totalReadLen], nil
+}
+
+// fileReadAheadBlock is a block being read ahead
+type fileReadAheadBlock struct {
+	offset    int64
+	data      []byte
+	err       error
+	done      chan bool // closed once the block is read
+	discarded bool      // not needed anymore, skipped if not read yet, guarded by the read-aheader's mutex
+}
+
+// fileReadAheader reads blocks ahead on its own connection and data object handle
+type fileReadAheader struct {
+	filesystem      *FileSystem
+	blockSize       int
+	connection      *connection.IRODSConnection
+	irodsFileHandle *types.IRODSFileHandle
+	serverOffset    int64
+	blocks          map[int64]*fileReadAheadBlock
+	requestChan     chan *fileReadAheadBlock
+	terminateChan   chan bool
+	waitGroup       sync.WaitGroup
+	mutex           sync.Mutex
+}
+
+func newFileReadAheader(filesystem *FileSystem, path string, resource string, blockSize int, maxBlocks int) (*fileReadAheader, error) {
+	conn, err := filesystem.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, offset, err := irods_fs.OpenDataObject(conn, path, resource, string(types.FileOpenModeReadOnly))
+	if err != nil {
+		filesystem.ioSession.ReturnConnection(conn)
+		return nil, err
+	}
+
+	readAheader := &fileReadAheader{
+		filesystem:      filesystem,
+		blockSize:       blockSize,
+		connection:      conn,
+		irodsFileHandle: handle,
+		serverOffset:    offset,
+		blocks:          map[int64]*fileReadAheadBlock{},
+		requestChan:     make(chan *fileReadAheadBlock, maxBlocks),
+		terminateChan:   make(chan bool),
+		waitGroup:       sync.WaitGroup{},
+		mutex:           sync.Mutex{},
+	}
+
+	readAheader.waitGroup.Add(1)
+	go readAheader.run()
+
+	return readAheader, nil
+}
+
+func (readAheader *fileReadAheader) run() {
+	defer readAheader.waitGroup.Done()
+
+	for {
+		select {
+		case <-readAheader.terminateChan:
+			return
+		case block := <-readAheader.requestChan:
+			readAheader.mutex.Lock()
+			discarded := block.discarded
+			readAheader.mutex.Unlock()
+
+			if discarded {
+				// the same offset may be requested again with a new block
+				continue
+			}
+
+			block.data, block.err = readAheader.readBlock(block.offset)
+			close(block.done)
+		}
+	}
+}
+
+func (readAheader *fileReadAheader) readBlock(blockOffset int64) ([]byte, error) {
+	if readAheader.serverOffset != blockOffset {
+		newOffset, err := irods_fs.SeekDataObject(readAheader.connection, readAheader.irodsFileHandle, blockOffset, types.SeekSet)
+		if err != nil {
+			return nil, err
+		}
+
+		readAheader.serverOffset = newOffset
+		if newOffset != blockOffset {
+			return nil, xerrors.Errorf("failed to seek to %d", blockOffset)
+		}
+	}
+
+	data, err := readFileBlock(readAheader.connection, readAheader.irodsFileHandle, readAheader.blockSize)
+	readAheader.serverOffset += int64(len(data))
+	return data, err
+}
+
+// request schedules reading a block, blocks already requested are ignored
+func (readAheader *fileReadAheader) request(blockOffset int64) {
+	readAheader.mutex.Lock()
+	defer readAheader.mutex.Unlock()
+
+	if _, ok := readAheader.blocks[blockOffset]; ok {
+		return
+	}
+
+	block := &fileReadAheadBlock{
+		offset:    blockOffset,
+		data:      nil,
+		err:       nil,
+		done:      make(chan bool),
+		discarded: false,
+	}
+
+	select {
+	case readAheader.requestChan <- block:
+		readAheader.blocks[blockOffset] = block
+	default:
+		// too many requests pending
+	}
+}
+
+// take removes the block from read-ahead blocks and returns it, nil if not requested
+func (readAheader *fileReadAheader) take(blockOffset int64) *fileReadAheadBlock {
+	readAheader.mutex.Lock()
+	defer readAheader.mutex.Unlock()
+
+	block, ok := readAheader.blocks[blockOffset]
+	if !ok {
+		return nil
+	}
+
+	delete(readAheader.blocks, blockOffset)
+	return block
+}
+
+// discardBefore drops blocks before the offset, they will not be read
+func (readAheader *fileReadAheader) discardBefore(offset int64) {
+	readAheader.mutex.Lock()
+	defer readAheader.mutex.Unlock()
+
+	for blockOffset, block := range readAheader.blocks {
+		if blockOffset < offset {
+			block.discarded = true
+			delete(readAheader.blocks, blockOffset)
+		}
+	}
+}
+
+// discardAll drops all blocks
+func (readAheader *fileReadAheader) discardAll() {
+	readAheader.mutex.Lock()
+	defer readAheader.mutex.Unlock()
+
+	for _, block := range readAheader.blocks {
+		block.discarded = true
+	}
+	readAheader.blocks = map[int64]*fileReadAheadBlock{}
+}
+
+// release stops reading ahead and returns the connection
+func (readAheader *fileReadAheader) release() {
+	close(readAheader.terminateChan)
+	readAheader.waitGroup.Wait()
+
+	irods_fs.CloseDataObject(readAheader.connection, readAheader.irodsFileHandle)
+	readAheader.connection.SetTransferRateLimiters(nil, nil)
+	readAheader.filesystem.ioSession.ReturnConnection(readAheader.connection)
+}
+
+// readBuffered reads data at the offset through the buffer, the handle must be locked
+func (handle *FileHandle) readBuffered(data []byte, offset int64) (int, error) {
+	err := handle.flushBuffered()
+	if err != nil {
+		return 0, err
+	}
+
+	buffer := handle.buffer
+	blockSize := int64(buffer.blockSize)
+
+	totalReadLen := 0
+	lastBlockOffset := offset / blockSize * blockSize
+	for totalReadLen < len(data) {
+		curOffset := offset + int64(totalReadLen)
+		blockOffset := curOffset / blockSize * blockSize
+		lastBlockOffset = blockOffset
+
+		blockData, err := handle.getReadBlock(blockOffset)
+		if err != nil {
+			return totalReadLen, err
+		}
+
+		inBlockOffset := int(curOffset - blockOffset)
+		if inBlockOffset >= len(blockData) {
+			// EOF
+			break
+		}
+
+		copyLen := copy(data[totalReadLen:], blockData[inBlockOffset:])
+		totalReadLen += copyLen
+
+		if len(blockData) < buffer.blockSize && inBlockOffset+copyLen >= len(blockData) {
+			// last block
+			break
+		}
+	}
+
+	// detect sequential access
+	if offset == buffer.lastReadEnd {
+		buffer.sequentialReads++
+	} else {
+		buffer.sequentialReads = 0
+	}
+	buffer.lastReadEnd = offset + int64(totalReadLen)
+
+	if buffer.sequentialReads >= fileBufferSequentialReadsForReadAhead {
+		handle.scheduleReadAhead(lastBlockOffset)
+	}
+
+	if totalReadLen == 0 && len(data) > 0 {
+		return 0, io.EOF
+	}
+
+	return totalReadLen, nil
+}
+
+// getReadBlock returns data of the block at the offset, the handle must be locked
+func (handle *FileHandle) getReadBlock(blockOffset int64) ([]byte, error) {
+	buffer := handle.buffer
+
+	if buffer.readBlockData != nil && buffer.readBlockOffset == blockOffset {
+		return buffer.readBlockData, nil
+	}
+
+	if buffer.readAheader != nil {
+		block := buffer.readAheader.take(blockOffset)
+		if block != nil {
+			<-block.done
+			if block.err == nil {
+				buffer.readBlockOffset = blockOffset
+				buffer.readBlockData = block.data
+				return block.data, nil
+			}
+			// read it again
+		}
+	}
+
+	if buffer.serverOffset != blockOffset {
+		newOffset, err := irods_fs.SeekDataObject(handle.connection, handle.irodsFileHandle, blockOffset, types.SeekSet)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.serverOffset = newOffset
+		if newOffset != blockOffset {
+			return nil, xerrors.Errorf("failed to seek to %d", blockOffset)
+		}
+	}
+
+	blockData, err := readFileBlock(handle.connection, handle.irodsFileHandle, buffer.blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.serverOffset += int64(len(blockData))
+	buffer.readBlockOffset = blockOffset
+	buffer.readBlockData = blockData
+	return blockData, nil
+}
+
+// scheduleReadAhead requests blocks after the current block, the handle must be locked
+func (handle *FileHandle) scheduleReadAhead(blockOffset int64) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "FileHandle",
+		"function": "scheduleReadAhead",
+	})
+
+	buffer := handle.buffer
+	if !buffer.readAhead || buffer.readAheadFailed || !handle.IsReadOnlyMode() {
+		return
+	}
+
+	if buffer.readAheader == nil {
+		readAheader, err := newFileReadAheader(handle.filesystem, handle.entry.Path, handle.irodsFileHandle.Resource, buffer.blockSize, buffer.readAheadBlocks)
+		if err != nil {
+			// continue without read-ahead, e.g., no connection available
+			logger.WithError(err).Debugf("failed to start read-ahead for %s", handle.entry.Path)
+			buffer.readAheadFailed = true
+			return
+		}
+
+		// read-ahead is a part of reads through the handle
+		readAheader.connection.SetTransferRateLimiters(handle.connection.GetTransferRateLimiters())
+		buffer.readAheader = readAheader
+	}
+
+	blockSize := int64(buffer.blockSize)
+	buffer.readAheader.discardBefore(blockOffset + blockSize)
+
+	for i := 1; i <= buffer.readAheadBlocks; i++ {
+		nextBlockOffset := blockOffset + int64(i)*blockSize
+		if nextBlockOffset >= handle.entry.Size {
+			break
+		}
+
+		buffer.readAheader.request(nextBlockOffset)
+	}
+}
+
+// writeBuffered writes data at the offset through the buffer, the handle must be locked
+func (handle *FileHandle) writeBuffered(data []byte, offset int64) (int, error) {
+	buffer := handle.buffer
+
+	if len(buffer.writeData) > 0 && buffer.writeOffset+int64(len(buffer.writeData)) != offset {
+		// not contiguous
+		err := handle.flushBuffered()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(buffer.writeData) == 0 {
+		buffer.writeOffset = offset
+	}
+
+	buffer.writeData = append(buffer.writeData, data...)
+	buffer.invalidateRead()
+
+	if len(buffer.writeData) >= buffer.blockSize {
+		err := handle.flushBuffered()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// update
+	if handle.entry.Size < offset+int64(len(data)) {
+		handle.entry.Size = offset + int64(len(data))
+	}
+
+	return len(data), nil
+}
+
+// flushBuffered writes buffered data to the server, the handle must be locked
+func (handle *FileHandle) flushBuffered() error {
+	buffer := handle.buffer
+	if buffer == nil || len(buffer.writeData) == 0 {
+		return nil
+	}
+
+	writeData := buffer.writeData
+	writeOffset := buffer.writeOffset
+	buffer.writeData = nil
+
+	if buffer.serverOffset != writeOffset {
+		newOffset, err := irods_fs.SeekDataObject(handle.connection, handle.irodsFileHandle, writeOffset, types.SeekSet)
+		if err != nil {
+			return err
+		}
+
+		buffer.serverOffset = newOffset
+		if newOffset != writeOffset {
+			return xerrors.Errorf("failed to seek to %d", writeOffset)
+		}
+	}
+
+	err := irods_fs.WriteDataObject(handle.connection, handle.irodsFileHandle, writeData)
+	if err != nil {
+		return err
+	}
+
+	buffer.serverOffset += int64(len(writeData))
+	return nil
+}
+
+// releaseReadAhead stops reading ahead, the handle must be locked
+func (handle *FileHandle) releaseReadAhead() {
+	buffer := handle.buffer
+	if buffer == nil {
+		return
+	}
+
+	buffer.readBlockData = nil
+	if buffer.readAheader != nil {
+		buffer.readAheader.release()
+		buffer.readAheader = nil
+	}
+}
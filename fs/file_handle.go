@@ -7,6 +7,7 @@ import (
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
 	"golang.org/x/xerrors"
 )
 
@@ -20,6 +21,7 @@ type FileHandle struct {
 	entry               *Entry
 	offset              int64
 	openMode            types.FileOpenMode
+	buffer              *fileBuffer // nil if not buffered
 	mutex               sync.Mutex
 }
 
@@ -71,6 +73,28 @@ func (handle *FileHandle) IsWriteOnlyMode() bool {
 	return handle.openMode.IsWriteOnly()
 }
 
+// IsBuffered returns true if reads and writes are buffered
+func (handle *FileHandle) IsBuffered() bool {
+	return handle.buffer != nil
+}
+
+// Flush writes buffered data to the server
+func (handle *FileHandle) Flush() error {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	return handle.flushBuffered()
+}
+
+// SetRateLimiters limits bytes written and read through the handle, on top of the file system limits
+// nil disables a limit, limiters can be shared by handles to limit a transfer over multiple handles
+func (handle *FileHandle) SetRateLimiters(writeLimiter *util.RateLimiter, readLimiter *util.RateLimiter) {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	handle.connection.SetTransferRateLimiters(writeLimiter, readLimiter)
+}
+
 // GetIRODSFileHandle returns iRODS File Handle
 func (handle *FileHandle) GetIRODSFileHandle() *types.IRODSFileHandle {
 	return handle.irodsFileHandle
@@ -86,6 +110,9 @@ func (handle *FileHandle) Close() error {
 	handle.mutex.Lock()
 	defer handle.mutex.Unlock()
 
+	flushErr := handle.flushBuffered()
+	handle.releaseReadAhead()
+
 	if handle.irodsFileLockHandle != nil {
 		// unlock if locked
 		err := irods_fs.UnlockDataObject(handle.connection, handle.irodsFileLockHandle)
@@ -96,6 +123,7 @@ func (handle *FileHandle) Close() error {
 		handle.irodsFileLockHandle = nil
 	}
 
+	handle.connection.SetTransferRateLimiters(nil, nil)
 	defer handle.filesystem.ioSession.ReturnConnection(handle.connection)
 
 	err := irods_fs.CloseDataObject(handle.connection, handle.irodsFileHandle)
@@ -106,20 +134,77 @@ func (handle *FileHandle) Close() error {
 		handle.filesystem.cachePropagation.PropagateFileUpdate(handle.entry.Path)
 	}
 
+	if err == nil {
+		err = flushErr
+	}
 	return err
 }
 
+// CloseReplica closes the file using replica close API
+// updateSize and updateStatus control whether the catalog is updated with the replica's size and status
+// if it fails, the file remains open and must be closed with Close
+func (handle *FileHandle) CloseReplica(updateSize bool, updateStatus bool, computeChecksum bool) error {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	flushErr := handle.flushBuffered()
+	handle.releaseReadAhead()
+
+	if handle.irodsFileLockHandle != nil {
+		// unlock if locked
+		err := irods_fs.UnlockDataObject(handle.connection, handle.irodsFileLockHandle)
+		if err != nil {
+			return err
+		}
+
+		handle.irodsFileLockHandle = nil
+	}
+
+	err := irods_fs.CloseDataObjectReplicaWithOptions(handle.connection, handle.irodsFileHandle, updateSize, updateStatus, computeChecksum)
+	if err != nil {
+		// the file is still open at the server, keep the handle so it can be closed with Close
+		return err
+	}
+
+	handle.connection.SetTransferRateLimiters(nil, nil)
+	handle.filesystem.ioSession.ReturnConnection(handle.connection)
+	handle.filesystem.fileHandleMap.Remove(handle.id)
+
+	if handle.IsWriteMode() {
+		handle.filesystem.invalidateCacheForFileUpdate(handle.entry.Path)
+		handle.filesystem.cachePropagation.PropagateFileUpdate(handle.entry.Path)
+	}
+
+	return flushErr
+}
+
 // Seek moves file pointer
 func (handle *FileHandle) Seek(offset int64, whence int) (int64, error) {
 	handle.mutex.Lock()
 	defer handle.mutex.Unlock()
 
+	if handle.buffer != nil {
+		err := handle.flushBuffered()
+		if err != nil {
+			return handle.offset, err
+		}
+
+		if types.Whence(whence) == types.SeekCur {
+			// the server-side offset differs while buffering
+			offset += handle.offset
+			whence = int(types.SeekSet)
+		}
+	}
+
 	newOffset, err := irods_fs.SeekDataObject(handle.connection, handle.irodsFileHandle, offset, types.Whence(whence))
 	if err != nil {
 		return newOffset, err
 	}
 
 	handle.offset = newOffset
+	if handle.buffer != nil {
+		handle.buffer.serverOffset = newOffset
+	}
 	return newOffset, nil
 }
 
@@ -128,6 +213,15 @@ func (handle *FileHandle) Truncate(size int64) error {
 	handle.mutex.Lock()
 	defer handle.mutex.Unlock()
 
+	if handle.buffer != nil {
+		err := handle.flushBuffered()
+		if err != nil {
+			return err
+		}
+
+		handle.buffer.invalidateRead()
+	}
+
 	err := irods_fs.TruncateDataObjectHandle(handle.connection, handle.irodsFileHandle, size)
 	if err != nil {
 		return err
@@ -145,6 +239,12 @@ func (handle *FileHandle) Read(buffer []byte) (int, error) {
 		return 0, xerrors.Errorf("file is opened with %s mode", handle.openMode)
 	}
 
+	if handle.buffer != nil {
+		readLen, err := handle.readBuffered(buffer, handle.offset)
+		handle.offset += int64(readLen)
+		return readLen, err
+	}
+
 	readLen, err := irods_fs.ReadDataObject(handle.connection, handle.irodsFileHandle, buffer)
 	if readLen > 0 {
 		handle.offset += int64(readLen)
@@ -163,6 +263,12 @@ func (handle *FileHandle) ReadAt(buffer []byte, offset int64) (int, error) {
 		return 0, xerrors.Errorf("file is opened with %s mode", handle.openMode)
 	}
 
+	if handle.buffer != nil {
+		readLen, err := handle.readBuffered(buffer, offset)
+		handle.offset = offset + int64(readLen)
+		return readLen, err
+	}
+
 	if handle.offset != offset {
 		newOffset, err := irods_fs.SeekDataObject(handle.connection, handle.irodsFileHandle, offset, types.SeekSet)
 		if err != nil {
@@ -194,6 +300,12 @@ func (handle *FileHandle) Write(data []byte) (int, error) {
 		return 0, xerrors.Errorf("file is opened with %s mode", handle.openMode)
 	}
 
+	if handle.buffer != nil {
+		writeLen, err := handle.writeBuffered(data, handle.offset)
+		handle.offset += int64(writeLen)
+		return writeLen, err
+	}
+
 	err := irods_fs.WriteDataObject(handle.connection, handle.irodsFileHandle, data)
 	if err != nil {
 		return 0, err
@@ -218,6 +330,12 @@ func (handle *FileHandle) WriteAt(data []byte, offset int64) (int, error) {
 		return 0, xerrors.Errorf("file is opened with %s mode", handle.openMode)
 	}
 
+	if handle.buffer != nil {
+		writeLen, err := handle.writeBuffered(data, offset)
+		handle.offset = offset + int64(writeLen)
+		return writeLen, err
+	}
+
 	if handle.offset != offset {
 		newOffset, err := irods_fs.SeekDataObject(handle.connection, handle.irodsFileHandle, offset, types.SeekSet)
 		if err != nil {
@@ -307,8 +425,15 @@ func (handle *FileHandle) UnlockDataObject() error {
 
 // preprocessRename should be called before the file is renamed
 func (handle *FileHandle) preprocessRename() error {
+	// write buffered data and stop reading ahead on the old path
+	flushErr := handle.flushBuffered()
+	handle.releaseReadAhead()
+
 	// first, we need to close the file
 	err := irods_fs.CloseDataObject(handle.connection, handle.irodsFileHandle)
+	if err == nil {
+		err = flushErr
+	}
 
 	if handle.IsWriteMode() {
 		handle.filesystem.invalidateCacheForFileUpdate(handle.entry.Path)
@@ -358,6 +483,9 @@ func (handle *FileHandle) postprocessRename(newPath string, newEntry *Entry) err
 	handle.irodsFileHandle = newHandle
 	handle.entry = newEntry
 	handle.openMode = newOpenMode
+	if handle.buffer != nil {
+		handle.buffer.serverOffset = handle.offset
+	}
 	return nil
 }
 
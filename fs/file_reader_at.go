@@ -0,0 +1,332 @@
+package fs
+
+import (
+	"io"
+	"sync"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"github.com/rs/xid"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// FileReaderAtConnectionMaxDefault is a default number of connections a FileReaderAt uses
+	FileReaderAtConnectionMaxDefault = 4
+)
+
+// fileReaderAtStream is a data object opened on a connection
+type fileReaderAtStream struct {
+	connection      *connection.IRODSConnection
+	irodsFileHandle *types.IRODSFileHandle
+	offset          int64
+}
+
+// FileReaderAt is a read-only handle of a file, implements io.ReaderAt
+// it is safe for concurrent use, reads are spread over up to connectionMax connections,
+// each of which opens the same replica
+type FileReaderAt struct {
+	id                string
+	filesystem        *FileSystem
+	entry             *Entry
+	resource          string
+	connectionMax     int
+	replicaToken      string
+	resourceHierarchy string
+	streams           int
+	idleStreams       []*fileReaderAtStream
+	closed            bool
+	mutex             sync.Mutex
+	streamCondition   *sync.Cond
+}
+
+// OpenFileReaderAt opens an existing file for concurrent reads
+// connectionMax bounds connections used by the handle, <= 0 uses FileReaderAtConnectionMaxDefault
+func (fs *FileSystem) OpenFileReaderAt(path string, resource string, connectionMax int) (*FileReaderAt, error) {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	if connectionMax <= 0 {
+		connectionMax = FileReaderAtConnectionMaxDefault
+	}
+
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Type == DirectoryEntry {
+		return nil, xerrors.Errorf("cannot open a collection %s", irodsPath)
+	}
+
+	conn, err := fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, offset, err := irods_fs.OpenDataObject(conn, irodsPath, resource, string(types.FileOpenModeReadOnly))
+	if err != nil {
+		fs.ioSession.ReturnConnection(conn)
+		return nil, err
+	}
+
+	replicaToken := ""
+	resourceHierarchy := ""
+	if conn.HasCapability(types.IRODSServerCapabilityReplicaClose) {
+		// open the same replica on other connections
+		replicaToken, resourceHierarchy, err = irods_fs.GetReplicaAccessInfo(conn, handle)
+		if err != nil {
+			irods_fs.CloseDataObject(conn, handle)
+			fs.ioSession.ReturnConnection(conn)
+			return nil, err
+		}
+	}
+
+	readerAt := &FileReaderAt{
+		id:                xid.New().String(),
+		filesystem:        fs,
+		entry:             entry,
+		resource:          resource,
+		connectionMax:     connectionMax,
+		replicaToken:      replicaToken,
+		resourceHierarchy: resourceHierarchy,
+		streams:           1,
+		idleStreams:       []*fileReaderAtStream{},
+		closed:            false,
+		mutex:             sync.Mutex{},
+	}
+
+	readerAt.streamCondition = sync.NewCond(&readerAt.mutex)
+	readerAt.idleStreams = append(readerAt.idleStreams, &fileReaderAtStream{
+		connection:      conn,
+		irodsFileHandle: handle,
+		offset:          offset,
+	})
+
+	return readerAt, nil
+}
+
+// GetID returns ID
+func (readerAt *FileReaderAt) GetID() string {
+	return readerAt.id
+}
+
+// GetEntry returns Entry info
+func (readerAt *FileReaderAt) GetEntry() *Entry {
+	return readerAt.entry
+}
+
+// Size returns the size of the file
+func (readerAt *FileReaderAt) Size() int64 {
+	return readerAt.entry.Size
+}
+
+// ReadAt reads data from given offset, implements io.ReaderAt
+func (readerAt *FileReaderAt) ReadAt(buffer []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, xerrors.Errorf("invalid offset %d", offset)
+	}
+
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+
+	stream, err := readerAt.acquireStream()
+	if err != nil {
+		return 0, err
+	}
+
+	readLen, err := readerAt.readStream(stream, buffer, offset)
+	if err != nil && err != io.EOF {
+		// the stream may be broken
+		readerAt.discardStream(stream)
+		return readLen, err
+	}
+
+	readerAt.returnStream(stream)
+	return readLen, err
+}
+
+func (readerAt *FileReaderAt) readStream(stream *fileReaderAtStream, buffer []byte, offset int64) (int, error) {
+	if stream.offset != offset {
+		newOffset, err := irods_fs.SeekDataObject(stream.connection, stream.irodsFileHandle, offset, types.SeekSet)
+		if err != nil {
+			return 0, err
+		}
+
+		stream.offset = newOffset
+		if newOffset != offset {
+			return 0, xerrors.Errorf("failed to seek to %d", offset)
+		}
+	}
+
+	totalReadLen := 0
+	for totalReadLen < len(buffer) {
+		readLen, err := irods_fs.ReadDataObject(stream.connection, stream.irodsFileHandle, buffer[totalReadLen:])
+		totalReadLen += readLen
+		stream.offset += int64(readLen)
+
+		if err != nil {
+			return totalReadLen, err
+		}
+
+		if readLen == 0 {
+			break
+		}
+	}
+
+	if totalReadLen < len(buffer) {
+		return totalReadLen, io.EOF
+	}
+
+	return totalReadLen, nil
+}
+
+// acquireStream returns an idle stream, opens a new stream if none is idle and the limit allows
+func (readerAt *FileReaderAt) acquireStream() (*fileReaderAtStream, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "FileReaderAt",
+		"function": "acquireStream",
+	})
+
+	readerAt.mutex.Lock()
+	defer readerAt.mutex.Unlock()
+
+	var openErr error
+	for {
+		if readerAt.closed {
+			return nil, xerrors.Errorf("file %s is already closed", readerAt.entry.Path)
+		}
+
+		if len(readerAt.idleStreams) > 0 {
+			stream := readerAt.idleStreams[len(readerAt.idleStreams)-1]
+			readerAt.idleStreams = readerAt.idleStreams[:len(readerAt.idleStreams)-1]
+			return stream, nil
+		}
+
+		if openErr != nil && readerAt.streams == 0 {
+			return nil, openErr
+		}
+
+		if openErr == nil && readerAt.streams < readerAt.connectionMax {
+			readerAt.streams++
+			readerAt.mutex.Unlock()
+			stream, err := readerAt.openStream()
+			readerAt.mutex.Lock()
+
+			if err == nil {
+				return stream, nil
+			}
+
+			readerAt.streams--
+			openErr = err
+
+			// wait for other streams, e.g., no more connections available
+			logger.WithError(err).Debugf("failed to open a new stream for %s", readerAt.entry.Path)
+			continue
+		}
+
+		readerAt.streamCondition.Wait()
+	}
+}
+
+func (readerAt *FileReaderAt) openStream() (*fileReaderAtStream, error) {
+	fs := readerAt.filesystem
+
+	conn, err := fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+	if err != nil {
+		return nil, err
+	}
+
+	var handle *types.IRODSFileHandle
+	var offset int64
+	if len(readerAt.replicaToken) > 0 {
+		handle, offset, err = irods_fs.OpenDataObjectWithReplicaToken(conn, readerAt.entry.Path, readerAt.resource, string(types.FileOpenModeReadOnly), readerAt.replicaToken, readerAt.resourceHierarchy, readerAt.connectionMax, readerAt.entry.Size)
+	} else {
+		handle, offset, err = irods_fs.OpenDataObject(conn, readerAt.entry.Path, readerAt.resource, string(types.FileOpenModeReadOnly))
+	}
+
+	if err != nil {
+		fs.ioSession.ReturnConnection(conn)
+		return nil, err
+	}
+
+	return &fileReaderAtStream{
+		connection:      conn,
+		irodsFileHandle: handle,
+		offset:          offset,
+	}, nil
+}
+
+// closeStream closes the data object and returns the connection, must be called without holding the lock
+func (readerAt *FileReaderAt) closeStream(stream *fileReaderAtStream) error {
+	err := irods_fs.CloseDataObject(stream.connection, stream.irodsFileHandle)
+	if err != nil {
+		readerAt.filesystem.ioSession.DiscardConnection(stream.connection)
+		return err
+	}
+
+	readerAt.filesystem.ioSession.ReturnConnection(stream.connection)
+	return nil
+}
+
+func (readerAt *FileReaderAt) returnStream(stream *fileReaderAtStream) {
+	readerAt.mutex.Lock()
+
+	if readerAt.closed {
+		readerAt.streams--
+		readerAt.mutex.Unlock()
+
+		readerAt.closeStream(stream)
+		return
+	}
+
+	readerAt.idleStreams = append(readerAt.idleStreams, stream)
+	readerAt.streamCondition.Signal()
+	readerAt.mutex.Unlock()
+}
+
+// discardStream drops a stream that failed, its connection may be broken so it is not reused
+func (readerAt *FileReaderAt) discardStream(stream *fileReaderAtStream) {
+	readerAt.filesystem.ioSession.DiscardConnection(stream.connection)
+
+	readerAt.mutex.Lock()
+	defer readerAt.mutex.Unlock()
+
+	readerAt.streams--
+	readerAt.streamCondition.Broadcast()
+}
+
+// Close closes the file, reads in progress close their streams when they finish
+func (readerAt *FileReaderAt) Close() error {
+	readerAt.mutex.Lock()
+
+	if readerAt.closed {
+		readerAt.mutex.Unlock()
+		return nil
+	}
+
+	readerAt.closed = true
+	readerAt.streamCondition.Broadcast()
+
+	idleStreams := readerAt.idleStreams
+	readerAt.streams -= len(idleStreams)
+	readerAt.idleStreams = nil
+	readerAt.mutex.Unlock()
+
+	// closing talks to the server, do not block others
+	var closeErr error
+	for _, stream := range idleStreams {
+		err := readerAt.closeStream(stream)
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
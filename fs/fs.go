@@ -26,18 +26,23 @@ type FileSystem struct {
 	cachePropagation     *FileSystemCachePropagation
 	cacheEventHandlerMap *FilesystemCacheEventHandlerMap
 	fileHandleMap        *FileHandleMap
+	uploadRateLimiter    *util.RateLimiter
+	downloadRateLimiter  *util.RateLimiter
 }
 
 // NewFileSystem creates a new FileSystem
 func NewFileSystem(account *types.IRODSAccount, config *FileSystemConfig) (*FileSystem, error) {
-	ioSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, config.ConnectionMax, config.TCPBufferSize, config.StartNewTransaction)
+	uploadRateLimiter := util.NewRateLimiter(config.UploadBandwidthLimit)
+	downloadRateLimiter := util.NewRateLimiter(config.DownloadBandwidthLimit)
+
+	ioSessionConfig := config.getIOSessionConfig(uploadRateLimiter, downloadRateLimiter)
 	ioSession, err := session.NewIRODSSession(account, ioSessionConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	metaSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, FileSystemConnectionMetaDefault, config.TCPBufferSize, config.StartNewTransaction)
-	metaSession, err := session.NewIRODSSession(account, metaSessionConfig)
+	metaSessionConfig := config.getMetadataSessionConfig(uploadRateLimiter, downloadRateLimiter)
+	metaSession, err := session.NewIRODSSessionSharingConnectionPool(account, metaSessionConfig, ioSession)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +71,8 @@ func NewFileSystem(account *types.IRODSAccount, config *FileSystemConfig) (*File
 		cache:                cache,
 		cacheEventHandlerMap: NewFilesystemCacheEventHandlerMap(),
 		fileHandleMap:        NewFileHandleMap(),
+		uploadRateLimiter:    uploadRateLimiter,
+		downloadRateLimiter:  downloadRateLimiter,
 	}
 
 	cachePropagation := NewFileSystemCachePropagation(fs)
@@ -76,14 +83,17 @@ func NewFileSystem(account *types.IRODSAccount, config *FileSystemConfig) (*File
 
 // NewFileSystemWithAddressResolver creates a new FileSystem
 func NewFileSystemWithAddressResolver(account *types.IRODSAccount, config *FileSystemConfig, addressResolver session.AddressResolver) (*FileSystem, error) {
-	ioSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, config.ConnectionMax, config.TCPBufferSize, config.StartNewTransaction)
+	uploadRateLimiter := util.NewRateLimiter(config.UploadBandwidthLimit)
+	downloadRateLimiter := util.NewRateLimiter(config.DownloadBandwidthLimit)
+
+	ioSessionConfig := config.getIOSessionConfig(uploadRateLimiter, downloadRateLimiter)
 	ioSession, err := session.NewIRODSSessionWithAddressResolver(account, ioSessionConfig, addressResolver)
 	if err != nil {
 		return nil, err
 	}
 
-	metaSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, FileSystemConnectionMetaDefault, config.TCPBufferSize, config.StartNewTransaction)
-	metaSession, err := session.NewIRODSSessionWithAddressResolver(account, metaSessionConfig, addressResolver)
+	metaSessionConfig := config.getMetadataSessionConfig(uploadRateLimiter, downloadRateLimiter)
+	metaSession, err := session.NewIRODSSessionSharingConnectionPool(account, metaSessionConfig, ioSession)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +122,8 @@ func NewFileSystemWithAddressResolver(account *types.IRODSAccount, config *FileS
 		cache:                cache,
 		cacheEventHandlerMap: NewFilesystemCacheEventHandlerMap(),
 		fileHandleMap:        NewFileHandleMap(),
+		uploadRateLimiter:    uploadRateLimiter,
+		downloadRateLimiter:  downloadRateLimiter,
 	}
 
 	cachePropagation := NewFileSystemCachePropagation(fs)
@@ -123,14 +135,17 @@ func NewFileSystemWithAddressResolver(account *types.IRODSAccount, config *FileS
 // NewFileSystemWithDefault creates a new FileSystem with default configurations
 func NewFileSystemWithDefault(account *types.IRODSAccount, applicationName string) (*FileSystem, error) {
 	config := NewFileSystemConfigWithDefault(applicationName)
-	ioSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, config.ConnectionMax, config.TCPBufferSize, config.StartNewTransaction)
+	uploadRateLimiter := util.NewRateLimiter(config.UploadBandwidthLimit)
+	downloadRateLimiter := util.NewRateLimiter(config.DownloadBandwidthLimit)
+
+	ioSessionConfig := config.getIOSessionConfig(uploadRateLimiter, downloadRateLimiter)
 	ioSession, err := session.NewIRODSSession(account, ioSessionConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	metaSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, FileSystemConnectionMetaDefault, config.TCPBufferSize, config.StartNewTransaction)
-	metaSession, err := session.NewIRODSSession(account, metaSessionConfig)
+	metaSessionConfig := config.getMetadataSessionConfig(uploadRateLimiter, downloadRateLimiter)
+	metaSession, err := session.NewIRODSSessionSharingConnectionPool(account, metaSessionConfig, ioSession)
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +161,8 @@ func NewFileSystemWithDefault(account *types.IRODSAccount, applicationName strin
 		cache:                cache,
 		cacheEventHandlerMap: NewFilesystemCacheEventHandlerMap(),
 		fileHandleMap:        NewFileHandleMap(),
+		uploadRateLimiter:    uploadRateLimiter,
+		downloadRateLimiter:  downloadRateLimiter,
 	}
 
 	cachePropagation := NewFileSystemCachePropagation(fs)
@@ -157,13 +174,39 @@ func NewFileSystemWithDefault(account *types.IRODSAccount, applicationName strin
 // NewFileSystemWithSessionConfig creates a new FileSystem with custom session configurations
 func NewFileSystemWithSessionConfig(account *types.IRODSAccount, sessConfig *session.IRODSSessionConfig, addressResolver session.AddressResolver) (*FileSystem, error) {
 	config := NewFileSystemConfigWithDefault(sessConfig.ApplicationName)
-	ioSession, err := session.NewIRODSSessionWithAddressResolver(account, sessConfig, addressResolver)
+
+	uploadRateLimiter := sessConfig.SendRateLimiter
+	if uploadRateLimiter == nil {
+		uploadRateLimiter = util.NewRateLimiter(0)
+	}
+
+	downloadRateLimiter := sessConfig.RecvRateLimiter
+	if downloadRateLimiter == nil {
+		downloadRateLimiter = util.NewRateLimiter(0)
+	}
+
+	// the metadata session shares the pool, io requests leave connections for metadata requests
+	ioSessionConfig := *sessConfig
+	ioSessionConfig.ConnectionMax = sessConfig.ConnectionMax + FileSystemConnectionMetaDefault
+	ioSessionConfig.ConnectionMaxPerPriority = map[session.ConnectionPriority]int{
+		session.ConnectionPriorityIO: sessConfig.GetConnectionMaxForPriority(session.ConnectionPriorityIO),
+	}
+	ioSessionConfig.SendRateLimiter = uploadRateLimiter
+	ioSessionConfig.RecvRateLimiter = downloadRateLimiter
+	ioSessionConfig.ConnectionPriority = session.ConnectionPriorityIO
+
+	ioSession, err := session.NewIRODSSessionWithAddressResolver(account, &ioSessionConfig, addressResolver)
 	if err != nil {
 		return nil, err
 	}
 
-	metaSessionConfig := session.NewIRODSSessionConfig(config.ApplicationName, config.ConnectionErrorTimeout, config.ConnectionInitNumber, config.ConnectionLifespan, config.OperationTimeout, config.ConnectionIdleTimeout, FileSystemConnectionMetaDefault, config.TCPBufferSize, config.StartNewTransaction)
-	metaSession, err := session.NewIRODSSessionWithAddressResolver(account, metaSessionConfig, addressResolver)
+	// keep the custom session configurations, only the priority differs
+	metaSessionConfig := *sessConfig
+	metaSessionConfig.SendRateLimiter = uploadRateLimiter
+	metaSessionConfig.RecvRateLimiter = downloadRateLimiter
+	metaSessionConfig.ConnectionPriority = session.ConnectionPriorityMetadata
+
+	metaSession, err := session.NewIRODSSessionSharingConnectionPool(account, &metaSessionConfig, ioSession)
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +222,8 @@ func NewFileSystemWithSessionConfig(account *types.IRODSAccount, sessConfig *ses
 		cache:                cache,
 		cacheEventHandlerMap: NewFilesystemCacheEventHandlerMap(),
 		fileHandleMap:        NewFileHandleMap(),
+		uploadRateLimiter:    uploadRateLimiter,
+		downloadRateLimiter:  downloadRateLimiter,
 	}
 
 	cachePropagation := NewFileSystemCachePropagation(fs)
@@ -206,9 +251,19 @@ func (fs *FileSystem) GetID() string {
 	return fs.id
 }
 
+// GetAccount returns the account
+func (fs *FileSystem) GetAccount() *types.IRODSAccount {
+	return fs.account
+}
+
 // GetIOConnection returns irods connection for IO
 func (fs *FileSystem) GetIOConnection() (*connection.IRODSConnection, error) {
-	return fs.ioSession.AcquireConnection()
+	return fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+}
+
+// GetIOConnectionWithRequest returns irods connection for IO, waiting in the pool as described in the request
+func (fs *FileSystem) GetIOConnectionWithRequest(request *session.ConnectionRequest) (*connection.IRODSConnection, error) {
+	return fs.ioSession.AcquireConnectionWithRequest(request)
 }
 
 // ReturnIOConnection returns irods connection for IO back to session
@@ -218,7 +273,12 @@ func (fs *FileSystem) ReturnIOConnection(conn *connection.IRODSConnection) {
 
 // GetMetadataConnection returns irods connection for metadata operations
 func (fs *FileSystem) GetMetadataConnection() (*connection.IRODSConnection, error) {
-	return fs.metaSession.AcquireConnection()
+	return fs.metaSession.AcquireConnectionWithPriority(session.ConnectionPriorityMetadata)
+}
+
+// GetMetadataConnectionWithRequest returns irods connection for metadata operations, waiting in the pool as described in the request
+func (fs *FileSystem) GetMetadataConnectionWithRequest(request *session.ConnectionRequest) (*connection.IRODSConnection, error) {
+	return fs.metaSession.AcquireConnectionWithRequest(request)
 }
 
 // ReturnMetadataConnection returns irods connection for metadata operations back to session
@@ -228,18 +288,110 @@ func (fs *FileSystem) ReturnMetadataConnection(conn *connection.IRODSConnection)
 
 // ConnectionTotal counts current established connections
 func (fs *FileSystem) ConnectionTotal() int {
-	return fs.ioSession.ConnectionTotal() + fs.metaSession.ConnectionTotal()
+	// the metadata session shares the pool of the IO session
+	return fs.ioSession.ConnectionTotal()
+}
+
+// hasOpenFiles returns true if there are files opened
+func (fs *FileSystem) hasOpenFiles() bool {
+	return len(fs.fileHandleMap.List()) > 0
 }
 
-// GetServerVersion returns server version info
+// GetCircuitState returns the circuit state of the file system
+// it returns the worst state of metadata and IO sessions
+func (fs *FileSystem) GetCircuitState() session.CircuitState {
+	metaState := fs.metaSession.GetCircuitState()
+	ioState := fs.ioSession.GetCircuitState()
+
+	if metaState == session.CircuitStateOpen || ioState == session.CircuitStateOpen {
+		return session.CircuitStateOpen
+	}
+
+	if metaState == session.CircuitStateHalfOpen || ioState == session.CircuitStateHalfOpen {
+		return session.CircuitStateHalfOpen
+	}
+
+	return session.CircuitStateClosed
+}
+
+// AddCircuitBreakerCallback adds a callback that is called when circuit state of metadata or IO session changes
+func (fs *FileSystem) AddCircuitBreakerCallback(callback session.CircuitBreakerCallback) {
+	fs.metaSession.AddCircuitBreakerCallback(callback)
+	fs.ioSession.AddCircuitBreakerCallback(callback)
+}
+
+// GetEndpointStatus returns health status of catalog endpoints
+// metadata and IO sessions share the connection pool and its endpoints
+func (fs *FileSystem) GetEndpointStatus() []session.EndpointStatus {
+	return fs.ioSession.GetEndpointStatus()
+}
+
+// GetServerVersion returns server version info, the version of each endpoint is cached when connections are made.
+// With multiple endpoints, the oldest version is returned, so its capabilities are supported by all endpoints connected.
+// Use HasCapability of a connection to check the server the connection is made to.
 func (fs *FileSystem) GetServerVersion() (*types.IRODSVersion, error) {
+	version := fs.getOldestServerVersion()
+	if version != nil {
+		return version, nil
+	}
+
+	// no connections are made yet
 	conn, err := fs.metaSession.AcquireConnection()
 	if err != nil {
 		return nil, err
 	}
 	defer fs.metaSession.ReturnConnection(conn)
 
-	return conn.GetVersion(), nil
+	version = fs.getOldestServerVersion()
+	if version == nil {
+		version = conn.GetVersion()
+	}
+
+	if version == nil {
+		return nil, xerrors.Errorf("failed to get server version")
+	}
+
+	return version, nil
+}
+
+// getOldestServerVersion returns the oldest server version of endpoints, nil if no connections are made yet
+func (fs *FileSystem) getOldestServerVersion() *types.IRODSVersion {
+	var oldest *types.IRODSVersion
+	for _, status := range fs.GetEndpointStatus() {
+		if status.ServerVersion == nil {
+			continue
+		}
+
+		if oldest == nil || !status.ServerVersion.HasHigherVersionThan(oldest.GetReleaseVersion()) {
+			oldest = status.ServerVersion
+		}
+	}
+	return oldest
+}
+
+// GetServerInfo returns information of the server, including capabilities derived from its version
+func (fs *FileSystem) GetServerInfo() (*types.IRODSServerInfo, error) {
+	var serverInfo *types.IRODSServerInfo
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		serverInfo, queryErr = irods_fs.GetServerInfo(conn)
+		return queryErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return serverInfo, nil
+}
+
+// HasCapability returns true if all endpoints connected support the capability
+func (fs *FileSystem) HasCapability(capability types.IRODSServerCapability) bool {
+	version, err := fs.GetServerVersion()
+	if err != nil {
+		return false
+	}
+
+	return version.HasCapability(capability)
 }
 
 // SupportParallelUpload returns if the server supports parallel upload
@@ -247,6 +399,23 @@ func (fs *FileSystem) SupportParallelUpload() bool {
 	return fs.metaSession.SupportParallelUpload()
 }
 
+// SetBandwidthLimit changes bytes per second uploaded and downloaded by the file system, 0 means no limit
+// transfers in progress follow the new limits
+func (fs *FileSystem) SetBandwidthLimit(uploadLimit int64, downloadLimit int64) {
+	fs.uploadRateLimiter.SetLimit(uploadLimit)
+	fs.downloadRateLimiter.SetLimit(downloadLimit)
+}
+
+// GetBandwidthLimit returns bytes per second uploaded and downloaded by the file system, 0 means no limit
+func (fs *FileSystem) GetBandwidthLimit() (int64, int64) {
+	return fs.uploadRateLimiter.GetLimit(), fs.downloadRateLimiter.GetLimit()
+}
+
+// GetParallelTransferTuner returns the tuner choosing the number of tasks and block size of parallel transfers
+func (fs *FileSystem) GetParallelTransferTuner() *util.ParallelTransferTuner {
+	return fs.ioSession.GetParallelTransferTuner()
+}
+
 // GetMetrics returns metrics
 func (fs *FileSystem) GetMetrics() *metrics.IRODSMetrics {
 	ioMetrics := fs.ioSession.GetMetrics()
@@ -432,6 +601,31 @@ func (fs *FileSystem) RemoveFile(path string, force bool) error {
 	return nil
 }
 
+// UnregisterDir unregisters a dir from the catalog, physical files are not deleted
+func (fs *FileSystem) UnregisterDir(path string, recurse bool) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	err = irods_fs.UnregisterCollection(conn, irodsPath, recurse, false)
+	if err != nil {
+		return err
+	}
+
+	fs.invalidateCacheForDirRemove(irodsPath, recurse)
+	fs.cachePropagation.PropagateDirRemove(irodsPath)
+	return nil
+}
+
+// UnregisterFile unregisters a file from the catalog, physical files are not deleted
+func (fs *FileSystem) UnregisterFile(path string) error {
+	return fs.UnregisterFileReplica(path, -1)
+}
+
 // RenameDir renames a dir
 func (fs *FileSystem) RenameDir(srcPath string, destPath string) error {
 	irodsSrcPath := util.GetCorrectIRODSPath(srcPath)
@@ -742,6 +936,45 @@ func (fs *FileSystem) TruncateFile(path string, size int64) error {
 	return nil
 }
 
+// SetModifyTime changes the modification time of a file
+func (fs *FileSystem) SetModifyTime(path string, modifyTime time.Time) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	err = irods_fs.ModifyDataObjectModifyTime(conn, irodsPath, modifyTime)
+	if err != nil {
+		return err
+	}
+
+	fs.invalidateCacheForFileUpdate(irodsPath)
+	fs.cachePropagation.PropagateFileUpdate(irodsPath)
+	return nil
+}
+
+// SetDirModifyTime changes the modification time of a collection
+func (fs *FileSystem) SetDirModifyTime(path string, modifyTime time.Time) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	err = irods_fs.ModifyCollectionModifyTime(conn, irodsPath, modifyTime)
+	if err != nil {
+		return err
+	}
+
+	fs.cache.RemoveEntryCache(irodsPath)
+	return nil
+}
+
 // ReplicateFile replicates a file
 func (fs *FileSystem) ReplicateFile(path string, resource string, update bool) error {
 	irodsPath := util.GetCorrectIRODSPath(path)
@@ -763,10 +996,16 @@ func (fs *FileSystem) ReplicateFile(path string, resource string, update bool) e
 }
 
 // OpenFile opens an existing file for read/write
+// reads and writes are buffered if FileBuffer is set in the config
 func (fs *FileSystem) OpenFile(path string, resource string, mode string) (*FileHandle, error) {
+	return fs.OpenFileWithBuffer(path, resource, mode, fs.config.FileBuffer)
+}
+
+// OpenFileWithBuffer opens an existing file for read/write with the buffer config, nil disables buffering
+func (fs *FileSystem) OpenFileWithBuffer(path string, resource string, mode string, bufferConfig *FileBufferConfig) (*FileHandle, error) {
 	irodsPath := util.GetCorrectIRODSPath(path)
 
-	conn, err := fs.ioSession.AcquireConnection()
+	conn, err := fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
 	if err != nil {
 		return nil, err
 	}
@@ -814,15 +1053,25 @@ func (fs *FileSystem) OpenFile(path string, resource string, mode string) (*File
 		openMode:        types.FileOpenMode(mode),
 	}
 
+	if bufferConfig != nil {
+		fileHandle.buffer = newFileBuffer(bufferConfig, offset)
+	}
+
 	fs.fileHandleMap.Add(fileHandle)
 	return fileHandle, nil
 }
 
 // CreateFile opens a new file for write
+// reads and writes are buffered if FileBuffer is set in the config
 func (fs *FileSystem) CreateFile(path string, resource string, mode string) (*FileHandle, error) {
+	return fs.CreateFileWithBuffer(path, resource, mode, fs.config.FileBuffer)
+}
+
+// CreateFileWithBuffer opens a new file for write with the buffer config, nil disables buffering
+func (fs *FileSystem) CreateFileWithBuffer(path string, resource string, mode string, bufferConfig *FileBufferConfig) (*FileHandle, error) {
 	irodsPath := util.GetCorrectIRODSPath(path)
 
-	conn, err := fs.ioSession.AcquireConnection()
+	conn, err := fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
 	if err != nil {
 		return nil, err
 	}
@@ -865,6 +1114,10 @@ func (fs *FileSystem) CreateFile(path string, resource string, mode string) (*Fi
 		openMode:        types.FileOpenMode(mode),
 	}
 
+	if bufferConfig != nil {
+		fileHandle.buffer = newFileBuffer(bufferConfig, offset)
+	}
+
 	fs.fileHandleMap.Add(fileHandle)
 	fs.invalidateCacheForFileCreate(irodsPath)
 	fs.cachePropagation.PropagateFileCreate(irodsPath)
@@ -875,13 +1128,12 @@ func (fs *FileSystem) CreateFile(path string, resource string, mode string) (*Fi
 // getCollectionNoCache returns collection entry
 func (fs *FileSystem) getCollectionNoCache(path string) (*Entry, error) {
 	// retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	collection, err := irods_fs.GetCollection(conn, path)
+	var collection *types.IRODSCollection
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		collection, queryErr = irods_fs.GetCollection(conn, path)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -996,13 +1248,18 @@ func (fs *FileSystem) listEntries(collection *types.IRODSCollection) ([]*Entry,
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
+	var collections []*types.IRODSCollection
+	var dataobjects []*types.IRODSDataObject
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		collections, queryErr = irods_fs.ListSubCollections(conn, collection.Path)
+		if queryErr != nil {
+			return queryErr
+		}
 
-	collections, err := irods_fs.ListSubCollections(conn, collection.Path)
+		dataobjects, queryErr = irods_fs.ListDataObjectsMasterReplica(conn, collection)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1018,11 +1275,6 @@ func (fs *FileSystem) listEntries(collection *types.IRODSCollection) ([]*Entry,
 		fs.cache.AddEntryCache(entry)
 	}
 
-	dataobjects, err := irods_fs.ListDataObjectsMasterReplica(conn, collection)
-	if err != nil {
-		return nil, err
-	}
-
 	for _, dataobject := range dataobjects {
 		if len(dataobject.Replicas) == 0 {
 			continue
@@ -1099,13 +1351,12 @@ func (fs *FileSystem) getDataObjectNoCache(path string) (*Entry, error) {
 
 	collection := fs.getCollectionFromEntry(collectionEntry)
 
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	dataobject, err := irods_fs.GetDataObjectMasterReplica(conn, collection, util.GetIRODSPathFileName(path))
+	var dataobject *types.IRODSDataObject
+	err = fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		dataobject, queryErr = irods_fs.GetDataObjectMasterReplica(conn, collection, util.GetIRODSPathFileName(path))
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
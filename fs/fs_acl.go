@@ -3,6 +3,7 @@ package fs
 import (
 	"fmt"
 
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/phdavis1027/go-irodsclient/irods/util"
@@ -79,13 +80,12 @@ func (fs *FileSystem) ListDirACLs(path string) ([]*types.IRODSAccess, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	accesses, err := irods_fs.ListCollectionAccesses(conn, irodsPath)
+	var accesses []*types.IRODSAccess
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		accesses, queryErr = irods_fs.ListCollectionAccesses(conn, irodsPath)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -151,12 +151,6 @@ func (fs *FileSystem) ListFileACLs(path string) ([]*types.IRODSAccess, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
 	collectionEntry, err := fs.getCollection(util.GetIRODSPathDirname(irodsPath))
 	if err != nil {
 		return nil, err
@@ -164,7 +158,12 @@ func (fs *FileSystem) ListFileACLs(path string) ([]*types.IRODSAccess, error) {
 
 	collection := fs.getCollectionFromEntry(collectionEntry)
 
-	accesses, err := irods_fs.ListDataObjectAccesses(conn, collection, util.GetIRODSPathFileName(irodsPath))
+	var accesses []*types.IRODSAccess
+	err = fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		accesses, queryErr = irods_fs.ListDataObjectAccesses(conn, collection, util.GetIRODSPathFileName(irodsPath))
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -340,3 +339,43 @@ func (fs *FileSystem) listACLsForEntries(collection *types.IRODSCollection) ([]*
 
 	return accesses, nil
 }
+
+// ChangeACL changes access of a user to the path, recursive is used only for collections
+func (fs *FileSystem) ChangeACL(path string, access types.IRODSAccessLevelType, userName string, zoneName string, recursive bool) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	stat, err := fs.Stat(irodsPath)
+	if err != nil {
+		return err
+	}
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	if stat.Type == DirectoryEntry {
+		err = irods_fs.ChangeCollectionAccess(conn, irodsPath, access, userName, zoneName, recursive, false)
+		if err != nil {
+			return err
+		}
+
+		if recursive {
+			fs.cache.ClearACLsCache()
+		} else {
+			fs.cache.RemoveACLsCache(irodsPath)
+		}
+		return nil
+	} else if stat.Type == FileEntry {
+		err = irods_fs.ChangeDataObjectAccess(conn, irodsPath, access, userName, zoneName, false)
+		if err != nil {
+			return err
+		}
+
+		fs.cache.RemoveACLsCache(irodsPath)
+		return nil
+	}
+
+	return xerrors.Errorf("unknown type - %s", stat.Type)
+}
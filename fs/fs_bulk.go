@@ -2,6 +2,7 @@ package fs
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -81,7 +82,14 @@ func (fs *FileSystem) DownloadFileResumable(irodsPath string, resource string, l
 }
 
 // DownloadFileToBuffer downloads a file to buffer
-func (fs *FileSystem) DownloadFileToBuffer(irodsPath string, resource string, buffer bytes.Buffer, callback common.TrackerCallBack) error {
+func (fs *FileSystem) DownloadFileToBuffer(irodsPath string, resource string, buffer *bytes.Buffer, callback common.TrackerCallBack) error {
+	return fs.DownloadToWriter(irodsPath, resource, buffer, 1, callback)
+}
+
+// DownloadToWriter downloads a file to the writer
+// If the writer is an io.WriterAt (e.g., os.File), ranges are downloaded in parallel with n (taskNum) tasks.
+// taskNum <= 0 decides the number of tasks from the file size.
+func (fs *FileSystem) DownloadToWriter(irodsPath string, resource string, writer io.Writer, taskNum int, callback common.TrackerCallBack) error {
 	irodsSrcPath := util.GetCorrectIRODSPath(irodsPath)
 
 	srcStat, err := fs.Stat(irodsSrcPath)
@@ -93,7 +101,7 @@ func (fs *FileSystem) DownloadFileToBuffer(irodsPath string, resource string, bu
 		return xerrors.Errorf("cannot download a collection %s", irodsSrcPath)
 	}
 
-	return irods_fs.DownloadDataObjectToBuffer(fs.ioSession, irodsSrcPath, resource, buffer, srcStat.Size, callback)
+	return irods_fs.DownloadDataObjectToWriter(fs.ioSession, irodsSrcPath, resource, writer, srcStat.Size, taskNum, callback)
 }
 
 // DownloadFileParallel downloads a file to local in parallel
@@ -246,7 +254,16 @@ func (fs *FileSystem) UploadFile(localPath string, irodsPath string, resource st
 }
 
 // UploadFileFromBuffer uploads buffer data to irods
-func (fs *FileSystem) UploadFileFromBuffer(buffer bytes.Buffer, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+func (fs *FileSystem) UploadFileFromBuffer(buffer *bytes.Buffer, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+	reader := bytes.NewReader(buffer.Bytes())
+	return fs.UploadFromReader(reader, reader.Size(), irodsPath, resource, 1, replicate, callback)
+}
+
+// UploadFromReader uploads data read from the reader to irods
+// size can be irods_fs.DataObjectLengthUnknown, then data is read until EOF.
+// If the reader is an io.ReaderAt (e.g., os.File) and the size is known, data is uploaded in parallel with n (taskNum) tasks.
+// taskNum <= 0 decides the number of tasks from the size.
+func (fs *FileSystem) UploadFromReader(reader io.Reader, size int64, irodsPath string, resource string, taskNum int, replicate bool, callback common.TrackerCallBack) error {
 	irodsDestPath := util.GetCorrectIRODSPath(irodsPath)
 
 	irodsFilePath := irodsDestPath
@@ -267,7 +284,7 @@ func (fs *FileSystem) UploadFileFromBuffer(buffer bytes.Buffer, irodsPath string
 		}
 	}
 
-	err = irods_fs.UploadDataObjectFromBuffer(fs.ioSession, buffer, irodsFilePath, resource, replicate, callback)
+	err = irods_fs.UploadDataObjectFromReader(fs.ioSession, reader, size, irodsFilePath, resource, taskNum, replicate, callback)
 	if err != nil {
 		return err
 	}
@@ -324,6 +341,88 @@ func (fs *FileSystem) UploadFileParallel(localPath string, irodsPath string, res
 	return nil
 }
 
+// UploadFileResumable uploads a local file to irods with support of transfer resume
+// transfer status is kept in UploadTransferStatusDir of the config
+// if verifyContent is true, data sent by the previous transfer is verified with the checksum before resuming
+func (fs *FileSystem) UploadFileResumable(localPath string, irodsPath string, resource string, replicate bool, verifyContent bool, callback common.TrackerCallBack) error {
+	irodsFilePath, err := fs.getUploadDestPath(localPath, irodsPath)
+	if err != nil {
+		return err
+	}
+
+	localSrcPath := util.GetCorrectLocalPath(localPath)
+
+	err = irods_fs.UploadDataObjectResumable(fs.ioSession, localSrcPath, irodsFilePath, resource, fs.config.UploadTransferStatusDir, replicate, verifyContent, callback)
+	if err != nil {
+		return err
+	}
+
+	fs.invalidateCacheForFileCreate(irodsFilePath)
+	fs.cachePropagation.PropagateFileCreate(irodsFilePath)
+	return nil
+}
+
+// UploadFileParallelResumable uploads a local file to irods in parallel with support of transfer resume
+// transfer status is kept in UploadTransferStatusDir of the config
+// if verifyContent is true, data sent by the previous transfer is verified with the checksum before resuming
+func (fs *FileSystem) UploadFileParallelResumable(localPath string, irodsPath string, resource string, taskNum int, replicate bool, verifyContent bool, callback common.TrackerCallBack) error {
+	irodsFilePath, err := fs.getUploadDestPath(localPath, irodsPath)
+	if err != nil {
+		return err
+	}
+
+	localSrcPath := util.GetCorrectLocalPath(localPath)
+
+	err = irods_fs.UploadDataObjectParallelResumable(fs.ioSession, localSrcPath, irodsFilePath, resource, fs.config.UploadTransferStatusDir, taskNum, replicate, verifyContent, callback)
+	if err != nil {
+		return err
+	}
+
+	fs.invalidateCacheForFileCreate(irodsFilePath)
+	fs.cachePropagation.PropagateFileCreate(irodsFilePath)
+	return nil
+}
+
+// getUploadDestPath returns the iRODS path of a data object to upload the local file to
+func (fs *FileSystem) getUploadDestPath(localPath string, irodsPath string) (string, error) {
+	localSrcPath := util.GetCorrectLocalPath(localPath)
+	irodsDestPath := util.GetCorrectIRODSPath(irodsPath)
+
+	irodsFilePath := irodsDestPath
+
+	srcStat, err := os.Stat(localSrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// file not exists
+			return "", xerrors.Errorf("failed to find a file for local path %s: %w", localSrcPath, types.NewFileNotFoundError(localSrcPath))
+		}
+		return "", err
+	}
+
+	if srcStat.IsDir() {
+		return "", xerrors.Errorf("failed to find a file for local path %s, the path is for a directory: %w", localSrcPath, types.NewFileNotFoundError(localSrcPath))
+	}
+
+	destStat, err := fs.Stat(irodsDestPath)
+	if err != nil {
+		if !types.IsFileNotFoundError(err) {
+			return "", err
+		}
+	} else {
+		switch destStat.Type {
+		case FileEntry:
+			// do nothing
+		case DirectoryEntry:
+			localFileName := filepath.Base(localSrcPath)
+			irodsFilePath = util.MakeIRODSPath(irodsDestPath, localFileName)
+		default:
+			return "", xerrors.Errorf("unknown entry type %s", destStat.Type)
+		}
+	}
+
+	return irodsFilePath, nil
+}
+
 // UploadFileParallelRedirectToResource uploads a file from local to resource server in parallel
 func (fs *FileSystem) UploadFileParallelRedirectToResource(localPath string, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
 	localSrcPath := util.GetCorrectLocalPath(localPath)
@@ -0,0 +1,600 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DirTransferWorkersDefault is a default number of files transferred concurrently
+	DirTransferWorkersDefault = 4
+)
+
+// OverwritePolicy decides whether an existing file at the destination is overwritten
+type OverwritePolicy string
+
+const (
+	// OverwriteAlways always overwrites existing files
+	OverwriteAlways OverwritePolicy = "always"
+	// OverwriteNever never overwrites existing files
+	OverwriteNever OverwritePolicy = "never"
+	// OverwriteIfNewer overwrites existing files if the source is modified after the destination
+	OverwriteIfNewer OverwritePolicy = "if_newer"
+	// OverwriteIfDifferentSize overwrites existing files if sizes differ
+	OverwriteIfDifferentSize OverwritePolicy = "if_different_size"
+	// OverwriteIfDifferentChecksum overwrites existing files if sizes or checksums differ
+	OverwriteIfDifferentChecksum OverwritePolicy = "if_different_checksum"
+)
+
+// SymlinkPolicy decides how local symbolic links are handled
+type SymlinkPolicy string
+
+const (
+	// SymlinkFollow transfers files and directories symbolic links point to
+	// downloads never write through symbolic links at the destination, the links are replaced with downloaded files
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkSkip skips symbolic links, including symbolic links at the destination of downloads
+	SymlinkSkip SymlinkPolicy = "skip"
+)
+
+// FileTransferStatus is a result status of a file transfer
+type FileTransferStatus string
+
+const (
+	// FileTransferStatusTransferred means the file is transferred
+	FileTransferStatusTransferred FileTransferStatus = "transferred"
+	// FileTransferStatusSkipped means the file is not transferred, e.g., by the overwrite policy
+	FileTransferStatusSkipped FileTransferStatus = "skipped"
+	// FileTransferStatusFailed means the file transfer failed
+	FileTransferStatusFailed FileTransferStatus = "failed"
+)
+
+// FileTransferResult is a result of a file transfer in a directory transfer
+type FileTransferResult struct {
+	SourcePath string
+	DestPath   string
+	Size       int64
+	Status     FileTransferStatus
+	Reason     string   // why the file is skipped
+	Error      error    // why the file transfer failed, or why the modification time of a transferred file is not kept
+	Warnings   []string // problems not failing the transfer, e.g., ACLs of users unknown at the destination
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// DirTransferReport is a report of a directory transfer
+type DirTransferReport struct {
+	Results []*FileTransferResult
+}
+
+// Transferred returns results of files transferred
+func (report *DirTransferReport) Transferred() []*FileTransferResult {
+	return report.filter(FileTransferStatusTransferred)
+}
+
+// Skipped returns results of files skipped
+func (report *DirTransferReport) Skipped() []*FileTransferResult {
+	return report.filter(FileTransferStatusSkipped)
+}
+
+// Failed returns results of files failed
+func (report *DirTransferReport) Failed() []*FileTransferResult {
+	return report.filter(FileTransferStatusFailed)
+}
+
+// TransferredBytes returns the total size of files transferred
+func (report *DirTransferReport) TransferredBytes() int64 {
+	total := int64(0)
+	for _, result := range report.Transferred() {
+		total += result.Size
+	}
+	return total
+}
+
+func (report *DirTransferReport) filter(status FileTransferStatus) []*FileTransferResult {
+	results := []*FileTransferResult{}
+	for _, result := range report.Results {
+		if result.Status == status {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// DirTransferConfig is a configuration of directory transfers
+type DirTransferConfig struct {
+	// number of files transferred concurrently
+	Workers int
+	// glob patterns (path.Match) of files to transfer, matched against the relative path and the name, empty includes all
+	Include []string
+	// glob patterns of files and directories not to transfer, matched against the relative path and the name
+	Exclude []string
+	// how existing files at the destination are handled
+	Overwrite OverwritePolicy
+	// how local symbolic links are handled
+	Symlink SymlinkPolicy
+	// resource to use, empty uses the default resource
+	Resource string
+	// number of parallel tasks per file, 1 transfers a file over a connection, 0 decides it from the file size
+	TaskNum int
+	// called when each file is done, may be called concurrently
+	ResultCallback func(result *FileTransferResult)
+}
+
+// NewDirTransferConfigWithDefault creates a DirTransferConfig with default settings
+func NewDirTransferConfigWithDefault() *DirTransferConfig {
+	return &DirTransferConfig{
+		Workers:        DirTransferWorkersDefault,
+		Include:        []string{},
+		Exclude:        []string{},
+		Overwrite:      OverwriteAlways,
+		Symlink:        SymlinkFollow,
+		Resource:       "",
+		TaskNum:        1,
+		ResultCallback: nil,
+	}
+}
+
+// matchPatterns returns true if the relative path or its name matches one of the patterns
+func matchPatterns(patterns []string, relPath string) bool {
+	name := path.Base(relPath)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (config *DirTransferConfig) isExcluded(relPath string) bool {
+	return matchPatterns(config.Exclude, relPath)
+}
+
+func (config *DirTransferConfig) isIncluded(relPath string) bool {
+	if len(config.Include) == 0 {
+		return true
+	}
+	return matchPatterns(config.Include, relPath)
+}
+
+// dirTransferTask is a file to transfer
+type dirTransferTask struct {
+	result   *FileTransferResult
+	transfer func(result *FileTransferResult)
+}
+
+// runDirTransferTasks runs tasks with workers, results are kept in task order
+func runDirTransferTasks(tasks []*dirTransferTask, config *DirTransferConfig) *DirTransferReport {
+	workers := config.Workers
+	if workers <= 0 {
+		workers = DirTransferWorkersDefault
+	}
+
+	taskChan := make(chan *dirTransferTask, len(tasks))
+	for _, task := range tasks {
+		taskChan <- task
+	}
+	close(taskChan)
+
+	waitGroup := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for task := range taskChan {
+				if task.transfer != nil {
+					task.result.StartTime = time.Now()
+					task.transfer(task.result)
+					task.result.EndTime = time.Now()
+				}
+
+				if config.ResultCallback != nil {
+					config.ResultCallback(task.result)
+				}
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	report := &DirTransferReport{
+		Results: make([]*FileTransferResult, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		report.Results = append(report.Results, task.result)
+	}
+	return report
+}
+
+// UploadDir uploads a local directory tree to irods, files in localPath are put in irodsPath
+// missing collections are created. Failures of individual files are recorded in the report,
+// an error is returned only if the transfer cannot start.
+func (fs *FileSystem) UploadDir(localPath string, irodsPath string, config *DirTransferConfig) (*DirTransferReport, error) {
+	localSrcPath := util.GetCorrectLocalPath(localPath)
+	irodsDestPath := util.GetCorrectIRODSPath(irodsPath)
+
+	if config == nil {
+		config = NewDirTransferConfigWithDefault()
+	}
+
+	srcStat, err := os.Stat(localSrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, xerrors.Errorf("failed to find a directory for local path %s: %w", localSrcPath, types.NewFileNotFoundError(localSrcPath))
+		}
+		return nil, err
+	}
+
+	if !srcStat.IsDir() {
+		return nil, xerrors.Errorf("local path %s is not a directory", localSrcPath)
+	}
+
+	if !fs.ExistsDir(irodsDestPath) {
+		err = fs.MakeDir(irodsDestPath, true)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to make a collection %s: %w", irodsDestPath, err)
+		}
+	}
+
+	tasks := []*dirTransferTask{}
+	visited := map[string]bool{}
+	fs.collectUploadTasks(localSrcPath, irodsDestPath, "", config, visited, &tasks)
+
+	return runDirTransferTasks(tasks, config), nil
+}
+
+func (fs *FileSystem) collectUploadTasks(localDir string, irodsDir string, relDir string, config *DirTransferConfig, visited map[string]bool, tasks *[]*dirTransferTask) {
+	// avoid loops through symbolic links
+	realDir, err := filepath.EvalSymlinks(localDir)
+	if err == nil {
+		if visited[realDir] {
+			return
+		}
+		visited[realDir] = true
+	}
+
+	dirEntries, err := os.ReadDir(localDir)
+	if err != nil {
+		*tasks = append(*tasks, newFailedDirTransferTask(localDir, irodsDir, xerrors.Errorf("failed to read directory %s: %w", localDir, err)))
+		return
+	}
+
+	for _, dirEntry := range dirEntries {
+		localEntryPath := filepath.Join(localDir, dirEntry.Name())
+		irodsEntryPath := util.MakeIRODSPath(irodsDir, dirEntry.Name())
+		relPath := path.Join(relDir, dirEntry.Name())
+
+		if config.isExcluded(relPath) {
+			continue
+		}
+
+		isSymlink := dirEntry.Type()&os.ModeSymlink != 0
+		if isSymlink && config.Symlink == SymlinkSkip {
+			*tasks = append(*tasks, newSkippedDirTransferTask(localEntryPath, irodsEntryPath, 0, "symbolic link"))
+			continue
+		}
+
+		// follow symbolic links
+		stat, err := os.Stat(localEntryPath)
+		if err != nil {
+			*tasks = append(*tasks, newFailedDirTransferTask(localEntryPath, irodsEntryPath, xerrors.Errorf("failed to stat %s: %w", localEntryPath, err)))
+			continue
+		}
+
+		if stat.IsDir() {
+			if !fs.ExistsDir(irodsEntryPath) {
+				err = fs.MakeDir(irodsEntryPath, true)
+				if err != nil {
+					*tasks = append(*tasks, newFailedDirTransferTask(localEntryPath, irodsEntryPath, xerrors.Errorf("failed to make a collection %s: %w", irodsEntryPath, err)))
+					continue
+				}
+			}
+
+			fs.collectUploadTasks(localEntryPath, irodsEntryPath, relPath, config, visited, tasks)
+			continue
+		}
+
+		if !stat.Mode().IsRegular() || !config.isIncluded(relPath) {
+			continue
+		}
+
+		result := &FileTransferResult{
+			SourcePath: localEntryPath,
+			DestPath:   irodsEntryPath,
+			Size:       stat.Size(),
+		}
+
+		*tasks = append(*tasks, &dirTransferTask{
+			result: result,
+			transfer: func(result *FileTransferResult) {
+				fs.uploadDirFile(stat, result, config)
+			},
+		})
+	}
+}
+
+func (fs *FileSystem) uploadDirFile(srcStat os.FileInfo, result *FileTransferResult, config *DirTransferConfig) {
+	destEntry, err := fs.Stat(result.DestPath)
+	if err != nil && !types.IsFileNotFoundError(err) {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	if err == nil {
+		if destEntry.IsDir() {
+			result.Status = FileTransferStatusFailed
+			result.Error = xerrors.Errorf("destination %s is a collection", result.DestPath)
+			return
+		}
+
+		overwrite, reason, err := fs.shouldOverwrite(config.Overwrite, srcStat.Size(), srcStat.ModTime(), destEntry.Size, destEntry.ModifyTime, func(algorithm types.ChecksumAlgorithm) ([]byte, error) {
+			return util.HashLocalFile(result.SourcePath, string(algorithm))
+		}, destEntry)
+		if err != nil {
+			result.Status = FileTransferStatusFailed
+			result.Error = err
+			return
+		}
+
+		if !overwrite {
+			result.Status = FileTransferStatusSkipped
+			result.Reason = reason
+			return
+		}
+	}
+
+	err = fs.UploadFileParallel(result.SourcePath, result.DestPath, config.Resource, config.TaskNum, false, nil)
+	if err != nil {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	result.Status = FileTransferStatusTransferred
+}
+
+// DownloadDir downloads a collection tree to local, files in irodsPath are put in localPath
+// missing directories are created. Failures of individual files are recorded in the report,
+// an error is returned only if the transfer cannot start.
+func (fs *FileSystem) DownloadDir(irodsPath string, localPath string, config *DirTransferConfig) (*DirTransferReport, error) {
+	irodsSrcPath := util.GetCorrectIRODSPath(irodsPath)
+	localDestPath := util.GetCorrectLocalPath(localPath)
+
+	if config == nil {
+		config = NewDirTransferConfigWithDefault()
+	}
+
+	srcEntry, err := fs.Stat(irodsSrcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !srcEntry.IsDir() {
+		return nil, xerrors.Errorf("irods path %s is not a collection", irodsSrcPath)
+	}
+
+	err = os.MkdirAll(localDestPath, 0755)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to make a directory %s: %w", localDestPath, err)
+	}
+
+	tasks := []*dirTransferTask{}
+	fs.collectDownloadTasks(irodsSrcPath, localDestPath, "", config, &tasks)
+
+	return runDirTransferTasks(tasks, config), nil
+}
+
+func (fs *FileSystem) collectDownloadTasks(irodsDir string, localDir string, relDir string, config *DirTransferConfig, tasks *[]*dirTransferTask) {
+	entries, err := fs.List(irodsDir)
+	if err != nil {
+		*tasks = append(*tasks, newFailedDirTransferTask(irodsDir, localDir, xerrors.Errorf("failed to list collection %s: %w", irodsDir, err)))
+		return
+	}
+
+	for _, entry := range entries {
+		localEntryPath := filepath.Join(localDir, entry.Name)
+		relPath := path.Join(relDir, entry.Name)
+
+		if config.isExcluded(relPath) {
+			continue
+		}
+
+		if entry.IsDir() {
+			err = os.MkdirAll(localEntryPath, 0755)
+			if err != nil {
+				*tasks = append(*tasks, newFailedDirTransferTask(entry.Path, localEntryPath, xerrors.Errorf("failed to make a directory %s: %w", localEntryPath, err)))
+				continue
+			}
+
+			fs.collectDownloadTasks(entry.Path, localEntryPath, relPath, config, tasks)
+			continue
+		}
+
+		if !config.isIncluded(relPath) {
+			continue
+		}
+
+		srcEntry := entry
+		result := &FileTransferResult{
+			SourcePath: entry.Path,
+			DestPath:   localEntryPath,
+			Size:       entry.Size,
+		}
+
+		*tasks = append(*tasks, &dirTransferTask{
+			result: result,
+			transfer: func(result *FileTransferResult) {
+				fs.downloadDirFile(srcEntry, result, config)
+			},
+		})
+	}
+}
+
+func (fs *FileSystem) downloadDirFile(srcEntry *Entry, result *FileTransferResult, config *DirTransferConfig) {
+	destStat, err := os.Lstat(result.DestPath)
+	if err != nil && !os.IsNotExist(err) {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	if err == nil {
+		if destStat.IsDir() {
+			result.Status = FileTransferStatusFailed
+			result.Error = xerrors.Errorf("destination %s is a directory", result.DestPath)
+			return
+		}
+
+		if destStat.Mode()&os.ModeSymlink != 0 {
+			if config.Symlink == SymlinkSkip {
+				result.Status = FileTransferStatusSkipped
+				result.Reason = "symbolic link"
+				return
+			}
+
+			// the link is not comparable with the source
+			if config.Overwrite == OverwriteNever {
+				result.Status = FileTransferStatusSkipped
+				result.Reason = "destination exists"
+				return
+			}
+
+			// never write through the link, it may point outside of the destination
+			err = os.Remove(result.DestPath)
+			if err != nil {
+				result.Status = FileTransferStatusFailed
+				result.Error = xerrors.Errorf("failed to remove symbolic link %s: %w", result.DestPath, err)
+				return
+			}
+		} else {
+			overwrite, reason, err := fs.shouldOverwrite(config.Overwrite, srcEntry.Size, srcEntry.ModifyTime, destStat.Size(), destStat.ModTime(), func(algorithm types.ChecksumAlgorithm) ([]byte, error) {
+				return util.HashLocalFile(result.DestPath, string(algorithm))
+			}, srcEntry)
+			if err != nil {
+				result.Status = FileTransferStatusFailed
+				result.Error = err
+				return
+			}
+
+			if !overwrite {
+				result.Status = FileTransferStatusSkipped
+				result.Reason = reason
+				return
+			}
+		}
+	}
+
+	err = irods_fs.DownloadDataObjectParallel(fs.ioSession, srcEntry.Path, config.Resource, result.DestPath, srcEntry.Size, config.TaskNum, nil)
+	if err != nil {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	result.Status = FileTransferStatusTransferred
+
+	// keep modification time, so if_newer works in both directions
+	err = os.Chtimes(result.DestPath, time.Now(), srcEntry.ModifyTime)
+	if err != nil {
+		result.Error = xerrors.Errorf("failed to keep modification time of %s: %w", result.DestPath, err)
+	}
+}
+
+// shouldOverwrite decides whether to overwrite the destination, returns a reason if not
+// localHash computes a hash of the local file, irodsEntry is the data object being compared
+func (fs *FileSystem) shouldOverwrite(policy OverwritePolicy, srcSize int64, srcModTime time.Time, destSize int64, destModTime time.Time, localHash func(algorithm types.ChecksumAlgorithm) ([]byte, error), irodsEntry *Entry) (bool, string, error) {
+	switch policy {
+	case OverwriteAlways, "":
+		return true, "", nil
+	case OverwriteNever:
+		return false, "destination exists", nil
+	case OverwriteIfNewer:
+		if srcModTime.After(destModTime) {
+			return true, "", nil
+		}
+		return false, "destination is not older", nil
+	case OverwriteIfDifferentSize:
+		if srcSize != destSize {
+			return true, "", nil
+		}
+		return false, "same size", nil
+	case OverwriteIfDifferentChecksum:
+		if srcSize != destSize {
+			return true, "", nil
+		}
+
+		checksum, err := fs.getDataObjectChecksum(irodsEntry)
+		if err != nil {
+			return false, "", err
+		}
+
+		localChecksum, err := localHash(checksum.Algorithm)
+		if err != nil {
+			return false, "", err
+		}
+
+		if bytes.Equal(checksum.Checksum, localChecksum) {
+			return false, "same checksum", nil
+		}
+		return true, "", nil
+	default:
+		return false, "", xerrors.Errorf("unknown overwrite policy %s", policy)
+	}
+}
+
+// getDataObjectChecksum returns the checksum of the data object, computes it if not in the catalog
+func (fs *FileSystem) getDataObjectChecksum(entry *Entry) (*types.IRODSChecksum, error) {
+	if len(entry.CheckSum) > 0 && entry.CheckSumAlgorithm != types.ChecksumAlgorithmUnknown {
+		return &types.IRODSChecksum{
+			Algorithm: entry.CheckSumAlgorithm,
+			Checksum:  entry.CheckSum,
+		}, nil
+	}
+
+	conn, err := fs.ioSession.AcquireConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.ioSession.ReturnConnection(conn)
+
+	return irods_fs.GetDataObjectChecksum(conn, entry.Path, "")
+}
+
+func newSkippedDirTransferTask(srcPath string, destPath string, size int64, reason string) *dirTransferTask {
+	return &dirTransferTask{
+		result: &FileTransferResult{
+			SourcePath: srcPath,
+			DestPath:   destPath,
+			Size:       size,
+			Status:     FileTransferStatusSkipped,
+			Reason:     reason,
+		},
+	}
+}
+
+func newFailedDirTransferTask(srcPath string, destPath string, err error) *dirTransferTask {
+	return &dirTransferTask{
+		result: &FileTransferResult{
+			SourcePath: srcPath,
+			DestPath:   destPath,
+			Status:     FileTransferStatusFailed,
+			Error:      err,
+		},
+	}
+}
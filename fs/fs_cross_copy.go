@@ -0,0 +1,521 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CrossCopyConfig is a configuration of copies between two file systems, e.g., of independent servers or zones
+type CrossCopyConfig struct {
+	// number of files copied concurrently by CopyDirToFileSystem
+	Workers int
+	// glob patterns (path.Match) of files to copy, matched against the relative path and the name, empty includes all
+	Include []string
+	// glob patterns of files and collections not to copy, matched against the relative path and the name
+	Exclude []string
+	// how existing files at the destination are handled by CopyDirToFileSystem
+	Overwrite OverwritePolicy
+	// resource at the destination, empty uses the default resource
+	Resource string
+	// number of parallel ranges per file, 1 copies a file over a connection on each side, 0 decides it from the file size
+	TaskNum int
+	// copy ACLs, users of the source zone are given access in the destination zone
+	PreserveACLs bool
+	// copy modification times of files and collections
+	PreserveModifyTime bool
+	// compare checksums of the source and the copy
+	VerifyChecksum bool
+	// called when each file is done, may be called concurrently
+	ResultCallback func(result *FileTransferResult)
+}
+
+// NewCrossCopyConfigWithDefault creates a CrossCopyConfig with default settings
+func NewCrossCopyConfigWithDefault() *CrossCopyConfig {
+	return &CrossCopyConfig{
+		Workers:            DirTransferWorkersDefault,
+		Include:            []string{},
+		Exclude:            []string{},
+		Overwrite:          OverwriteAlways,
+		Resource:           "",
+		TaskNum:            0,
+		PreserveACLs:       false,
+		PreserveModifyTime: false,
+		VerifyChecksum:     true,
+		ResultCallback:     nil,
+	}
+}
+
+// getDirTransferConfig returns a DirTransferConfig for filtering and running copy tasks
+func (config *CrossCopyConfig) getDirTransferConfig() *DirTransferConfig {
+	return &DirTransferConfig{
+		Workers:        config.Workers,
+		Include:        config.Include,
+		Exclude:        config.Exclude,
+		Overwrite:      config.Overwrite,
+		Symlink:        SymlinkFollow,
+		Resource:       config.Resource,
+		TaskNum:        config.TaskNum,
+		ResultCallback: config.ResultCallback,
+	}
+}
+
+// crossCopyDir is a collection copied by CopyDirToFileSystem
+type crossCopyDir struct {
+	srcEntry *Entry
+	destPath string
+}
+
+// CopyFileToFileSystem copies a file to another file system, e.g., of another server or zone
+// data is streamed from this file system to destFS in parallel ranges without staging on local disk.
+// metadata (AVUs) are always copied, ACLs, modification time and checksum verification follow the config.
+// ACLs of users unknown at the destination are skipped with warnings in log.
+func (fs *FileSystem) CopyFileToFileSystem(srcPath string, destFS *FileSystem, destPath string, config *CrossCopyConfig, callback common.TrackerCallBack) error {
+	irodsSrcPath := util.GetCorrectIRODSPath(srcPath)
+	irodsDestPath := util.GetCorrectIRODSPath(destPath)
+
+	if config == nil {
+		config = NewCrossCopyConfigWithDefault()
+	}
+
+	srcEntry, err := fs.Stat(irodsSrcPath)
+	if err != nil {
+		return err
+	}
+
+	if srcEntry.Type != FileEntry {
+		return xerrors.Errorf("failed to find a file for path %s, the path is for a collection: %w", irodsSrcPath, types.NewFileNotFoundError(irodsSrcPath))
+	}
+
+	irodsFilePath := irodsDestPath
+
+	destEntry, err := destFS.Stat(irodsDestPath)
+	if err != nil {
+		if !types.IsFileNotFoundError(err) {
+			return err
+		}
+	} else {
+		switch destEntry.Type {
+		case FileEntry:
+			// do nothing
+		case DirectoryEntry:
+			irodsFilePath = util.MakeIRODSPath(irodsDestPath, srcEntry.Name)
+		default:
+			return xerrors.Errorf("unknown entry type %s", destEntry.Type)
+		}
+	}
+
+	knownUsers, err := destFS.getCrossCopyKnownUsers(config)
+	if err != nil {
+		return err
+	}
+
+	_, err = fs.copyFileToFileSystem(srcEntry, destFS, irodsFilePath, config, knownUsers, callback)
+	return err
+}
+
+// CopyDirToFileSystem copies a collection tree to another file system, files in srcPath are put in destPath
+// missing collections are created. Failures of individual files are recorded in the report,
+// an error is returned only if the copy cannot start.
+// ACLs of users unknown at the destination are skipped, with warnings in results of files and in log for collections.
+func (fs *FileSystem) CopyDirToFileSystem(srcPath string, destFS *FileSystem, destPath string, config *CrossCopyConfig) (*DirTransferReport, error) {
+	irodsSrcPath := util.GetCorrectIRODSPath(srcPath)
+	irodsDestPath := util.GetCorrectIRODSPath(destPath)
+
+	if config == nil {
+		config = NewCrossCopyConfigWithDefault()
+	}
+
+	srcEntry, err := fs.Stat(irodsSrcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !srcEntry.IsDir() {
+		return nil, xerrors.Errorf("irods path %s is not a collection", irodsSrcPath)
+	}
+
+	if !destFS.ExistsDir(irodsDestPath) {
+		err = destFS.MakeDir(irodsDestPath, true)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to make a collection %s: %w", irodsDestPath, err)
+		}
+	}
+
+	// listed once for all entries
+	knownUsers, err := destFS.getCrossCopyKnownUsers(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dirConfig := config.getDirTransferConfig()
+
+	tasks := []*dirTransferTask{}
+	dirs := []*crossCopyDir{}
+	_, err = fs.copyEntryAttributesToFileSystem(srcEntry, destFS, irodsDestPath, config, knownUsers)
+	if err != nil {
+		tasks = append(tasks, newFailedDirTransferTask(irodsSrcPath, irodsDestPath, err))
+	}
+
+	dirs = append(dirs, &crossCopyDir{
+		srcEntry: srcEntry,
+		destPath: irodsDestPath,
+	})
+
+	fs.collectCrossCopyTasks(irodsSrcPath, destFS, irodsDestPath, "", config, dirConfig, knownUsers, &tasks, &dirs)
+
+	report := runDirTransferTasks(tasks, dirConfig)
+
+	if config.PreserveModifyTime {
+		// set after files are copied as they update modification times of collections, subcollections first
+		for i := len(dirs) - 1; i >= 0; i-- {
+			dir := dirs[i]
+			err = destFS.SetDirModifyTime(dir.destPath, dir.srcEntry.ModifyTime)
+			if err != nil {
+				report.Results = append(report.Results, newFailedDirTransferTask(dir.srcEntry.Path, dir.destPath, xerrors.Errorf("failed to set modification time of %s: %w", dir.destPath, err)).result)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (fs *FileSystem) collectCrossCopyTasks(srcDir string, destFS *FileSystem, destDir string, relDir string, config *CrossCopyConfig, dirConfig *DirTransferConfig, knownUsers map[string]bool, tasks *[]*dirTransferTask, dirs *[]*crossCopyDir) {
+	entries, err := fs.List(srcDir)
+	if err != nil {
+		*tasks = append(*tasks, newFailedDirTransferTask(srcDir, destDir, xerrors.Errorf("failed to list collection %s: %w", srcDir, err)))
+		return
+	}
+
+	for _, entry := range entries {
+		destEntryPath := util.MakeIRODSPath(destDir, entry.Name)
+		relPath := path.Join(relDir, entry.Name)
+
+		if dirConfig.isExcluded(relPath) {
+			continue
+		}
+
+		if entry.IsDir() {
+			if !destFS.ExistsDir(destEntryPath) {
+				err = destFS.MakeDir(destEntryPath, true)
+				if err != nil {
+					*tasks = append(*tasks, newFailedDirTransferTask(entry.Path, destEntryPath, xerrors.Errorf("failed to make a collection %s: %w", destEntryPath, err)))
+					continue
+				}
+			}
+
+			_, err = fs.copyEntryAttributesToFileSystem(entry, destFS, destEntryPath, config, knownUsers)
+			if err != nil {
+				*tasks = append(*tasks, newFailedDirTransferTask(entry.Path, destEntryPath, err))
+			}
+
+			*dirs = append(*dirs, &crossCopyDir{
+				srcEntry: entry,
+				destPath: destEntryPath,
+			})
+
+			fs.collectCrossCopyTasks(entry.Path, destFS, destEntryPath, relPath, config, dirConfig, knownUsers, tasks, dirs)
+			continue
+		}
+
+		if !dirConfig.isIncluded(relPath) {
+			continue
+		}
+
+		srcEntry := entry
+		result := &FileTransferResult{
+			SourcePath: entry.Path,
+			DestPath:   destEntryPath,
+			Size:       entry.Size,
+		}
+
+		*tasks = append(*tasks, &dirTransferTask{
+			result: result,
+			transfer: func(result *FileTransferResult) {
+				fs.copyDirFileToFileSystem(srcEntry, destFS, result, config, knownUsers)
+			},
+		})
+	}
+}
+
+func (fs *FileSystem) copyDirFileToFileSystem(srcEntry *Entry, destFS *FileSystem, result *FileTransferResult, config *CrossCopyConfig, knownUsers map[string]bool) {
+	destEntry, err := destFS.Stat(result.DestPath)
+	if err != nil && !types.IsFileNotFoundError(err) {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	if err == nil {
+		if destEntry.IsDir() {
+			result.Status = FileTransferStatusFailed
+			result.Error = xerrors.Errorf("destination %s is a collection", result.DestPath)
+			return
+		}
+
+		overwrite, reason, err := destFS.shouldOverwrite(config.Overwrite, srcEntry.Size, srcEntry.ModifyTime, destEntry.Size, destEntry.ModifyTime, func(algorithm types.ChecksumAlgorithm) ([]byte, error) {
+			return fs.getDataObjectHash(srcEntry, algorithm)
+		}, destEntry)
+		if err != nil {
+			result.Status = FileTransferStatusFailed
+			result.Error = err
+			return
+		}
+
+		if !overwrite {
+			result.Status = FileTransferStatusSkipped
+			result.Reason = reason
+			return
+		}
+	}
+
+	warnings, err := fs.copyFileToFileSystem(srcEntry, destFS, result.DestPath, config, knownUsers, nil)
+	result.Warnings = warnings
+	if err != nil {
+		result.Status = FileTransferStatusFailed
+		result.Error = err
+		return
+	}
+
+	result.Status = FileTransferStatusTransferred
+}
+
+// copyFileToFileSystem streams a file to destFS, then copies its attributes, returns warnings of attributes not copied
+func (fs *FileSystem) copyFileToFileSystem(srcEntry *Entry, destFS *FileSystem, destPath string, config *CrossCopyConfig, knownUsers map[string]bool, callback common.TrackerCallBack) ([]string, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "FileSystem",
+		"function": "copyFileToFileSystem",
+	})
+
+	numTasks := config.TaskNum
+	if numTasks <= 0 {
+		numTasks = destFS.ioSession.GetParallelTransferTuner().GetNumTasks(srcEntry.Size)
+	}
+
+	// ranges of the source are read concurrently over as many connections as the destination writes
+	reader, err := fs.OpenFileReaderAt(srcEntry.Path, "", numTasks)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open file %s: %w", srcEntry.Path, err)
+	}
+	defer reader.Close()
+
+	err = destFS.UploadFromReader(io.NewSectionReader(reader, 0, srcEntry.Size), srcEntry.Size, destPath, config.Resource, numTasks, false, callback)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to copy file %s to %s: %w", srcEntry.Path, destPath, err)
+	}
+
+	if config.VerifyChecksum {
+		err = fs.verifyCopyToFileSystem(srcEntry, destFS, destPath)
+		if err != nil {
+			// do not leave a broken copy
+			removeErr := destFS.RemoveFile(destPath, true)
+			if removeErr != nil {
+				logger.WithError(removeErr).Warnf("failed to remove data object %s", destPath)
+			}
+			return nil, err
+		}
+	}
+
+	warnings, err := fs.copyEntryAttributesToFileSystem(srcEntry, destFS, destPath, config, knownUsers)
+	if err != nil {
+		return warnings, err
+	}
+
+	// set at last, as other changes may update modification time
+	if config.PreserveModifyTime {
+		err = destFS.SetModifyTime(destPath, srcEntry.ModifyTime)
+		if err != nil {
+			return warnings, xerrors.Errorf("failed to set modification time of %s: %w", destPath, err)
+		}
+	}
+
+	return warnings, nil
+}
+
+// verifyCopyToFileSystem compares sizes and checksums of a file and its copy
+// the checksum algorithm of the destination is used, the source is hashed locally if its server uses another one
+func (fs *FileSystem) verifyCopyToFileSystem(srcEntry *Entry, destFS *FileSystem, destPath string) error {
+	destEntry, err := destFS.Stat(destPath)
+	if err != nil {
+		return err
+	}
+
+	if destEntry.Size != srcEntry.Size {
+		return xerrors.Errorf("failed to verify copy of %s, size of %s is %d, expected %d", srcEntry.Path, destPath, destEntry.Size, srcEntry.Size)
+	}
+
+	destChecksum, err := destFS.getDataObjectChecksum(destEntry)
+	if err != nil {
+		return xerrors.Errorf("failed to get checksum of %s: %w", destPath, err)
+	}
+
+	srcHash, err := fs.getDataObjectHash(srcEntry, destChecksum.Algorithm)
+	if err != nil {
+		return xerrors.Errorf("failed to get checksum of %s: %w", srcEntry.Path, err)
+	}
+
+	if !bytes.Equal(srcHash, destChecksum.Checksum) {
+		return xerrors.Errorf("failed to verify copy of %s, checksum of %s does not match", srcEntry.Path, destPath)
+	}
+
+	return nil
+}
+
+// getDataObjectHash returns a hash of the data object with the algorithm
+// the checksum of the server is used if the algorithm is the same, otherwise data is read and hashed
+func (fs *FileSystem) getDataObjectHash(entry *Entry, algorithm types.ChecksumAlgorithm) ([]byte, error) {
+	checksum, err := fs.getDataObjectChecksum(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum.Algorithm == algorithm {
+		return checksum.Checksum, nil
+	}
+
+	reader, err := fs.OpenFileReaderAt(entry.Path, "", 1)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open file %s: %w", entry.Path, err)
+	}
+	defer reader.Close()
+
+	return util.HashReader(io.NewSectionReader(reader, 0, entry.Size), string(algorithm))
+}
+
+// copyEntryAttributesToFileSystem copies metadata, and ACLs if configured, of a file or a collection
+// returns warnings of ACLs not copied
+func (fs *FileSystem) copyEntryAttributesToFileSystem(srcEntry *Entry, destFS *FileSystem, destPath string, config *CrossCopyConfig, knownUsers map[string]bool) ([]string, error) {
+	err := fs.copyMetadataToFileSystem(srcEntry.Path, destFS, destPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.PreserveACLs {
+		return fs.copyACLsToFileSystem(srcEntry.Path, destFS, destPath, knownUsers)
+	}
+
+	return nil, nil
+}
+
+// copyMetadataToFileSystem adds AVUs of the source missing at the destination
+func (fs *FileSystem) copyMetadataToFileSystem(srcPath string, destFS *FileSystem, destPath string) error {
+	srcMetas, err := fs.ListMetadata(srcPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %s: %w", srcPath, err)
+	}
+
+	if len(srcMetas) == 0 {
+		return nil
+	}
+
+	destMetas, err := destFS.ListMetadata(destPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %s: %w", destPath, err)
+	}
+
+	existing := map[types.IRODSMeta]bool{}
+	for _, meta := range destMetas {
+		existing[types.IRODSMeta{Name: meta.Name, Value: meta.Value, Units: meta.Units}] = true
+	}
+
+	for _, meta := range srcMetas {
+		if existing[types.IRODSMeta{Name: meta.Name, Value: meta.Value, Units: meta.Units}] {
+			continue
+		}
+
+		err = destFS.AddMetadata(destPath, meta.Name, meta.Value, meta.Units)
+		if err != nil {
+			return xerrors.Errorf("failed to add metadata %s to %s: %w", meta.Name, destPath, err)
+		}
+	}
+
+	return nil
+}
+
+// getCrossCopyKnownUsers returns users and groups of the file system keyed by user#zone, if ACLs are copied to it
+func (fs *FileSystem) getCrossCopyKnownUsers(config *CrossCopyConfig) (map[string]bool, error) {
+	knownUsers := map[string]bool{}
+	if !config.PreserveACLs {
+		return knownUsers, nil
+	}
+
+	users, err := fs.ListUsers()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list users: %w", err)
+	}
+
+	groups, err := fs.ListGroups()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list groups: %w", err)
+	}
+
+	for _, user := range users {
+		knownUsers[fmt.Sprintf("%s#%s", user.Name, user.Zone)] = true
+	}
+
+	for _, group := range groups {
+		knownUsers[fmt.Sprintf("%s#%s", group.Name, group.Zone)] = true
+	}
+
+	return knownUsers, nil
+}
+
+// copyACLsToFileSystem gives users access to the destination as to the source
+// users of the source zone are mapped to the destination zone, access of the user of destFS is not changed.
+// users and groups not in knownUsers are skipped, warnings for them are logged and returned
+func (fs *FileSystem) copyACLsToFileSystem(srcPath string, destFS *FileSystem, destPath string, knownUsers map[string]bool) ([]string, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "FileSystem",
+		"function": "copyACLsToFileSystem",
+	})
+
+	accesses, err := fs.ListACLs(srcPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list ACLs of %s: %w", srcPath, err)
+	}
+
+	if len(accesses) == 0 {
+		return nil, nil
+	}
+
+	srcZone := fs.account.ClientZone
+	destUser := destFS.account.ClientUser
+	destZone := destFS.account.ClientZone
+
+	warnings := []string{}
+	for _, access := range accesses {
+		zone := access.UserZone
+		if zone == srcZone {
+			zone = destZone
+		}
+
+		if access.UserName == destUser && zone == destZone {
+			// keep ownership of the copy
+			continue
+		}
+
+		if !knownUsers[fmt.Sprintf("%s#%s", access.UserName, zone)] {
+			warning := fmt.Sprintf("skipped %s access of %s for %s#%s, the user is not found at the destination", access.AccessLevel, destPath, access.UserName, zone)
+			logger.Warn(warning)
+			warnings = append(warnings, warning)
+			continue
+		}
+
+		err = destFS.ChangeACL(destPath, access.AccessLevel, access.UserName, zone, false)
+		if err != nil {
+			return warnings, xerrors.Errorf("failed to change access of %s for %s#%s: %w", destPath, access.UserName, zone, err)
+		}
+	}
+
+	return warnings, nil
+}
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/phdavis1027/go-irodsclient/irods/util"
@@ -213,13 +214,12 @@ func (fs *FileSystem) DeleteUserMetadataByName(user string, attName string) erro
 
 // ListUserMetadata lists all user metadata
 func (fs *FileSystem) ListUserMetadata(user string) ([]*types.IRODSMeta, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	metadataobjects, err := irods_fs.ListUserMeta(conn, user)
+	var metadataobjects []*types.IRODSMeta
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		metadataobjects, queryErr = irods_fs.ListUserMeta(conn, user)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -292,13 +292,12 @@ func (fs *FileSystem) DeleteResourceMetadataByName(resource string, attName stri
 
 // ListResourceMetadata lists all resource metadata
 func (fs *FileSystem) ListResourceMetadata(resource string) ([]*types.IRODSMeta, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	metadataobjects, err := irods_fs.ListResourceMeta(conn, resource)
+	var metadataobjects []*types.IRODSMeta
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		metadataobjects, queryErr = irods_fs.ListResourceMeta(conn, resource)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -308,13 +307,18 @@ func (fs *FileSystem) ListResourceMetadata(resource string) ([]*types.IRODSMeta,
 
 // searchEntriesByMeta searches entries by meta
 func (fs *FileSystem) searchEntriesByMeta(metaName string, metaValue string) ([]*Entry, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
+	var collections []*types.IRODSCollection
+	var dataobjects []*types.IRODSDataObject
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		collections, queryErr = irods_fs.SearchCollectionsByMeta(conn, metaName, metaValue)
+		if queryErr != nil {
+			return queryErr
+		}
 
-	collections, err := irods_fs.SearchCollectionsByMeta(conn, metaName, metaValue)
+		dataobjects, queryErr = irods_fs.SearchDataObjectsMasterReplicaByMeta(conn, metaName, metaValue)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -330,11 +334,6 @@ func (fs *FileSystem) searchEntriesByMeta(metaName string, metaValue string) ([]
 		fs.cache.AddEntryCache(entry)
 	}
 
-	dataobjects, err := irods_fs.SearchDataObjectsMasterReplicaByMeta(conn, metaName, metaValue)
-	if err != nil {
-		return nil, err
-	}
-
 	for _, dataobject := range dataobjects {
 		if len(dataobject.Replicas) == 0 {
 			continue
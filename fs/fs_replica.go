@@ -0,0 +1,140 @@
+package fs
+
+import (
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"github.com/rs/xid"
+)
+
+// ListReplicas lists all replicas of a file, sorted by replica number
+func (fs *FileSystem) ListReplicas(path string) ([]*types.IRODSReplica, error) {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	collectionEntry, err := fs.getCollection(util.GetIRODSPathDirname(irodsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	collection := fs.getCollectionFromEntry(collectionEntry)
+
+	var replicas []*types.IRODSReplica
+	err = fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		replicas, queryErr = irods_fs.ListDataObjectReplicas(conn, collection, util.GetIRODSPathFileName(irodsPath))
+		return queryErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return replicas, nil
+}
+
+// OpenFileReplica opens a specific replica of an existing file for read/write
+// replica is selected by resourceHierarchy if given, otherwise by replicaNumber
+func (fs *FileSystem) OpenFileReplica(path string, replicaNumber int64, resourceHierarchy string, mode string) (*FileHandle, error) {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.ioSession.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, offset, err := irods_fs.OpenDataObjectReplica(conn, irodsPath, replicaNumber, resourceHierarchy, mode)
+	if err != nil {
+		fs.ioSession.ReturnConnection(conn)
+		return nil, err
+	}
+
+	entry, err := fs.getDataObjectWithConnection(conn, irodsPath)
+	if err != nil {
+		entry = &Entry{
+			ID:                0,
+			Type:              FileEntry,
+			Name:              util.GetIRODSPathFileName(irodsPath),
+			Path:              irodsPath,
+			Owner:             fs.account.ClientUser,
+			Size:              0,
+			CreateTime:        time.Now(),
+			ModifyTime:        time.Now(),
+			CheckSumAlgorithm: types.ChecksumAlgorithmUnknown,
+			CheckSum:          nil,
+		}
+	}
+
+	// do not return connection here
+	fileHandle := &FileHandle{
+		id:              xid.New().String(),
+		filesystem:      fs,
+		connection:      conn,
+		irodsFileHandle: handle,
+		entry:           entry,
+		offset:          offset,
+		openMode:        types.FileOpenMode(mode),
+	}
+
+	fs.fileHandleMap.Add(fileHandle)
+	return fileHandle, nil
+}
+
+// SetReplicaStatus sets the status of a replica of a file
+// adminFlag runs it as rodsadmin, required to change replicas owned by other users
+func (fs *FileSystem) SetReplicaStatus(path string, replicaNumber int64, status types.ReplicaStatus, adminFlag bool) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	err = irods_fs.ModifyDataObjectReplicaStatus(conn, irodsPath, replicaNumber, "", status, adminFlag)
+	if err != nil {
+		return err
+	}
+
+	fs.invalidateCacheForFileUpdate(irodsPath)
+	fs.cachePropagation.PropagateFileUpdate(irodsPath)
+	return nil
+}
+
+// MarkReplicaStale marks a replica of a file stale
+func (fs *FileSystem) MarkReplicaStale(path string, replicaNumber int64, adminFlag bool) error {
+	return fs.SetReplicaStatus(path, replicaNumber, types.ReplicaStatusStale, adminFlag)
+}
+
+// MarkReplicaGood marks a replica of a file good
+func (fs *FileSystem) MarkReplicaGood(path string, replicaNumber int64, adminFlag bool) error {
+	return fs.SetReplicaStatus(path, replicaNumber, types.ReplicaStatusGood, adminFlag)
+}
+
+// UnregisterFileReplica unregisters a replica of a file from the catalog, physical files are not deleted
+// all replicas are unregistered if replicaNumber is negative
+func (fs *FileSystem) UnregisterFileReplica(path string, replicaNumber int64) error {
+	irodsPath := util.GetCorrectIRODSPath(path)
+
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return err
+	}
+	defer fs.metaSession.ReturnConnection(conn)
+
+	err = irods_fs.UnregisterDataObjectReplica(conn, irodsPath, replicaNumber, false)
+	if err != nil {
+		return err
+	}
+
+	if replicaNumber < 0 {
+		fs.invalidateCacheForFileRemove(irodsPath)
+		fs.cachePropagation.PropagateFileRemove(irodsPath)
+	} else {
+		fs.invalidateCacheForFileUpdate(irodsPath)
+		fs.cachePropagation.PropagateFileUpdate(irodsPath)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package fs
 import (
 	"time"
 
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/phdavis1027/go-irodsclient/irods/util"
@@ -10,13 +11,12 @@ import (
 
 // GetTicketForAnonymousAccess gets ticket information for anonymous access
 func (fs *FileSystem) GetTicketForAnonymousAccess(ticketName string) (*types.IRODSTicketForAnonymousAccess, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	ticketInfo, err := irods_fs.GetTicketForAnonymousAccess(conn, ticketName)
+	var ticketInfo *types.IRODSTicketForAnonymousAccess
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		ticketInfo, queryErr = irods_fs.GetTicketForAnonymousAccess(conn, ticketName)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -26,13 +26,12 @@ func (fs *FileSystem) GetTicketForAnonymousAccess(ticketName string) (*types.IRO
 
 // GetTicket gets ticket information
 func (fs *FileSystem) GetTicket(ticketName string) (*types.IRODSTicket, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	ticketInfo, err := irods_fs.GetTicket(conn, ticketName)
+	var ticketInfo *types.IRODSTicket
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		ticketInfo, queryErr = irods_fs.GetTicket(conn, ticketName)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -42,13 +41,12 @@ func (fs *FileSystem) GetTicket(ticketName string) (*types.IRODSTicket, error) {
 
 // ListTickets lists all available ticket information
 func (fs *FileSystem) ListTickets() ([]*types.IRODSTicket, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	tickets, err := irods_fs.ListTickets(conn)
+	var tickets []*types.IRODSTicket
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		tickets, queryErr = irods_fs.ListTickets(conn)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +56,12 @@ func (fs *FileSystem) ListTickets() ([]*types.IRODSTicket, error) {
 
 // ListTicketsBasic lists all available basic ticket information
 func (fs *FileSystem) ListTicketsBasic() ([]*types.IRODSTicket, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	tickets, err := irods_fs.ListTicketsBasic(conn)
+	var tickets []*types.IRODSTicket
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		tickets, queryErr = irods_fs.ListTicketsBasic(conn)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -74,23 +71,24 @@ func (fs *FileSystem) ListTicketsBasic() ([]*types.IRODSTicket, error) {
 
 // GetTicketRestrictions gets all restriction info. for the given ticket
 func (fs *FileSystem) GetTicketRestrictions(ticketID int64) (*IRODSTicketRestrictions, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	hosts, err := irods_fs.ListTicketAllowedHosts(conn, ticketID)
-	if err != nil {
-		return nil, err
-	}
-
-	usernames, err := irods_fs.ListTicketAllowedUserNames(conn, ticketID)
-	if err != nil {
-		return nil, err
-	}
-
-	groupnames, err := irods_fs.ListTicketAllowedGroupNames(conn, ticketID)
+	var hosts []string
+	var usernames []string
+	var groupnames []string
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		hosts, queryErr = irods_fs.ListTicketAllowedHosts(conn, ticketID)
+		if queryErr != nil {
+			return queryErr
+		}
+
+		usernames, queryErr = irods_fs.ListTicketAllowedUserNames(conn, ticketID)
+		if queryErr != nil {
+			return queryErr
+		}
+
+		groupnames, queryErr = irods_fs.ListTicketAllowedGroupNames(conn, ticketID)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -104,13 +102,12 @@ func (fs *FileSystem) GetTicketRestrictions(ticketID int64) (*IRODSTicketRestric
 
 // ListTicketHostRestrictions lists all host restrictions for the given ticket
 func (fs *FileSystem) ListTicketHostRestrictions(ticketID int64) ([]string, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	hosts, err := irods_fs.ListTicketAllowedHosts(conn, ticketID)
+	var hosts []string
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		hosts, queryErr = irods_fs.ListTicketAllowedHosts(conn, ticketID)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -120,13 +117,12 @@ func (fs *FileSystem) ListTicketHostRestrictions(ticketID int64) ([]string, erro
 
 // ListTicketUserNameRestrictions lists all user name restrictions for the given ticket
 func (fs *FileSystem) ListTicketUserNameRestrictions(ticketID int64) ([]string, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	usernames, err := irods_fs.ListTicketAllowedUserNames(conn, ticketID)
+	var usernames []string
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		usernames, queryErr = irods_fs.ListTicketAllowedUserNames(conn, ticketID)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -136,13 +132,12 @@ func (fs *FileSystem) ListTicketUserNameRestrictions(ticketID int64) ([]string,
 
 // ListTicketUserGroupRestrictions lists all group name restrictions for the given ticket
 func (fs *FileSystem) ListTicketUserGroupRestrictions(ticketID int64) ([]string, error) {
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	groupnames, err := irods_fs.ListTicketAllowedGroupNames(conn, ticketID)
+	var groupnames []string
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		groupnames, queryErr = irods_fs.ListTicketAllowedGroupNames(conn, ticketID)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
 )
@@ -14,13 +15,12 @@ func (fs *FileSystem) ListGroupUsers(group string) ([]*types.IRODSUser, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	users, err := irods_fs.ListGroupUsers(conn, group)
+	var users []*types.IRODSUser
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		users, queryErr = irods_fs.ListGroupUsers(conn, group)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -40,13 +40,12 @@ func (fs *FileSystem) ListGroups() ([]*types.IRODSUser, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
-
-	groups, err := irods_fs.ListGroups(conn)
+	var groups []*types.IRODSUser
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		groups, queryErr = irods_fs.ListGroups(conn)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -66,25 +65,26 @@ func (fs *FileSystem) ListUserGroups(user string) ([]*types.IRODSUser, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
-	if err != nil {
-		return nil, err
-	}
-	defer fs.metaSession.ReturnConnection(conn)
+	var groups []*types.IRODSUser
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		groupNames, queryErr := irods_fs.ListUserGroupNames(conn, user)
+		if queryErr != nil {
+			return queryErr
+		}
 
-	groupNames, err := irods_fs.ListUserGroupNames(conn, user)
-	if err != nil {
-		return nil, err
-	}
+		groups = []*types.IRODSUser{}
+		for _, groupName := range groupNames {
+			group, queryErr := irods_fs.GetGroup(conn, groupName)
+			if queryErr != nil {
+				return queryErr
+			}
 
-	groups := []*types.IRODSUser{}
-	for _, groupName := range groupNames {
-		group, err := irods_fs.GetGroup(conn, groupName)
-		if err != nil {
-			return nil, err
+			groups = append(groups, group)
 		}
-
-		groups = append(groups, group)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// cache it
@@ -102,19 +102,58 @@ func (fs *FileSystem) ListUsers() ([]*types.IRODSUser, error) {
 	}
 
 	// otherwise, retrieve it and add it to cache
-	conn, err := fs.metaSession.AcquireConnection()
+	var users []*types.IRODSUser
+	err := fs.metaSession.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		var queryErr error
+		users, queryErr = irods_fs.ListUsers(conn)
+		return queryErr
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	// cache it
+	fs.cache.AddUsersCache(users)
+
+	return users, nil
+}
+
+// GetTempPassword returns a temporary password for the current user
+func (fs *FileSystem) GetTempPassword() (string, error) {
+	conn, err := fs.metaSession.AcquireConnection()
+	if err != nil {
+		return "", err
+	}
 	defer fs.metaSession.ReturnConnection(conn)
 
-	users, err := irods_fs.ListUsers(conn)
+	return irods_fs.GetTempPassword(conn)
+}
+
+// GetTempPasswordForOther returns a temporary password for other user, requires rodsadmin privilege
+func (fs *FileSystem) GetTempPasswordForOther(user string) (string, error) {
+	conn, err := fs.metaSession.AcquireConnection()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	defer fs.metaSession.ReturnConnection(conn)
 
-	// cache it
-	fs.cache.AddUsersCache(users)
+	return irods_fs.GetTempPasswordForOther(conn, user)
+}
 
-	return users, nil
+// GetTempPasswordAccount returns an account for accessing as the given user with a temporary password
+// The current user is used if user is empty, requires rodsadmin privilege for other users.
+func (fs *FileSystem) GetTempPasswordAccount(user string) (*types.IRODSAccount, error) {
+	var tempPassword string
+	var err error
+	if len(user) == 0 || user == fs.account.ClientUser {
+		tempPassword, err = fs.GetTempPassword()
+	} else {
+		tempPassword, err = fs.GetTempPasswordForOther(user)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return types.CreateIRODSAccountForTempPassword(fs.account, user, tempPassword)
 }
@@ -0,0 +1,393 @@
+package fs
+
+import (
+	"io"
+	io_fs "io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+// IOFS is an adapter of FileSystem implementing io/fs interfaces
+// it provides read-only access to the collection given as a root
+// e.g., http.FileServer(http.FS(fs.NewIOFS(filesystem, "/zone/home/user")))
+type IOFS struct {
+	filesystem *FileSystem
+	root       string
+}
+
+var (
+	_ io_fs.FS         = (*IOFS)(nil)
+	_ io_fs.StatFS     = (*IOFS)(nil)
+	_ io_fs.ReadDirFS  = (*IOFS)(nil)
+	_ io_fs.ReadFileFS = (*IOFS)(nil)
+	_ io_fs.SubFS      = (*IOFS)(nil)
+)
+
+// NewIOFS creates IOFS rooted at the given collection path
+func NewIOFS(filesystem *FileSystem, root string) *IOFS {
+	return &IOFS{
+		filesystem: filesystem,
+		root:       path.Clean(root),
+	}
+}
+
+// GetRoot returns the root collection path
+func (iofs *IOFS) GetRoot() string {
+	return iofs.root
+}
+
+// getPath returns an iRODS path of the given io/fs name
+func (iofs *IOFS) getPath(op string, name string) (string, error) {
+	if !io_fs.ValidPath(name) {
+		return "", &io_fs.PathError{Op: op, Path: name, Err: io_fs.ErrInvalid}
+	}
+
+	return path.Join(iofs.root, name), nil
+}
+
+// Open opens the named file or directory, implements io/fs.FS
+func (iofs *IOFS) Open(name string) (io_fs.File, error) {
+	irodsPath, err := iofs.getPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := iofs.filesystem.Stat(irodsPath)
+	if err != nil {
+		return nil, newIOFSPathError("open", name, err)
+	}
+
+	if entry.IsDir() {
+		return &IOFSDir{
+			iofs:  iofs,
+			name:  name,
+			entry: entry,
+		}, nil
+	}
+
+	handle, err := iofs.filesystem.OpenFile(irodsPath, "", string(types.FileOpenModeReadOnly))
+	if err != nil {
+		return nil, newIOFSPathError("open", name, err)
+	}
+
+	return &IOFSFile{
+		handle: handle,
+		name:   name,
+		entry:  entry,
+		offset: 0,
+	}, nil
+}
+
+// Stat returns FileInfo of the named file or directory, implements io/fs.StatFS
+func (iofs *IOFS) Stat(name string) (io_fs.FileInfo, error) {
+	irodsPath, err := iofs.getPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := iofs.filesystem.Stat(irodsPath)
+	if err != nil {
+		return nil, newIOFSPathError("stat", name, err)
+	}
+
+	return NewIOFSFileInfo(entry), nil
+}
+
+// ReadDir returns entries of the named directory sorted by name, implements io/fs.ReadDirFS
+func (iofs *IOFS) ReadDir(name string) ([]io_fs.DirEntry, error) {
+	irodsPath, err := iofs.getPath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return iofs.readDir(name, irodsPath)
+}
+
+func (iofs *IOFS) readDir(name string, irodsPath string) ([]io_fs.DirEntry, error) {
+	entries, err := iofs.filesystem.List(irodsPath)
+	if err != nil {
+		return nil, newIOFSPathError("readdir", name, err)
+	}
+
+	dirEntries := make([]io_fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		dirEntries = append(dirEntries, NewIOFSFileInfo(entry))
+	}
+
+	sort.Slice(dirEntries, func(i int, j int) bool {
+		return dirEntries[i].Name() < dirEntries[j].Name()
+	})
+
+	return dirEntries, nil
+}
+
+// ReadFile reads the whole content of the named file, implements io/fs.ReadFileFS
+func (iofs *IOFS) ReadFile(name string) ([]byte, error) {
+	file, err := iofs.Open(name)
+	if err != nil {
+		return nil, newIOFSPathError("readfile", name, err)
+	}
+	defer file.Close()
+
+	if _, ok := file.(*IOFSDir); ok {
+		return nil, &io_fs.PathError{Op: "readfile", Path: name, Err: xerrors.Errorf("is a directory")}
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, newIOFSPathError("readfile", name, err)
+	}
+
+	return data, nil
+}
+
+// Sub returns IOFS rooted at the named directory, implements io/fs.SubFS
+func (iofs *IOFS) Sub(dir string) (io_fs.FS, error) {
+	irodsPath, err := iofs.getPath("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "." {
+		return iofs, nil
+	}
+
+	return NewIOFS(iofs.filesystem, irodsPath), nil
+}
+
+// newIOFSPathError converts an error to io/fs.PathError, so errors.Is(err, io_fs.ErrNotExist) works
+func newIOFSPathError(op string, name string, err error) error {
+	var pathError *io_fs.PathError
+	if xerrors.As(err, &pathError) {
+		return err
+	}
+
+	if types.IsFileNotFoundError(err) {
+		return &io_fs.PathError{Op: op, Path: name, Err: io_fs.ErrNotExist}
+	}
+
+	switch types.GetIRODSErrorCode(err) {
+	case common.CAT_NO_ACCESS_PERMISSION, common.SYS_USER_NO_PERMISSION, common.USER_ACCESS_DENIED:
+		return &io_fs.PathError{Op: op, Path: name, Err: io_fs.ErrPermission}
+	}
+
+	return &io_fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// IOFSFileInfo exposes Entry as io/fs.FileInfo and io/fs.DirEntry
+type IOFSFileInfo struct {
+	entry *Entry
+}
+
+// NewIOFSFileInfo creates IOFSFileInfo
+func NewIOFSFileInfo(entry *Entry) *IOFSFileInfo {
+	return &IOFSFileInfo{
+		entry: entry,
+	}
+}
+
+// GetEntry returns Entry
+func (info *IOFSFileInfo) GetEntry() *Entry {
+	return info.entry
+}
+
+// Name returns base name of the entry
+func (info *IOFSFileInfo) Name() string {
+	return info.entry.Name
+}
+
+// Size returns size of the entry, 0 for directories
+func (info *IOFSFileInfo) Size() int64 {
+	if info.entry.IsDir() {
+		return 0
+	}
+	return info.entry.Size
+}
+
+// Mode returns file mode of the entry, the adapter is read-only
+func (info *IOFSFileInfo) Mode() io_fs.FileMode {
+	if info.entry.IsDir() {
+		return io_fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// ModTime returns modification time of the entry
+func (info *IOFSFileInfo) ModTime() time.Time {
+	return info.entry.ModifyTime
+}
+
+// IsDir returns true if the entry is a directory
+func (info *IOFSFileInfo) IsDir() bool {
+	return info.entry.IsDir()
+}
+
+// Sys returns the underlying Entry
+func (info *IOFSFileInfo) Sys() interface{} {
+	return info.entry
+}
+
+// Type returns type bits of the entry, implements io/fs.DirEntry
+func (info *IOFSFileInfo) Type() io_fs.FileMode {
+	return info.Mode().Type()
+}
+
+// Info returns FileInfo of the entry, implements io/fs.DirEntry
+func (info *IOFSFileInfo) Info() (io_fs.FileInfo, error) {
+	return info, nil
+}
+
+// IOFSFile is a file opened via IOFS, implements io/fs.File, io.ReaderAt and io.Seeker
+// Read and Seek use their own offset, so ReadAt calls do not move it
+type IOFSFile struct {
+	handle *FileHandle
+	name   string
+	entry  *Entry
+	offset int64
+}
+
+// GetFileHandle returns the underlying FileHandle
+func (file *IOFSFile) GetFileHandle() *FileHandle {
+	return file.handle
+}
+
+// Stat returns FileInfo of the file
+func (file *IOFSFile) Stat() (io_fs.FileInfo, error) {
+	return NewIOFSFileInfo(file.entry), nil
+}
+
+// Read reads the file, implements io.Reader
+func (file *IOFSFile) Read(buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+
+	readLen, err := file.readAt(buffer, file.offset)
+	file.offset += int64(readLen)
+	return readLen, err
+}
+
+// ReadAt reads data from given offset, implements io.ReaderAt
+func (file *IOFSFile) ReadAt(buffer []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, &io_fs.PathError{Op: "readat", Path: file.name, Err: io_fs.ErrInvalid}
+	}
+
+	totalReadLen := 0
+	for totalReadLen < len(buffer) {
+		readLen, err := file.readAt(buffer[totalReadLen:], offset+int64(totalReadLen))
+		totalReadLen += readLen
+		if err != nil {
+			return totalReadLen, err
+		}
+
+		if readLen == 0 {
+			return totalReadLen, io.EOF
+		}
+	}
+
+	return totalReadLen, nil
+}
+
+func (file *IOFSFile) readAt(buffer []byte, offset int64) (int, error) {
+	if offset >= file.entry.Size {
+		return 0, io.EOF
+	}
+
+	readLen, err := file.handle.ReadAt(buffer, offset)
+	if err != nil && err != io.EOF {
+		return readLen, newIOFSPathError("read", file.name, err)
+	}
+	return readLen, err
+}
+
+// Seek sets offset for next Read, implements io.Seeker
+func (file *IOFSFile) Seek(offset int64, whence int) (int64, error) {
+	newOffset := offset
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		newOffset += file.offset
+	case io.SeekEnd:
+		newOffset += file.entry.Size
+	default:
+		return 0, &io_fs.PathError{Op: "seek", Path: file.name, Err: io_fs.ErrInvalid}
+	}
+
+	if newOffset < 0 {
+		return 0, &io_fs.PathError{Op: "seek", Path: file.name, Err: io_fs.ErrInvalid}
+	}
+
+	file.offset = newOffset
+	return newOffset, nil
+}
+
+// Close closes the file
+func (file *IOFSFile) Close() error {
+	err := file.handle.Close()
+	if err != nil {
+		return newIOFSPathError("close", file.name, err)
+	}
+	return nil
+}
+
+// IOFSDir is a directory opened via IOFS, implements io/fs.ReadDirFile
+type IOFSDir struct {
+	iofs    *IOFS
+	name    string
+	entry   *Entry
+	entries []io_fs.DirEntry
+	listed  bool
+	offset  int
+}
+
+// Stat returns FileInfo of the directory
+func (dir *IOFSDir) Stat() (io_fs.FileInfo, error) {
+	return NewIOFSFileInfo(dir.entry), nil
+}
+
+// Read returns an error, directories cannot be read
+func (dir *IOFSDir) Read(buffer []byte) (int, error) {
+	return 0, &io_fs.PathError{Op: "read", Path: dir.name, Err: xerrors.Errorf("is a directory")}
+}
+
+// ReadDir returns up to n entries of the directory, implements io/fs.ReadDirFile
+// n <= 0 returns all remaining entries
+func (dir *IOFSDir) ReadDir(n int) ([]io_fs.DirEntry, error) {
+	if !dir.listed {
+		entries, err := dir.iofs.readDir(dir.name, dir.entry.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		dir.entries = entries
+		dir.listed = true
+	}
+
+	remaining := dir.entries[dir.offset:]
+	if n <= 0 {
+		dir.offset = len(dir.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+
+	dir.offset += n
+	return remaining[:n], nil
+}
+
+// Close closes the directory
+func (dir *IOFSDir) Close() error {
+	return nil
+}
@@ -0,0 +1,418 @@
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ProxyFileSystemIdleTimeoutDefault is a default time a cached FileSystem stays unused before it is released
+	ProxyFileSystemIdleTimeoutDefault = 10 * time.Minute
+)
+
+// proxyFileSystemEntry is a cached FileSystem of a client user
+type proxyFileSystemEntry struct {
+	filesystem *FileSystem // nil while being created
+	references int         // callers holding the FileSystem
+	lastAccess time.Time
+	ready      chan bool // closed when creation finishes
+	err        error     // creation error
+	removed    bool      // removed from the cache, released when the last reference is returned
+}
+
+// ProxyFileSystemFactory creates FileSystems that act on behalf of client users.
+// It authenticates as a proxy user (rodsadmin) once, and opens sessions whose client user is the named user.
+// FileSystems are cached per user and share one configuration. Total connections are bounded by
+// releasing least recently used FileSystems that no caller holds.
+type ProxyFileSystemFactory struct {
+	account         *types.IRODSAccount
+	config          *FileSystemConfig
+	addressResolver session.AddressResolver
+	maxConnections  int
+	idleTimeout     time.Duration
+	fileSystems     map[string]*proxyFileSystemEntry      // key is user#zone
+	acquired        map[*FileSystem]*proxyFileSystemEntry // FileSystems held by callers
+	terminateChan   chan bool
+	terminated      bool
+	mutex           sync.Mutex
+}
+
+// NewProxyFileSystemFactory creates a ProxyFileSystemFactory
+// account must be of a rodsadmin user, its client user is replaced per FileSystem.
+// maxConnections bounds connections of all cached FileSystems, 0 means no limit.
+func NewProxyFileSystemFactory(account *types.IRODSAccount, config *FileSystemConfig, maxConnections int) (*ProxyFileSystemFactory, error) {
+	return NewProxyFileSystemFactoryWithAddressResolver(account, config, maxConnections, nil)
+}
+
+// NewProxyFileSystemFactoryWithAddressResolver creates a ProxyFileSystemFactory
+func NewProxyFileSystemFactoryWithAddressResolver(account *types.IRODSAccount, config *FileSystemConfig, maxConnections int, addressResolver session.AddressResolver) (*ProxyFileSystemFactory, error) {
+	// act as the proxy user itself to verify credentials
+	proxyAccount := *account
+	proxyAccount.ClientUser = account.ProxyUser
+	proxyAccount.ClientZone = account.ProxyZone
+	proxyAccount.FixAuthConfiguration()
+
+	if maxConnections > 0 && maxConnections < config.getConnectionsPerFileSystem() {
+		return nil, xerrors.Errorf("max connections %d is smaller than connections required by a file system %d", maxConnections, config.getConnectionsPerFileSystem())
+	}
+
+	// authenticate once, credentials obtained (e.g., PAM token) are saved to the account and reused
+	err := authenticateProxyAccount(&proxyAccount, config, addressResolver)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to authenticate proxy user %s: %w", proxyAccount.ProxyUser, err)
+	}
+
+	factory := &ProxyFileSystemFactory{
+		account:         &proxyAccount,
+		config:          config,
+		addressResolver: addressResolver,
+		maxConnections:  maxConnections,
+		idleTimeout:     ProxyFileSystemIdleTimeoutDefault,
+		fileSystems:     map[string]*proxyFileSystemEntry{},
+		acquired:        map[*FileSystem]*proxyFileSystemEntry{},
+		terminateChan:   make(chan bool),
+		terminated:      false,
+		mutex:           sync.Mutex{},
+	}
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-factory.terminateChan:
+				return
+			case <-ticker.C:
+				factory.ReleaseIdleFileSystems()
+			}
+		}
+	}()
+
+	return factory, nil
+}
+
+func authenticateProxyAccount(account *types.IRODSAccount, config *FileSystemConfig, addressResolver session.AddressResolver) error {
+	connAccount := account
+	if addressResolver != nil {
+		resolvedAccount := *account
+		resolvedAccount.Host = addressResolver(resolvedAccount.Host)
+		connAccount = &resolvedAccount
+	}
+
+	conn := connection.NewIRODSConnection(connAccount, config.OperationTimeout, config.ApplicationName)
+	conn.SetDialer(config.Dialer)
+	err := conn.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Disconnect()
+
+	// keep credentials obtained during authentication
+	account.PamToken = connAccount.PamToken
+	return nil
+}
+
+// SetIdleTimeout sets how long a cached FileSystem stays unused before it is released, 0 disables it
+func (factory *ProxyFileSystemFactory) SetIdleTimeout(timeout time.Duration) {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+
+	factory.idleTimeout = timeout
+}
+
+// GetAccount returns the proxy account
+func (factory *ProxyFileSystemFactory) GetAccount() *types.IRODSAccount {
+	return factory.account
+}
+
+// AcquireFileSystem returns a FileSystem acting on behalf of the given user in the proxy user's zone
+func (factory *ProxyFileSystemFactory) AcquireFileSystem(user string) (*FileSystem, error) {
+	return factory.AcquireFileSystemForZone(user, "")
+}
+
+// AcquireFileSystemForZone returns a FileSystem acting on behalf of the given user.
+// A cached FileSystem is returned if exists. The FileSystem is not released while it is held,
+// callers must return it with ReturnFileSystem and must not release it.
+func (factory *ProxyFileSystemFactory) AcquireFileSystemForZone(user string, zone string) (*FileSystem, error) {
+	if len(zone) == 0 {
+		zone = factory.account.ProxyZone
+	}
+
+	key := makeProxyFileSystemKey(user, zone)
+
+	factory.mutex.Lock()
+
+	if factory.terminated {
+		factory.mutex.Unlock()
+		return nil, xerrors.Errorf("failed to get a file system, the factory is already released")
+	}
+
+	if entry, ok := factory.fileSystems[key]; ok {
+		entry.references++
+		entry.lastAccess = time.Now()
+		factory.mutex.Unlock()
+
+		// may be being created by another caller
+		<-entry.ready
+		if entry.err != nil {
+			return nil, entry.err
+		}
+
+		// returning the FileSystem drops it from acquired FileSystems when no one holds it
+		factory.mutex.Lock()
+		factory.acquired[entry.filesystem] = entry
+		factory.mutex.Unlock()
+
+		return entry.filesystem, nil
+	}
+
+	// make room
+	evicted := []*proxyFileSystemEntry{}
+	if factory.maxConnections > 0 {
+		for (len(factory.fileSystems)+1)*factory.config.getConnectionsPerFileSystem() > factory.maxConnections {
+			evictedEntry := factory.evictLocked()
+			if evictedEntry == nil {
+				err := xerrors.Errorf("failed to create a file system for user %s, all %d file systems are in use: %w", user, len(factory.fileSystems), types.NewConnectionPoolFullError(len(factory.fileSystems)*factory.config.getConnectionsPerFileSystem(), factory.maxConnections))
+				factory.mutex.Unlock()
+
+				releaseProxyFileSystemEntries(evicted)
+				return nil, err
+			}
+
+			evicted = append(evicted, evictedEntry)
+		}
+	}
+
+	// reserve the room, others asking for the same user wait for the creation
+	entry := &proxyFileSystemEntry{
+		filesystem: nil,
+		references: 1,
+		lastAccess: time.Now(),
+		ready:      make(chan bool),
+		err:        nil,
+		removed:    false,
+	}
+	factory.fileSystems[key] = entry
+	factory.mutex.Unlock()
+
+	// connecting takes time, do not block others
+	releaseProxyFileSystemEntries(evicted)
+
+	filesystem, err := factory.newFileSystem(user, zone)
+
+	factory.mutex.Lock()
+	defer close(entry.ready)
+	defer factory.mutex.Unlock()
+
+	if err == nil && factory.terminated {
+		filesystem.Release()
+		err = xerrors.Errorf("failed to get a file system, the factory is already released")
+	}
+
+	if err != nil {
+		entry.err = err
+		if factory.fileSystems[key] == entry {
+			delete(factory.fileSystems, key)
+		}
+		return nil, err
+	}
+
+	entry.filesystem = filesystem
+	factory.acquired[filesystem] = entry
+	return filesystem, nil
+}
+
+// ReturnFileSystem returns the FileSystem acquired, it stays cached for other calls
+func (factory *ProxyFileSystemFactory) ReturnFileSystem(filesystem *FileSystem) {
+	factory.mutex.Lock()
+
+	entry, ok := factory.acquired[filesystem]
+	if !ok {
+		factory.mutex.Unlock()
+		return
+	}
+
+	entry.references--
+	entry.lastAccess = time.Now()
+
+	release := false
+	if entry.references <= 0 {
+		delete(factory.acquired, filesystem)
+		release = entry.removed
+	}
+
+	factory.mutex.Unlock()
+
+	if release {
+		filesystem.Release()
+	}
+}
+
+// ReleaseFileSystem releases the cached FileSystem of the given user,
+// if callers hold it, it is released when the last one returns it
+func (factory *ProxyFileSystemFactory) ReleaseFileSystem(user string, zone string) {
+	if len(zone) == 0 {
+		zone = factory.account.ProxyZone
+	}
+
+	key := makeProxyFileSystemKey(user, zone)
+
+	factory.mutex.Lock()
+	released := []*proxyFileSystemEntry{}
+	if entry, ok := factory.fileSystems[key]; ok {
+		delete(factory.fileSystems, key)
+		if factory.removeLocked(entry) {
+			released = append(released, entry)
+		}
+	}
+	factory.mutex.Unlock()
+
+	releaseProxyFileSystemEntries(released)
+}
+
+// FileSystems returns the number of cached FileSystems
+func (factory *ProxyFileSystemFactory) FileSystems() int {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+
+	return len(factory.fileSystems)
+}
+
+// ConnectionTotal returns the number of connections of all cached FileSystems
+func (factory *ProxyFileSystemFactory) ConnectionTotal() int {
+	factory.mutex.Lock()
+	defer factory.mutex.Unlock()
+
+	total := 0
+	for _, entry := range factory.fileSystems {
+		if entry.filesystem != nil {
+			total += entry.filesystem.ConnectionTotal()
+		}
+	}
+	return total
+}
+
+// Release releases all cached FileSystems, FileSystems held by callers are released when returned
+func (factory *ProxyFileSystemFactory) Release() {
+	factory.mutex.Lock()
+	if factory.terminated {
+		factory.mutex.Unlock()
+		return
+	}
+
+	factory.terminated = true
+	close(factory.terminateChan)
+
+	released := []*proxyFileSystemEntry{}
+	for _, entry := range factory.fileSystems {
+		if factory.removeLocked(entry) {
+			released = append(released, entry)
+		}
+	}
+	factory.fileSystems = map[string]*proxyFileSystemEntry{}
+	factory.mutex.Unlock()
+
+	releaseProxyFileSystemEntries(released)
+}
+
+// newFileSystem creates a FileSystem of the user
+func (factory *ProxyFileSystemFactory) newFileSystem(user string, zone string) (*FileSystem, error) {
+	clientAccount, err := types.CreateIRODSAccountForProxyClient(factory.account, user, zone)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create an account for user %s: %w", user, err)
+	}
+
+	filesystem, err := NewFileSystemWithAddressResolver(clientAccount, factory.config, factory.addressResolver)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a file system for user %s: %w", user, err)
+	}
+
+	return filesystem, nil
+}
+
+// removeLocked marks the entry removed from the cache, returns true if it can be released now
+func (factory *ProxyFileSystemFactory) removeLocked(entry *proxyFileSystemEntry) bool {
+	entry.removed = true
+	return entry.references <= 0 && entry.filesystem != nil
+}
+
+// isIdleLocked returns true if no caller holds the entry
+func (factory *ProxyFileSystemFactory) isIdleLocked(entry *proxyFileSystemEntry) bool {
+	return entry.references <= 0 && entry.filesystem != nil && !entry.filesystem.hasOpenFiles()
+}
+
+// evictLocked removes the least recently used FileSystem that no caller holds from the cache,
+// the caller releases the returned entry after unlocking. Returns nil if all are in use.
+func (factory *ProxyFileSystemFactory) evictLocked() *proxyFileSystemEntry {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "ProxyFileSystemFactory",
+		"function": "evictLocked",
+	})
+
+	var oldestKey string
+	var oldestEntry *proxyFileSystemEntry
+	for key, entry := range factory.fileSystems {
+		if !factory.isIdleLocked(entry) {
+			continue
+		}
+
+		if oldestEntry == nil || entry.lastAccess.Before(oldestEntry.lastAccess) {
+			oldestKey = key
+			oldestEntry = entry
+		}
+	}
+
+	if oldestEntry == nil {
+		return nil
+	}
+
+	logger.Debugf("Releasing a file system for %s to make room", oldestKey)
+
+	delete(factory.fileSystems, oldestKey)
+	factory.removeLocked(oldestEntry)
+	return oldestEntry
+}
+
+// ReleaseIdleFileSystems releases FileSystems unused for the idle timeout, it runs every minute in background
+func (factory *ProxyFileSystemFactory) ReleaseIdleFileSystems() {
+	factory.mutex.Lock()
+
+	if factory.idleTimeout <= 0 {
+		factory.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	idleEntries := []*proxyFileSystemEntry{}
+	for key, entry := range factory.fileSystems {
+		if entry.lastAccess.Add(factory.idleTimeout).Before(now) && factory.isIdleLocked(entry) {
+			delete(factory.fileSystems, key)
+			factory.removeLocked(entry)
+			idleEntries = append(idleEntries, entry)
+		}
+	}
+
+	factory.mutex.Unlock()
+
+	releaseProxyFileSystemEntries(idleEntries)
+}
+
+// releaseProxyFileSystemEntries releases FileSystems of entries, must be called without holding the lock
+func releaseProxyFileSystemEntries(entries []*proxyFileSystemEntry) {
+	for _, entry := range entries {
+		entry.filesystem.Release()
+	}
+}
+
+func makeProxyFileSystemKey(user string, zone string) string {
+	return user + "#" + zone
+}
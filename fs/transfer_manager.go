@@ -0,0 +1,1047 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"github.com/rs/xid"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TransferManagerConcurrencyDefault is a default number of jobs run concurrently
+	TransferManagerConcurrencyDefault = 2
+	// TransferManagerRetryMaxDefault is a default number of retries of a failed job
+	TransferManagerRetryMaxDefault = 3
+	// TransferManagerRetryDelayDefault is a default delay before retrying a failed job
+	TransferManagerRetryDelayDefault = 5 * time.Second
+	// TransferManagerProgressIntervalDefault is a default interval of progress events
+	TransferManagerProgressIntervalDefault = 1 * time.Second
+	// TransferManagerRateLimitBlockSize is a size of blocks a job waits for its rate limit at a time
+	TransferManagerRateLimitBlockSize = 64 * 1024
+)
+
+var (
+	errTransferJobStopped = xerrors.Errorf("transfer job is stopped")
+)
+
+// TransferJobType is a type of a transfer job
+type TransferJobType string
+
+const (
+	// TransferJobTypeUpload uploads a local file to iRODS
+	TransferJobTypeUpload TransferJobType = "upload"
+	// TransferJobTypeDownload downloads a data object to local
+	TransferJobTypeDownload TransferJobType = "download"
+	// TransferJobTypeCopy copies a data object in iRODS
+	TransferJobTypeCopy TransferJobType = "copy"
+)
+
+// TransferJobState is a state of a transfer job
+type TransferJobState string
+
+const (
+	// TransferJobStateQueued means the job waits for a worker
+	TransferJobStateQueued TransferJobState = "queued"
+	// TransferJobStateRunning means the job is running or waits for a retry
+	TransferJobStateRunning TransferJobState = "running"
+	// TransferJobStatePaused means the job is paused and can be resumed
+	TransferJobStatePaused TransferJobState = "paused"
+	// TransferJobStateCompleted means the job is done
+	TransferJobStateCompleted TransferJobState = "completed"
+	// TransferJobStateFailed means the job failed after retries, it can be resumed
+	TransferJobStateFailed TransferJobState = "failed"
+	// TransferJobStateCanceled means the job is canceled
+	TransferJobStateCanceled TransferJobState = "canceled"
+)
+
+// IsDone returns true if the job is not going to run unless it is resumed
+func (state TransferJobState) IsDone() bool {
+	switch state {
+	case TransferJobStateCompleted, TransferJobStateFailed, TransferJobStateCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransferJob is a transfer queued in TransferManager
+type TransferJob struct {
+	id          string
+	jobType     TransferJobType
+	sourcePath  string
+	destPath    string
+	resource    string
+	taskNum     int
+	rateLimiter *util.RateLimiter
+	state       TransferJobState
+	stopRequest TransferJobState // paused or canceled, requested while running
+	stopChan    chan bool
+	destChecked bool // whether the destination is checked before the first attempt
+	destCreated bool // the destination did not exist, so it is created by the job
+	size        int64
+	transferred int64
+	attempts    int
+	lastError   error
+	createTime  time.Time
+	startTime   time.Time
+	endTime     time.Time
+	mutex       sync.Mutex
+}
+
+// GetID returns ID
+func (job *TransferJob) GetID() string {
+	return job.id
+}
+
+// GetType returns the job type
+func (job *TransferJob) GetType() TransferJobType {
+	return job.jobType
+}
+
+// GetSourcePath returns the source path
+func (job *TransferJob) GetSourcePath() string {
+	return job.sourcePath
+}
+
+// GetDestPath returns the destination path
+func (job *TransferJob) GetDestPath() string {
+	return job.destPath
+}
+
+// GetResource returns the resource
+func (job *TransferJob) GetResource() string {
+	return job.resource
+}
+
+// GetState returns the job state
+func (job *TransferJob) GetState() TransferJobState {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.state
+}
+
+// GetSize returns the size of the file, 0 until the job starts
+func (job *TransferJob) GetSize() int64 {
+	return atomic.LoadInt64(&job.size)
+}
+
+// GetTransferred returns bytes transferred
+func (job *TransferJob) GetTransferred() int64 {
+	return atomic.LoadInt64(&job.transferred)
+}
+
+// GetAttempts returns the number of attempts since the job is queued or resumed
+func (job *TransferJob) GetAttempts() int {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.attempts
+}
+
+// GetError returns the last error
+func (job *TransferJob) GetError() error {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.lastError
+}
+
+// GetCreateTime returns the time the job is added
+func (job *TransferJob) GetCreateTime() time.Time {
+	return job.createTime
+}
+
+// GetStartTime returns the time the job starts first
+func (job *TransferJob) GetStartTime() time.Time {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.startTime
+}
+
+// GetEndTime returns the time the job is done
+func (job *TransferJob) GetEndTime() time.Time {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.endTime
+}
+
+// SetBandwidthLimit changes bytes per second transferred by the job, 0 means no limit
+// the limit applies on top of the file system limits, and can be changed while the job is running
+func (job *TransferJob) SetBandwidthLimit(limit int64) {
+	job.rateLimiter.SetLimit(limit)
+}
+
+// GetBandwidthLimit returns bytes per second transferred by the job, 0 means no limit
+func (job *TransferJob) GetBandwidthLimit() int64 {
+	return job.rateLimiter.GetLimit()
+}
+
+// waitRateLimiter waits for the job's rate limiter in blocks, returns an error if the job is asked to stop meanwhile
+func (job *TransferJob) waitRateLimiter(size int) error {
+	for size > 0 {
+		blockLen := size
+		if blockLen > TransferManagerRateLimitBlockSize {
+			blockLen = TransferManagerRateLimitBlockSize
+		}
+
+		job.rateLimiter.Wait(blockLen)
+		size -= blockLen
+
+		if job.isStopRequested() {
+			return errTransferJobStopped
+		}
+	}
+
+	return nil
+}
+
+// recordDestination records whether the destination exists before the job first writes to it
+func (job *TransferJob) recordDestination(exists bool) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if !job.destChecked {
+		job.destChecked = true
+		job.destCreated = !exists
+	}
+}
+
+func (job *TransferJob) isDestinationCreated() bool {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.destCreated
+}
+
+func (job *TransferJob) isStopRequested() bool {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return len(job.stopRequest) > 0
+}
+
+// TransferProgress is an aggregate progress of jobs in TransferManager
+type TransferProgress struct {
+	Jobs             int
+	Queued           int
+	Running          int
+	Paused           int
+	Completed        int
+	Failed           int
+	Canceled         int
+	TotalBytes       int64 // sizes of jobs known so far
+	TransferredBytes int64
+	BytesPerSecond   float64 // throughput in the last progress interval
+	Time             time.Time
+}
+
+// TransferJobCallback is called when the state of a job changes
+type TransferJobCallback func(job *TransferJob)
+
+// TransferProgressCallback is called periodically while jobs are running
+type TransferProgressCallback func(progress *TransferProgress)
+
+// TransferManagerConfig is a configuration of TransferManager
+type TransferManagerConfig struct {
+	// number of jobs run concurrently
+	Concurrency int
+	// number of retries of a failed job, uploads and downloads resume from where they failed
+	RetryMax int
+	// delay before retrying a failed job
+	RetryDelay time.Duration
+	// interval of progress events
+	ProgressInterval time.Duration
+}
+
+// NewTransferManagerConfigWithDefault creates a TransferManagerConfig with default settings
+func NewTransferManagerConfigWithDefault() *TransferManagerConfig {
+	return &TransferManagerConfig{
+		Concurrency:      TransferManagerConcurrencyDefault,
+		RetryMax:         TransferManagerRetryMaxDefault,
+		RetryDelay:       TransferManagerRetryDelayDefault,
+		ProgressInterval: TransferManagerProgressIntervalDefault,
+	}
+}
+
+// TransferManager queues transfer jobs and runs them in background
+// Downloads keep transfer status in a status file (.grc.*.trx_status) next to the local file,
+// uploads keep it in UploadTransferStatusDir of the file system config,
+// so paused or failed transfers resume from where they stopped.
+// Copies run on the server and cannot be paused or canceled while running.
+type TransferManager struct {
+	filesystem        *FileSystem
+	config            *TransferManagerConfig
+	jobs              []*TransferJob
+	jobMap            map[string]*TransferJob
+	queue             []*TransferJob
+	running           int
+	jobCallbacks      []TransferJobCallback
+	progressCallbacks []TransferProgressCallback
+	bytesTransferred  int64
+	lastBytes         int64
+	lastProgressTime  time.Time
+	bytesPerSecond    float64
+	released          bool
+	terminateChan     chan bool
+	idleCondition     *sync.Cond
+	mutex             sync.Mutex
+}
+
+// NewTransferManager creates a new TransferManager, config can be nil to use default settings
+func NewTransferManager(filesystem *FileSystem, config *TransferManagerConfig) *TransferManager {
+	if config == nil {
+		config = NewTransferManagerConfigWithDefault()
+	}
+
+	manager := &TransferManager{
+		filesystem:        filesystem,
+		config:            config,
+		jobs:              []*TransferJob{},
+		jobMap:            map[string]*TransferJob{},
+		queue:             []*TransferJob{},
+		running:           0,
+		jobCallbacks:      []TransferJobCallback{},
+		progressCallbacks: []TransferProgressCallback{},
+		bytesTransferred:  0,
+		lastBytes:         0,
+		lastProgressTime:  time.Now(),
+		bytesPerSecond:    0,
+		released:          false,
+		terminateChan:     make(chan bool),
+		mutex:             sync.Mutex{},
+	}
+
+	manager.idleCondition = sync.NewCond(&manager.mutex)
+
+	if config.ProgressInterval > 0 {
+		go manager.reportProgress()
+	}
+
+	return manager
+}
+
+// Release pauses running jobs and stops the manager
+// paused downloads can be resumed by another manager from their status files
+func (manager *TransferManager) Release() {
+	manager.mutex.Lock()
+	if manager.released {
+		manager.mutex.Unlock()
+		return
+	}
+
+	manager.released = true
+
+	for _, job := range manager.queue {
+		job.mutex.Lock()
+		job.state = TransferJobStatePaused
+		job.mutex.Unlock()
+	}
+	manager.queue = []*TransferJob{}
+	manager.idleCondition.Broadcast()
+
+	for _, job := range manager.jobs {
+		job.mutex.Lock()
+		if job.state == TransferJobStateRunning && job.jobType != TransferJobTypeCopy {
+			manager.requestStopLocked(job, TransferJobStatePaused)
+		}
+		job.mutex.Unlock()
+	}
+
+	for manager.running > 0 {
+		manager.idleCondition.Wait()
+	}
+	manager.mutex.Unlock()
+
+	close(manager.terminateChan)
+}
+
+// AddJobCallback adds a callback called when the state of a job changes, callbacks may be called concurrently
+func (manager *TransferManager) AddJobCallback(callback TransferJobCallback) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.jobCallbacks = append(manager.jobCallbacks, callback)
+}
+
+// AddProgressCallback adds a callback called every progress interval while jobs are running
+func (manager *TransferManager) AddProgressCallback(callback TransferProgressCallback) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.progressCallbacks = append(manager.progressCallbacks, callback)
+}
+
+// AddUpload queues a job uploading a local file to irods
+// taskNum is the number of parallel tasks, 0 decides it from the file size
+func (manager *TransferManager) AddUpload(localPath string, irodsPath string, resource string, taskNum int) (*TransferJob, error) {
+	localSrcPath := util.GetCorrectLocalPath(localPath)
+	irodsDestPath := util.GetCorrectIRODSPath(irodsPath)
+
+	srcStat, err := os.Stat(localSrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, types.NewFileNotFoundError(localSrcPath)
+		}
+
+		return nil, xerrors.Errorf("failed to stat file %s: %w", localSrcPath, err)
+	}
+
+	if srcStat.IsDir() {
+		return nil, xerrors.Errorf("cannot upload a directory %s", localSrcPath)
+	}
+
+	irodsFilePath := irodsDestPath
+	if manager.filesystem.ExistsDir(irodsDestPath) {
+		irodsFilePath = util.MakeIRODSPath(irodsDestPath, filepath.Base(localSrcPath))
+	}
+
+	return manager.addJob(TransferJobTypeUpload, localSrcPath, irodsFilePath, resource, taskNum)
+}
+
+// AddDownload queues a job downloading a data object to local
+// taskNum is the number of parallel tasks, 0 decides it from the file size
+func (manager *TransferManager) AddDownload(irodsPath string, localPath string, resource string, taskNum int) (*TransferJob, error) {
+	irodsSrcPath := util.GetCorrectIRODSPath(irodsPath)
+	localDestPath := util.GetCorrectLocalPath(localPath)
+
+	srcStat, err := manager.filesystem.Stat(irodsSrcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if srcStat.Type == DirectoryEntry {
+		return nil, xerrors.Errorf("cannot download a collection %s", irodsSrcPath)
+	}
+
+	localFilePath := localDestPath
+	destStat, err := os.Stat(localDestPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, xerrors.Errorf("failed to stat file %s: %w", localDestPath, err)
+		}
+	} else if destStat.IsDir() {
+		localFilePath = filepath.Join(localDestPath, util.GetIRODSPathFileName(irodsSrcPath))
+	}
+
+	return manager.addJob(TransferJobTypeDownload, irodsSrcPath, localFilePath, resource, taskNum)
+}
+
+// AddCopy queues a job copying a data object to another path in irods
+func (manager *TransferManager) AddCopy(srcPath string, destPath string) (*TransferJob, error) {
+	irodsSrcPath := util.GetCorrectIRODSPath(srcPath)
+	irodsDestPath := util.GetCorrectIRODSPath(destPath)
+
+	srcStat, err := manager.filesystem.Stat(irodsSrcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if srcStat.Type == DirectoryEntry {
+		return nil, xerrors.Errorf("cannot copy a collection %s", irodsSrcPath)
+	}
+
+	irodsFilePath := irodsDestPath
+	if manager.filesystem.ExistsDir(irodsDestPath) {
+		irodsFilePath = util.MakeIRODSPath(irodsDestPath, util.GetIRODSPathFileName(irodsSrcPath))
+	}
+
+	return manager.addJob(TransferJobTypeCopy, irodsSrcPath, irodsFilePath, "", 1)
+}
+
+func (manager *TransferManager) addJob(jobType TransferJobType, sourcePath string, destPath string, resource string, taskNum int) (*TransferJob, error) {
+	job := &TransferJob{
+		id:          xid.New().String(),
+		jobType:     jobType,
+		sourcePath:  sourcePath,
+		destPath:    destPath,
+		resource:    resource,
+		taskNum:     taskNum,
+		rateLimiter: util.NewRateLimiter(0),
+		state:       TransferJobStateQueued,
+		stopRequest: "",
+		destChecked: false,
+		destCreated: false,
+		createTime:  time.Now(),
+		mutex:       sync.Mutex{},
+	}
+
+	manager.mutex.Lock()
+	if manager.released {
+		manager.mutex.Unlock()
+		return nil, xerrors.Errorf("transfer manager is already released")
+	}
+
+	manager.jobs = append(manager.jobs, job)
+	manager.jobMap[job.id] = job
+	manager.queue = append(manager.queue, job)
+	manager.mutex.Unlock()
+
+	manager.notifyJob(job)
+
+	manager.mutex.Lock()
+	manager.scheduleLocked()
+	manager.mutex.Unlock()
+
+	return job, nil
+}
+
+// GetJob returns the job with the id
+func (manager *TransferManager) GetJob(jobID string) (*TransferJob, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	job, ok := manager.jobMap[jobID]
+	if !ok {
+		return nil, xerrors.Errorf("failed to find transfer job %s", jobID)
+	}
+
+	return job, nil
+}
+
+// Jobs returns all jobs in the order they are added
+func (manager *TransferManager) Jobs() []*TransferJob {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	jobs := make([]*TransferJob, len(manager.jobs))
+	copy(jobs, manager.jobs)
+	return jobs
+}
+
+// RemoveJob removes a completed, failed or canceled job from the manager
+func (manager *TransferManager) RemoveJob(jobID string) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	job, ok := manager.jobMap[jobID]
+	if !ok {
+		return xerrors.Errorf("failed to find transfer job %s", jobID)
+	}
+
+	state := job.GetState()
+	if !state.IsDone() {
+		return xerrors.Errorf("cannot remove transfer job %s in %s state", jobID, state)
+	}
+
+	delete(manager.jobMap, jobID)
+	for idx, existingJob := range manager.jobs {
+		if existingJob == job {
+			manager.jobs = append(manager.jobs[:idx], manager.jobs[idx+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// Pause pauses a queued or running job, running copies cannot be paused
+// a running job is paused after its current block is transferred
+func (manager *TransferManager) Pause(jobID string) error {
+	job, err := manager.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	job.mutex.Lock()
+
+	switch job.state {
+	case TransferJobStateQueued:
+		manager.removeFromQueueLocked(job)
+		job.state = TransferJobStatePaused
+	case TransferJobStateRunning:
+		if job.jobType == TransferJobTypeCopy {
+			job.mutex.Unlock()
+			manager.mutex.Unlock()
+			return xerrors.Errorf("cannot pause running copy job %s", jobID)
+		}
+
+		manager.requestStopLocked(job, TransferJobStatePaused)
+		job.mutex.Unlock()
+		manager.mutex.Unlock()
+		return nil
+	default:
+		state := job.state
+		job.mutex.Unlock()
+		manager.mutex.Unlock()
+		return xerrors.Errorf("cannot pause transfer job %s in %s state", jobID, state)
+	}
+
+	job.mutex.Unlock()
+	manager.mutex.Unlock()
+
+	manager.notifyJob(job)
+	return nil
+}
+
+// Resume queues a paused or failed job again
+func (manager *TransferManager) Resume(jobID string) error {
+	job, err := manager.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	if manager.released {
+		manager.mutex.Unlock()
+		return xerrors.Errorf("transfer manager is already released")
+	}
+
+	job.mutex.Lock()
+	if job.state != TransferJobStatePaused && job.state != TransferJobStateFailed {
+		state := job.state
+		job.mutex.Unlock()
+		manager.mutex.Unlock()
+		return xerrors.Errorf("cannot resume transfer job %s in %s state", jobID, state)
+	}
+
+	job.state = TransferJobStateQueued
+	job.attempts = 0
+	job.lastError = nil
+	job.endTime = time.Time{}
+	job.mutex.Unlock()
+
+	manager.queue = append(manager.queue, job)
+	manager.mutex.Unlock()
+
+	manager.notifyJob(job)
+
+	manager.mutex.Lock()
+	manager.scheduleLocked()
+	manager.mutex.Unlock()
+	return nil
+}
+
+// Cancel cancels a job and removes data partially transferred, running copies cannot be canceled
+// a running job is canceled after its current block is transferred
+// destinations that existed before the job started are kept
+func (manager *TransferManager) Cancel(jobID string) error {
+	job, err := manager.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	job.mutex.Lock()
+
+	switch job.state {
+	case TransferJobStateQueued, TransferJobStatePaused, TransferJobStateFailed:
+		manager.removeFromQueueLocked(job)
+		job.state = TransferJobStateCanceled
+		job.endTime = time.Now()
+	case TransferJobStateRunning:
+		if job.jobType == TransferJobTypeCopy {
+			job.mutex.Unlock()
+			manager.mutex.Unlock()
+			return xerrors.Errorf("cannot cancel running copy job %s", jobID)
+		}
+
+		manager.requestStopLocked(job, TransferJobStateCanceled)
+		job.mutex.Unlock()
+		manager.mutex.Unlock()
+		return nil
+	default:
+		state := job.state
+		job.mutex.Unlock()
+		manager.mutex.Unlock()
+		return xerrors.Errorf("cannot cancel transfer job %s in %s state", jobID, state)
+	}
+
+	started := !job.startTime.IsZero()
+	job.mutex.Unlock()
+	manager.mutex.Unlock()
+
+	if started {
+		manager.cleanupJob(job)
+	}
+
+	manager.notifyJob(job)
+	return nil
+}
+
+// Wait waits until no jobs are queued or running
+func (manager *TransferManager) Wait() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	for manager.running > 0 || len(manager.queue) > 0 {
+		manager.idleCondition.Wait()
+	}
+}
+
+// GetProgress returns the aggregate progress of jobs
+func (manager *TransferManager) GetProgress() *TransferProgress {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return manager.getProgressLocked()
+}
+
+func (manager *TransferManager) getProgressLocked() *TransferProgress {
+	progress := &TransferProgress{
+		Jobs:           len(manager.jobs),
+		BytesPerSecond: manager.bytesPerSecond,
+		Time:           time.Now(),
+	}
+
+	for _, job := range manager.jobs {
+		switch job.GetState() {
+		case TransferJobStateQueued:
+			progress.Queued++
+		case TransferJobStateRunning:
+			progress.Running++
+		case TransferJobStatePaused:
+			progress.Paused++
+		case TransferJobStateCompleted:
+			progress.Completed++
+		case TransferJobStateFailed:
+			progress.Failed++
+		case TransferJobStateCanceled:
+			progress.Canceled++
+		}
+
+		progress.TotalBytes += job.GetSize()
+		progress.TransferredBytes += job.GetTransferred()
+	}
+
+	return progress
+}
+
+func (manager *TransferManager) reportProgress() {
+	ticker := time.NewTicker(manager.config.ProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-manager.terminateChan:
+			return
+		case <-ticker.C:
+			manager.mutex.Lock()
+			now := time.Now()
+			bytesTransferred := atomic.LoadInt64(&manager.bytesTransferred)
+			elapsed := now.Sub(manager.lastProgressTime).Seconds()
+			changed := bytesTransferred != manager.lastBytes
+			if elapsed > 0 {
+				manager.bytesPerSecond = float64(bytesTransferred-manager.lastBytes) / elapsed
+			}
+			manager.lastBytes = bytesTransferred
+			manager.lastProgressTime = now
+
+			if manager.running == 0 && !changed {
+				manager.mutex.Unlock()
+				continue
+			}
+
+			progress := manager.getProgressLocked()
+			callbacks := manager.progressCallbacks
+			manager.mutex.Unlock()
+
+			for _, callback := range callbacks {
+				callback(progress)
+			}
+		}
+	}
+}
+
+func (manager *TransferManager) notifyJob(job *TransferJob) {
+	manager.mutex.Lock()
+	callbacks := manager.jobCallbacks
+	manager.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(job)
+	}
+}
+
+// requestStopLocked asks a running job to stop, both manager and job mutexes must be held
+func (manager *TransferManager) requestStopLocked(job *TransferJob, state TransferJobState) {
+	if len(job.stopRequest) == 0 {
+		close(job.stopChan)
+	}
+
+	// cancel overrides pause
+	if job.stopRequest != TransferJobStateCanceled {
+		job.stopRequest = state
+	}
+}
+
+func (manager *TransferManager) removeFromQueueLocked(job *TransferJob) {
+	for idx, queuedJob := range manager.queue {
+		if queuedJob == job {
+			manager.queue = append(manager.queue[:idx], manager.queue[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (manager *TransferManager) scheduleLocked() {
+	for manager.running < manager.getConcurrency() && len(manager.queue) > 0 {
+		job := manager.queue[0]
+		manager.queue = manager.queue[1:]
+		manager.running++
+
+		job.mutex.Lock()
+		job.state = TransferJobStateRunning
+		job.stopRequest = ""
+		job.stopChan = make(chan bool)
+		if job.startTime.IsZero() {
+			job.startTime = time.Now()
+		}
+		job.mutex.Unlock()
+
+		go manager.runJob(job)
+	}
+}
+
+func (manager *TransferManager) getConcurrency() int {
+	if manager.config.Concurrency <= 0 {
+		return 1
+	}
+	return manager.config.Concurrency
+}
+
+func (manager *TransferManager) runJob(job *TransferJob) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "TransferManager",
+		"function": "runJob",
+	})
+
+	manager.notifyJob(job)
+
+	var err error
+	for {
+		job.mutex.Lock()
+		job.attempts++
+		attempts := job.attempts
+		job.mutex.Unlock()
+
+		err = manager.executeJob(job)
+		if err == nil || job.isStopRequested() {
+			break
+		}
+
+		if attempts > manager.config.RetryMax {
+			break
+		}
+
+		logger.WithError(err).Debugf("failed to %s %s, retrying (attempt %d)", job.jobType, job.sourcePath, attempts)
+
+		job.mutex.Lock()
+		job.lastError = err
+		stopChan := job.stopChan
+		job.mutex.Unlock()
+
+		select {
+		case <-stopChan:
+		case <-time.After(manager.config.RetryDelay):
+		}
+
+		if job.isStopRequested() {
+			break
+		}
+	}
+
+	job.mutex.Lock()
+	stopRequest := job.stopRequest
+	job.stopRequest = ""
+
+	// a job finished before it sees the stop request is completed
+	canceled := false
+	switch {
+	case err == nil:
+		job.state = TransferJobStateCompleted
+		job.lastError = nil
+		job.endTime = time.Now()
+	case stopRequest == TransferJobStatePaused:
+		job.state = TransferJobStatePaused
+	case stopRequest == TransferJobStateCanceled:
+		job.state = TransferJobStateCanceled
+		job.endTime = time.Now()
+		canceled = true
+	default:
+		job.state = TransferJobStateFailed
+		job.lastError = err
+		job.endTime = time.Now()
+	}
+	job.mutex.Unlock()
+
+	if canceled {
+		manager.cleanupJob(job)
+	}
+
+	manager.notifyJob(job)
+
+	manager.mutex.Lock()
+	manager.running--
+	if !manager.released {
+		manager.scheduleLocked()
+	}
+	manager.idleCondition.Broadcast()
+	manager.mutex.Unlock()
+}
+
+func (manager *TransferManager) executeJob(job *TransferJob) error {
+	switch job.jobType {
+	case TransferJobTypeUpload:
+		return manager.executeUpload(job)
+	case TransferJobTypeDownload:
+		return manager.executeDownload(job)
+	case TransferJobTypeCopy:
+		return manager.executeCopy(job)
+	default:
+		return xerrors.Errorf("unknown transfer job type %s", job.jobType)
+	}
+}
+
+// updateTransferred sets bytes transferred of a job and accumulates the increase for throughput
+func (manager *TransferManager) updateTransferred(job *TransferJob, transferred int64) {
+	previous := atomic.SwapInt64(&job.transferred, transferred)
+	if transferred > previous {
+		atomic.AddInt64(&manager.bytesTransferred, transferred-previous)
+	}
+}
+
+func (manager *TransferManager) addTransferred(job *TransferJob, delta int64) {
+	atomic.AddInt64(&job.transferred, delta)
+	atomic.AddInt64(&manager.bytesTransferred, delta)
+}
+
+func (manager *TransferManager) executeUpload(job *TransferJob) error {
+	stat, err := os.Stat(job.sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat file %s: %w", job.sourcePath, err)
+	}
+
+	if stat.IsDir() {
+		return xerrors.Errorf("cannot upload a directory %s", job.sourcePath)
+	}
+
+	atomic.StoreInt64(&job.size, stat.Size())
+
+	job.recordDestination(manager.filesystem.ExistsFile(job.destPath))
+
+	blockHook := func(size int) error {
+		if job.isStopRequested() {
+			return errTransferJobStopped
+		}
+
+		return job.waitRateLimiter(size)
+	}
+
+	callback := func(processed int64, total int64) {
+		manager.updateTransferred(job, processed)
+	}
+
+	err = irods_fs.UploadDataObjectParallelResumableWithBlockHook(manager.filesystem.ioSession, job.sourcePath, job.destPath, job.resource, manager.filesystem.config.UploadTransferStatusDir, job.taskNum, false, false, blockHook, callback)
+	if err != nil {
+		return err
+	}
+
+	manager.filesystem.invalidateCacheForFileCreate(job.destPath)
+	manager.filesystem.cachePropagation.PropagateFileCreate(job.destPath)
+	return nil
+}
+
+func (manager *TransferManager) executeDownload(job *TransferJob) error {
+	srcStat, err := manager.filesystem.Stat(job.sourcePath)
+	if err != nil {
+		return err
+	}
+
+	if srcStat.Type == DirectoryEntry {
+		return xerrors.Errorf("cannot download a collection %s", job.sourcePath)
+	}
+
+	atomic.StoreInt64(&job.size, srcStat.Size)
+
+	_, err = os.Lstat(job.destPath)
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("failed to stat file %s: %w", job.destPath, err)
+	}
+
+	job.recordDestination(err == nil)
+
+	blockHook := func(size int) error {
+		if job.isStopRequested() {
+			return errTransferJobStopped
+		}
+
+		return job.waitRateLimiter(size)
+	}
+
+	callback := func(processed int64, total int64) {
+		manager.updateTransferred(job, processed)
+	}
+
+	return irods_fs.DownloadDataObjectParallelResumableWithBlockHook(manager.filesystem.ioSession, job.sourcePath, job.resource, job.destPath, srcStat.Size, job.taskNum, blockHook, callback)
+}
+
+func (manager *TransferManager) executeCopy(job *TransferJob) error {
+	srcStat, err := manager.filesystem.Stat(job.sourcePath)
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt64(&job.size, srcStat.Size)
+
+	err = manager.filesystem.CopyFileToFile(job.sourcePath, job.destPath, true)
+	if err != nil {
+		return err
+	}
+
+	manager.updateTransferred(job, srcStat.Size)
+	return nil
+}
+
+// cleanupJob removes data partially transferred by a canceled job, destinations the job did not create are kept
+func (manager *TransferManager) cleanupJob(job *TransferJob) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"struct":   "TransferManager",
+		"function": "cleanupJob",
+	})
+
+	switch job.jobType {
+	case TransferJobTypeDownload:
+		statusFilePath := irods_fs.GetDataObjectTransferStatusFilePath(job.destPath)
+		err := os.RemoveAll(statusFilePath)
+		if err != nil {
+			logger.WithError(err).Warnf("failed to remove transfer status file %s", statusFilePath)
+		}
+
+		if !job.isDestinationCreated() {
+			return
+		}
+
+		err = os.Remove(job.destPath)
+		if err != nil && !os.IsNotExist(err) {
+			logger.WithError(err).Warnf("failed to remove file %s", job.destPath)
+		}
+	case TransferJobTypeUpload:
+		statusFilePath := irods_fs.GetDataObjectUploadTransferStatusFilePath(manager.filesystem.config.UploadTransferStatusDir, job.sourcePath, job.destPath)
+		err := os.RemoveAll(statusFilePath)
+		if err != nil {
+			logger.WithError(err).Warnf("failed to remove transfer status file %s", statusFilePath)
+		}
+
+		if job.isDestinationCreated() && manager.filesystem.ExistsFile(job.destPath) {
+			err := manager.filesystem.RemoveFile(job.destPath, true)
+			if err != nil {
+				logger.WithError(err).Warnf("failed to remove data object %s", job.destPath)
+			}
+		}
+	}
+}
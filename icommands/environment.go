@@ -91,6 +91,9 @@ func CreateIcommandsEnvironmentManagerFromIRODSAccount(account *types.IRODSAccou
 		manager.Environment.EncryptionAlgorithm = account.SSLConfiguration.EncryptionAlgorithm
 		manager.Environment.EncryptionSaltSize = account.SSLConfiguration.SaltSize
 		manager.Environment.EncryptionNumHashRounds = account.SSLConfiguration.HashRounds
+		manager.Environment.SSLCertificateChainFile = account.SSLConfiguration.CertificateFile
+		manager.Environment.SSLCertificateKeyFile = account.SSLConfiguration.CertificateKeyFile
+		manager.Environment.SSLVerifyServer = string(account.SSLConfiguration.VerifyServer)
 	}
 
 	manager.Password = account.Password
@@ -209,7 +212,11 @@ func (manager *ICommandsEnvironmentManager) ToIRODSAccount() (*types.IRODSAccoun
 		return nil, xerrors.Errorf("environment is not set")
 	}
 
-	account := manager.Environment.ToIRODSAccount()
+	account, err := manager.Environment.ToIRODSAccountWithValidation()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create an account from environment: %w", err)
+	}
+
 	account.Password = manager.Password
 	account.PamToken = manager.PamToken
 
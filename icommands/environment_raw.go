@@ -80,7 +80,24 @@ func CreateICommandsEnvironmentFromJSON(jsonBytes []byte) (*ICommandsEnvironment
 }
 
 // ToIRODSAccount creates IRODSAccount
+// an invalid irods_ssl_verify_server value verifies the hostname, use ToIRODSAccountWithValidation to get an error
 func (env *ICommandsEnvironment) ToIRODSAccount() *types.IRODSAccount {
+	account, _ := env.toIRODSAccount()
+	return account
+}
+
+// ToIRODSAccountWithValidation creates IRODSAccount, it returns an error if the environment has invalid values
+func (env *ICommandsEnvironment) ToIRODSAccountWithValidation() (*types.IRODSAccount, error) {
+	account, err := env.toIRODSAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// toIRODSAccount creates IRODSAccount, the account is also returned with an error for invalid values
+func (env *ICommandsEnvironment) toIRODSAccount() (*types.IRODSAccount, error) {
 	authScheme := types.GetAuthScheme(env.AuthenticationScheme)
 
 	negotiationRequired := false
@@ -111,12 +128,26 @@ func (env *ICommandsEnvironment) ToIRODSAccount() *types.IRODSAccount {
 			EncryptionAlgorithm: env.EncryptionAlgorithm,
 			SaltSize:            env.EncryptionSaltSize,
 			HashRounds:          env.EncryptionNumHashRounds,
+			CertificateFile:     env.SSLCertificateChainFile,
+			CertificateKeyFile:  env.SSLCertificateKeyFile,
 		},
 	}
 
+	// not set does not verify the server certificate for backward compatibility
+	var validationErr error
+	if len(strings.TrimSpace(env.SSLVerifyServer)) > 0 {
+		verifyServer, err := types.GetSSLVerifyServer(env.SSLVerifyServer)
+		if err != nil {
+			// an invalid value must not turn verification off
+			verifyServer = types.SSLVerifyServerHostname
+			validationErr = xerrors.Errorf("failed to parse irods_ssl_verify_server: %w", err)
+		}
+		account.SSLConfiguration.VerifyServer = verifyServer
+	}
+
 	account.FixAuthConfiguration()
 
-	return account
+	return account, validationErr
 }
 
 // ToJSON converts to JSON bytes
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+)
+
+const (
+	tempPasswordHashLen int = 100
+)
+
+// GenerateTempPassword returns a temporary password computed from the challenge given by server
+// it hashes the challenge and password concatenated in a zero-filled buffer as iRODS clients do
+func GenerateTempPassword(challenge string, password string) string {
+	hashBuffer := make([]byte, tempPasswordHashLen)
+	copy(hashBuffer, []byte(challenge+password))
+
+	m := md5.New()
+	m.Write(hashBuffer)
+	encodedPassword := m.Sum(nil)
+
+	return hex.EncodeToString(encodedPassword)
+}
@@ -190,4 +190,6 @@ const (
 	ATOMIC_APPLY_METADATA_OPERATIONS_APN APINumber = 20002
 	REPLICA_CLOSE_APN                    APINumber = 20004
 	TOUCH_APN                            APINumber = 20007
+
+	AUTHENTICATION_APN APINumber = 110000
 )
@@ -5,22 +5,24 @@ type KeyWord string
 
 // reserved keywords
 const (
-	ZONE_KW          KeyWord = "zone"
-	RECURSIVE_OPR_KW KeyWord = "recursiveOpr"
-	FORCE_FLAG_KW    KeyWord = "forceFlag"
-	BULK_OPR_KW      KeyWord = "bulkOpr"
-	//ALL_KW           KeyWord = "all"
-	DEST_RESC_NAME_KW  KeyWord = "destRescName"
-	DATA_TYPE_KW       KeyWord = "dataType"
-	DATA_SIZE_KW       KeyWord = "dataSize"
-	NUM_THREADS_KW     KeyWord = "numThreads"
-	OPR_TYPE_KW        KeyWord = "oprType"
-	UPDATE_REPL_KW     KeyWord = "updateRepl"
-	RESC_NAME_KW       KeyWord = "rescName"
-	COPIES_KW          KeyWord = "copies"
-	AGE_KW             KeyWord = "age"
-	ADMIN_KW           KeyWord = "irodsAdmin"
-	COLLECTION_TYPE_KW KeyWord = "collectionType"
+	ZONE_KW             KeyWord = "zone"
+	RECURSIVE_OPR_KW    KeyWord = "recursiveOpr"
+	FORCE_FLAG_KW       KeyWord = "forceFlag"
+	BULK_OPR_KW         KeyWord = "bulkOpr"
+	ALL_KW              KeyWord = "all"
+	DEST_RESC_NAME_KW   KeyWord = "destRescName"
+	DATA_TYPE_KW        KeyWord = "dataType"
+	DATA_SIZE_KW        KeyWord = "dataSize"
+	NUM_THREADS_KW      KeyWord = "numThreads"
+	OPR_TYPE_KW         KeyWord = "oprType"
+	UPDATE_REPL_KW      KeyWord = "updateRepl"
+	RESC_NAME_KW        KeyWord = "rescName"
+	COPIES_KW           KeyWord = "copies"
+	AGE_KW              KeyWord = "age"
+	ADMIN_KW            KeyWord = "irodsAdmin"
+	COLLECTION_TYPE_KW  KeyWord = "collectionType"
+	COLLECTION_MTIME_KW KeyWord = "collectionMtime"
+	UNREG_COLL_KW       KeyWord = "unregColl"
 
 	LOCK_TYPE_KW KeyWord = "lockType"
 	LOCK_CMD_KW  KeyWord = "lockCmd"
@@ -33,4 +35,11 @@ const (
 	STAGE_OBJ_KW          KeyWord = "stage_object"
 	SYNC_OBJ_KW           KeyWord = "sync_object"
 	IN_REPL_KW            KeyWord = "in_repl"
+
+	REPL_NUM_KW    KeyWord = "replNum"
+	REPL_STATUS_KW KeyWord = "replStatus"
+
+	DATA_MODIFY_KW KeyWord = "dataModify"
+
+	FORCE_CHKSUM_KW KeyWord = "forceChksum"
 )
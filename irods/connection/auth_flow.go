@@ -0,0 +1,233 @@
+package connection
+
+import (
+	"fmt"
+
+	"github.com/phdavis1027/go-irodsclient/irods/auth"
+	"github.com/phdavis1027/go-irodsclient/irods/message"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// keys and operations used by iRODS 4.3 authentication framework
+const (
+	AuthFlowSchemeKey        string = "scheme"
+	AuthFlowNextOperationKey string = "next_operation"
+	AuthFlowUserNameKey      string = "user_name"
+	AuthFlowZoneNameKey      string = "zone_name"
+	AuthFlowRequestResultKey string = "request_result"
+	AuthFlowPasswordKey      string = "a_pw"
+	AuthFlowTTLKey           string = "a_ttl"
+	AuthFlowDigestKey        string = "digest"
+
+	AuthFlowClientStart       string = "auth_client_start"
+	AuthFlowAgentAuthRequest  string = "auth_agent_auth_request"
+	AuthFlowAgentAuthResponse string = "auth_agent_auth_response"
+	AuthFlowComplete          string = "auth_flow_complete"
+
+	AuthFlowNativeSchemeName string = "native"
+	AuthFlowPAMSchemeName    string = "pam_password"
+)
+
+const (
+	authFlowNativeRequest    string = "native_auth_client_request"
+	authFlowNativeContext    string = "native_auth_establish_context"
+	authFlowNativeResponse   string = "native_auth_client_response"
+	authFlowPAMRequest       string = "pam_password_auth_client_request"
+	authFlowPAMContext       string = "pam_password_auth_establish_context"
+	authFlowPAMPerformNative string = "perform_native_auth"
+
+	authFlowChallengeMinLength int = 64
+)
+
+// AuthFlowOperation is a client-side step of an authentication flow
+// it receives the current state and returns the next state, which must have the next operation set
+type AuthFlowOperation func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error)
+
+// AuthFlow is a client-side state machine of iRODS 4.3 authentication framework
+// The flow starts from AuthFlowClientStart and runs operations until the next operation becomes AuthFlowComplete.
+type AuthFlow struct {
+	Scheme     string
+	Operations map[string]AuthFlowOperation
+}
+
+// Run runs the authentication flow
+func (flow *AuthFlow) Run(conn *IRODSConnection, initialState map[string]interface{}) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "connection",
+		"struct":   "AuthFlow",
+		"function": "Run",
+	})
+
+	state := map[string]interface{}{}
+	for k, v := range initialState {
+		state[k] = v
+	}
+
+	state[AuthFlowSchemeKey] = flow.Scheme
+	state[AuthFlowNextOperationKey] = AuthFlowClientStart
+
+	for {
+		nextOperation, ok := state[AuthFlowNextOperationKey].(string)
+		if !ok || len(nextOperation) == 0 {
+			return xerrors.Errorf("next operation is not set in auth flow %s: %w", flow.Scheme, types.NewAuthError(conn.account))
+		}
+
+		if nextOperation == AuthFlowComplete {
+			return nil
+		}
+
+		operation, ok := flow.Operations[nextOperation]
+		if !ok {
+			return xerrors.Errorf("unknown operation %s in auth flow %s: %w", nextOperation, flow.Scheme, types.NewAuthError(conn.account))
+		}
+
+		logger.Debugf("Running auth flow %s operation %s", flow.Scheme, nextOperation)
+
+		newState, err := operation(conn, state)
+		if err != nil {
+			return err
+		}
+
+		state = newState
+	}
+}
+
+// RequestAuthentication sends a request to iRODS 4.3 authentication API and returns the response
+func (conn *IRODSConnection) RequestAuthentication(request map[string]interface{}) (map[string]interface{}, error) {
+	authRequest := message.NewIRODSMessageAuthenticationRequest(request)
+	authResponse := message.IRODSMessageAuthenticationResponse{}
+	err := conn.RequestAndCheck(authRequest, &authResponse, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("received authentication error (%s): %w", err.Error(), types.NewAuthError(conn.account))
+	}
+
+	if authResponse.Body == nil {
+		return map[string]interface{}{}, nil
+	}
+	return authResponse.Body, nil
+}
+
+// SupportAuthFlow checks if the server supports iRODS 4.3 authentication framework
+func (conn *IRODSConnection) SupportAuthFlow() bool {
+	return conn.HasCapability(types.IRODSServerCapabilityAuthFramework)
+}
+
+func copyAuthFlowState(state map[string]interface{}) map[string]interface{} {
+	newState := map[string]interface{}{}
+	for k, v := range state {
+		newState[k] = v
+	}
+	return newState
+}
+
+func authFlowStart(conn *IRODSConnection, state map[string]interface{}, nextOperation string) (map[string]interface{}, error) {
+	newState := copyAuthFlowState(state)
+	newState[AuthFlowUserNameKey] = conn.account.ProxyUser
+	newState[AuthFlowZoneNameKey] = conn.account.ProxyZone
+	newState[AuthFlowNextOperationKey] = nextOperation
+	return newState, nil
+}
+
+func authFlowRequestServer(conn *IRODSConnection, state map[string]interface{}, serverOperation string, nextOperation string) (map[string]interface{}, error) {
+	request := copyAuthFlowState(state)
+	request[AuthFlowNextOperationKey] = serverOperation
+
+	response, err := conn.RequestAuthentication(request)
+	if err != nil {
+		return nil, err
+	}
+
+	newState := copyAuthFlowState(response)
+	newState[AuthFlowNextOperationKey] = nextOperation
+	return newState, nil
+}
+
+// NewNativeAuthFlow creates an AuthFlow for native authentication with the password
+func NewNativeAuthFlow(password string) *AuthFlow {
+	return &AuthFlow{
+		Scheme: AuthFlowNativeSchemeName,
+		Operations: map[string]AuthFlowOperation{
+			AuthFlowClientStart: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				return authFlowStart(conn, state, authFlowNativeRequest)
+			},
+			authFlowNativeRequest: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				return authFlowRequestServer(conn, state, AuthFlowAgentAuthRequest, authFlowNativeContext)
+			},
+			authFlowNativeContext: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				challenge, ok := state[AuthFlowRequestResultKey].(string)
+				if !ok {
+					return nil, xerrors.Errorf("failed to get authentication challenge: %w", types.NewAuthError(conn.account))
+				}
+
+				challengeBytes := make([]byte, authFlowChallengeMinLength)
+				copy(challengeBytes, []byte(challenge))
+				if len(challenge) > authFlowChallengeMinLength {
+					challengeBytes = []byte(challenge)
+				}
+
+				// save client signature
+				conn.clientSignature = conn.createClientSignature(challengeBytes)
+
+				newState := copyAuthFlowState(state)
+				delete(newState, AuthFlowRequestResultKey)
+				newState[AuthFlowDigestKey] = auth.GenerateAuthResponse(challengeBytes, password)
+				newState[AuthFlowNextOperationKey] = authFlowNativeResponse
+				return newState, nil
+			},
+			authFlowNativeResponse: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				return authFlowRequestServer(conn, state, AuthFlowAgentAuthResponse, AuthFlowComplete)
+			},
+		},
+	}
+}
+
+// NewPAMAuthFlow creates an AuthFlow for pam_password authentication
+// The password generated by the server is saved to the account's PamToken, then native authentication is performed with it.
+func NewPAMAuthFlow(password string, ttl int) *AuthFlow {
+	return &AuthFlow{
+		Scheme: AuthFlowPAMSchemeName,
+		Operations: map[string]AuthFlowOperation{
+			AuthFlowClientStart: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				newState, err := authFlowStart(conn, state, authFlowPAMRequest)
+				if err != nil {
+					return nil, err
+				}
+
+				newState[AuthFlowPasswordKey] = password
+				newState[AuthFlowTTLKey] = fmt.Sprintf("%d", ttl)
+				return newState, nil
+			},
+			authFlowPAMRequest: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				return authFlowRequestServer(conn, state, AuthFlowAgentAuthRequest, authFlowPAMContext)
+			},
+			authFlowPAMContext: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				generatedPassword, ok := state[AuthFlowRequestResultKey].(string)
+				if !ok {
+					return nil, xerrors.Errorf("failed to get pam generated password: %w", types.NewAuthError(conn.account))
+				}
+
+				// save irods generated password for possible future use
+				conn.account.PamToken = generatedPassword
+
+				newState := copyAuthFlowState(state)
+				delete(newState, AuthFlowPasswordKey)
+				delete(newState, AuthFlowRequestResultKey)
+				newState[AuthFlowNextOperationKey] = authFlowPAMPerformNative
+				return newState, nil
+			},
+			authFlowPAMPerformNative: func(conn *IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				err := NewNativeAuthFlow(conn.account.PamToken).Run(conn, map[string]interface{}{})
+				if err != nil {
+					return nil, err
+				}
+
+				newState := copyAuthFlowState(state)
+				newState[AuthFlowNextOperationKey] = AuthFlowComplete
+				return newState, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package connection
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/phdavis1027/go-irodsclient/irods/message"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Authenticator authenticates a connection using an authentication scheme
+type Authenticator interface {
+	// Authenticate is called after the connection is started up (and SSL is established if required).
+	// The connection is locked while Authenticate is called.
+	Authenticate(conn *IRODSConnection) error
+}
+
+// AuthenticatorFunc is a function that implements Authenticator
+type AuthenticatorFunc func(conn *IRODSConnection) error
+
+// Authenticate authenticates the connection
+func (f AuthenticatorFunc) Authenticate(conn *IRODSConnection) error {
+	return f(conn)
+}
+
+var (
+	authenticators = map[types.AuthScheme]Authenticator{
+		types.AuthSchemeNative:    &NativeAuthenticator{},
+		types.AuthSchemeGSI:       &GSIAuthenticator{},
+		types.AuthSchemePAM:       &PAMAuthenticator{},
+		types.AuthSchemePAMPlugin: NewPAMAuthPluginAuthenticator(),
+	}
+	authenticatorsMutex sync.RWMutex
+)
+
+// RegisterAuthenticator registers an authenticator for the authentication scheme, replacing existing one
+// scheme names are case-insensitive
+func RegisterAuthenticator(authScheme types.AuthScheme, authenticator Authenticator) {
+	scheme := types.RegisterAuthScheme(string(authScheme))
+
+	authenticatorsMutex.Lock()
+	defer authenticatorsMutex.Unlock()
+
+	authenticators[scheme] = authenticator
+}
+
+// UnregisterAuthenticator unregisters an authenticator for the authentication scheme
+// a custom scheme name is unregistered too, so GetAuthScheme no longer resolves it
+func UnregisterAuthenticator(authScheme types.AuthScheme) {
+	scheme := types.NormalizeAuthScheme(string(authScheme))
+
+	authenticatorsMutex.Lock()
+	defer authenticatorsMutex.Unlock()
+
+	delete(authenticators, scheme)
+	types.UnregisterAuthScheme(string(scheme))
+}
+
+// GetAuthenticator returns an authenticator for the authentication scheme, returns nil if not registered
+func GetAuthenticator(authScheme types.AuthScheme) Authenticator {
+	scheme := types.NormalizeAuthScheme(string(authScheme))
+
+	authenticatorsMutex.RLock()
+	defer authenticatorsMutex.RUnlock()
+
+	if authenticator, ok := authenticators[scheme]; ok {
+		return authenticator
+	}
+	return nil
+}
+
+// NativeAuthenticator authenticates using native authentication scheme
+type NativeAuthenticator struct{}
+
+// Authenticate authenticates the connection
+func (authenticator *NativeAuthenticator) Authenticate(conn *IRODSConnection) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "connection",
+		"struct":   "NativeAuthenticator",
+		"function": "Authenticate",
+	})
+
+	logger.Debug("Logging in using native authentication method")
+	return conn.LoginWithPassword(conn.account.Password)
+}
+
+// GSIAuthenticator authenticates using GSI authentication scheme, not yet implemented
+type GSIAuthenticator struct{}
+
+// Authenticate authenticates the connection
+func (authenticator *GSIAuthenticator) Authenticate(conn *IRODSConnection) error {
+	return xerrors.Errorf("GSI login is not yet implemented: %w", types.NewAuthError(conn.account))
+}
+
+// PAMAuthenticator authenticates using PAM authentication scheme
+// A password generated by the server is saved to the account's PamToken and reused in later logins.
+type PAMAuthenticator struct{}
+
+// Authenticate authenticates the connection
+func (authenticator *PAMAuthenticator) Authenticate(conn *IRODSConnection) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "connection",
+		"struct":   "PAMAuthenticator",
+		"function": "Authenticate",
+	})
+
+	logger.Debug("Logging in using pam authentication method")
+
+	// Check whether ssl has already started, if not, start ssl.
+	if !conn.IsSSL() {
+		return xerrors.Errorf("connection should be using SSL: %w", types.NewConnectionError())
+	}
+
+	if len(conn.account.PamToken) == 0 {
+		ttl := conn.account.PamTTL
+		if ttl <= 0 {
+			ttl = 1
+		}
+
+		if conn.SupportAuthFlow() {
+			// iRODS 4.3 authentication framework
+			return NewPAMAuthFlow(conn.account.Password, ttl).Run(conn, map[string]interface{}{})
+		}
+
+		// authenticate
+		pamAuthRequest := message.NewIRODSMessagePamAuthRequest(conn.account.ClientUser, conn.account.Password, ttl)
+		pamAuthResponse := message.IRODSMessagePamAuthResponse{}
+		err := conn.Request(pamAuthRequest, &pamAuthResponse, nil)
+		if err != nil {
+			return xerrors.Errorf("failed to receive an authentication challenge message (%s): %w", err.Error(), types.NewAuthError(conn.account))
+		}
+
+		// save irods generated password for possible future use
+		conn.account.PamToken = pamAuthResponse.GeneratedPassword
+	}
+
+	// retry native auth with generated password
+	return conn.LoginWithPassword(conn.account.PamToken)
+}
+
+// AuthPluginAuthenticator authenticates using server-side auth plugin (AUTH_PLUG_REQ_AN)
+// The context built by BuildContext is sent to the plugin of PluginScheme,
+// and the result returned by the plugin is handled by HandleResult.
+type AuthPluginAuthenticator struct {
+	// PluginScheme is the scheme name of the server-side auth plugin
+	PluginScheme string
+	// RequireSSL makes authentication fail if the connection is not using SSL
+	RequireSSL bool
+	// BuildContext returns a context string to be sent to the plugin
+	BuildContext func(conn *IRODSConnection) (string, error)
+	// HandleResult handles a result returned by the plugin, e.g., logging in with the returned password
+	HandleResult func(conn *IRODSConnection, result string) error
+}
+
+// NewPAMAuthPluginAuthenticator creates an AuthPluginAuthenticator that authenticates using PAM auth plugin
+// The password generated by the server is saved to the account's PamToken.
+func NewPAMAuthPluginAuthenticator() *AuthPluginAuthenticator {
+	return &AuthPluginAuthenticator{
+		PluginScheme: "pam",
+		RequireSSL:   true,
+		BuildContext: func(conn *IRODSConnection) (string, error) {
+			ttl := conn.account.PamTTL
+			if ttl <= 0 {
+				ttl = 1
+			}
+
+			return fmt.Sprintf("a_user=%s;a_pw=%s;a_ttl=%d", escapeAuthPluginContextValue(conn.account.ClientUser), escapeAuthPluginContextValue(conn.account.Password), ttl), nil
+		},
+		HandleResult: func(conn *IRODSConnection, result string) error {
+			// save irods generated password for possible future use
+			conn.account.PamToken = result
+			return conn.LoginWithPassword(result)
+		},
+	}
+}
+
+// escapeAuthPluginContextValue escapes delimiters of key-value pairs in an auth plugin context with backslashes
+// the server unescapes them, as iCommands sends passwords containing ';' or '='
+func escapeAuthPluginContextValue(value string) string {
+	return authPluginContextEscaper.Replace(value)
+}
+
+var authPluginContextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, "=", `\=`)
+
+// Authenticate authenticates the connection
+func (authenticator *AuthPluginAuthenticator) Authenticate(conn *IRODSConnection) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "connection",
+		"struct":   "AuthPluginAuthenticator",
+		"function": "Authenticate",
+	})
+
+	logger.Debugf("Logging in using auth plugin %s", authenticator.PluginScheme)
+
+	if authenticator.RequireSSL && !conn.IsSSL() {
+		return xerrors.Errorf("connection should be using SSL: %w", types.NewConnectionError())
+	}
+
+	context := ""
+	if authenticator.BuildContext != nil {
+		ctx, err := authenticator.BuildContext(conn)
+		if err != nil {
+			return xerrors.Errorf("failed to build auth plugin context (%s): %w", err.Error(), types.NewAuthError(conn.account))
+		}
+		context = ctx
+	}
+
+	request := message.NewIRODSMessageAuthPluginRequest(authenticator.PluginScheme, context)
+	response := message.IRODSMessageAuthPluginResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		return xerrors.Errorf("received auth plugin error (%s): %w", err.Error(), types.NewAuthError(conn.account))
+	}
+
+	if authenticator.HandleResult != nil {
+		return authenticator.HandleResult(conn, response.Result)
+	}
+	return nil
+}
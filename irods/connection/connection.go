@@ -34,6 +34,17 @@ type IRODSConnection struct {
 	requestTimeout  time.Duration
 	tcpBufferSize   int
 	applicationName string
+	dialer          Dialer
+
+	tcpKeepAlivePeriod time.Duration
+	heartbeatInterval  time.Duration
+	heartbeatTerminate chan bool
+
+	sendRateLimiter         *util.RateLimiter
+	recvRateLimiter         *util.RateLimiter
+	transferSendRateLimiter *util.RateLimiter
+	transferRecvRateLimiter *util.RateLimiter
+	rateLimitWaitTime       int64 // nanoseconds spent waiting for rate limiters, accessed atomically
 
 	connected            bool
 	isSSLSocket          bool
@@ -96,6 +107,16 @@ func (conn *IRODSConnection) Unlock() {
 	conn.mutex.Unlock()
 }
 
+// TryLock tries to lock connection, returns false if it is locked by others
+func (conn *IRODSConnection) TryLock() bool {
+	if !conn.mutex.TryLock() {
+		return false
+	}
+
+	conn.locked = true
+	return true
+}
+
 // GetAccount returns iRODSAccount
 func (conn *IRODSConnection) GetAccount() *types.IRODSAccount {
 	return conn.account
@@ -111,10 +132,25 @@ func (conn *IRODSConnection) SetTCPBufferSize(bufferSize int) {
 	conn.tcpBufferSize = bufferSize
 }
 
+// SetDialer sets a dialer used to connect to the server and resource servers, nil connects directly
+func (conn *IRODSConnection) SetDialer(dialer Dialer) {
+	conn.dialer = dialer
+}
+
+// GetDialer returns the dialer
+func (conn *IRODSConnection) GetDialer() Dialer {
+	return conn.dialer
+}
+
+// HasCapability checks if the server supports the capability, derived from the server version
+func (conn *IRODSConnection) HasCapability(capability types.IRODSServerCapability) bool {
+	return conn.serverVersion != nil && conn.serverVersion.HasCapability(capability)
+}
+
 // SupportParallelUpload checks if the server supports parallel upload
 // available from 4.2.9
 func (conn *IRODSConnection) SupportParallelUpload() bool {
-	return conn.serverVersion.HasHigherVersionThan(4, 2, 9)
+	return conn.HasCapability(types.IRODSServerCapabilityParallelTransfer)
 }
 
 func (conn *IRODSConnection) requiresCSNegotiation() bool {
@@ -181,6 +217,9 @@ func (conn *IRODSConnection) setSocketOpt(socket net.Conn, bufferSize int) {
 		//tcpSocket.SetNoDelay(true)
 
 		tcpSocket.SetKeepAlive(true)
+		if conn.tcpKeepAlivePeriod > 0 {
+			tcpSocket.SetKeepAlivePeriod(conn.tcpKeepAlivePeriod)
+		}
 
 		// TCP buffer size
 		if bufferSize <= 0 {
@@ -226,13 +265,12 @@ func (conn *IRODSConnection) Connect() error {
 	logger.Debugf("Connecting to %s", server)
 
 	// must connect to the server in 10 sec
-	var dialer net.Dialer
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
-	socket, err := dialer.DialContext(ctx, "tcp", server)
+	socket, err := dialWithDialer(ctx, conn.dialer, "tcp", server)
 	if err != nil {
-		connErr := xerrors.Errorf("failed to connect to specified host %s and port %d (%s): %w", conn.account.Host, conn.account.Port, err.Error(), types.NewConnectionError())
+		connErr := xerrors.Errorf("failed to connect to specified host %s and port %d: %w", conn.account.Host, conn.account.Port, types.NewConnectionErrorWithCause(err))
 		logger.Errorf("%+v", connErr)
 
 		if conn.metrics != nil {
@@ -259,7 +297,7 @@ func (conn *IRODSConnection) Connect() error {
 	}
 
 	if err != nil {
-		connErr := xerrors.Errorf("failed to startup an iRODS connection to server %s and port %d (%s): %w", conn.account.Host, conn.account.Port, err.Error(), types.NewConnectionError())
+		connErr := xerrors.Errorf("failed to startup an iRODS connection to server %s and port %d: %w", conn.account.Host, conn.account.Port, types.NewConnectionErrorWithCause(err))
 		logger.Errorf("%+v", connErr)
 		_ = conn.disconnectNow()
 		if conn.metrics != nil {
@@ -270,19 +308,11 @@ func (conn *IRODSConnection) Connect() error {
 
 	conn.serverVersion = irodsVersion
 
-	switch conn.account.AuthenticationScheme {
-	case types.AuthSchemeNative:
-		err = conn.loginNative()
-	case types.AuthSchemeGSI:
-		err = conn.loginGSI()
-	case types.AuthSchemePAM:
-		if len(conn.account.PamToken) > 0 {
-			err = conn.loginPAMWithToken()
-		} else {
-			err = conn.loginPAMWithPassword()
-		}
-	default:
+	authenticator := GetAuthenticator(conn.account.AuthenticationScheme)
+	if authenticator == nil {
 		err = xerrors.Errorf("unknown Authentication Scheme - %s: %w", conn.account.AuthenticationScheme, types.NewConnectionConfigError(conn.account))
+	} else {
+		err = authenticator.Authenticate(conn)
 	}
 
 	if err != nil {
@@ -303,6 +333,8 @@ func (conn *IRODSConnection) Connect() error {
 	conn.connected = true
 	conn.lastSuccessfulAccess = time.Now()
 
+	conn.startHeartbeat()
+
 	return nil
 }
 
@@ -325,13 +357,13 @@ func (conn *IRODSConnection) connectWithCSNegotiation() (*types.IRODSVersion, er
 	startup := message.NewIRODSMessageStartupPack(conn.account, conn.applicationName, true)
 	err := conn.RequestWithoutResponse(startup)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to send startup (%s): %w", err.Error(), types.NewConnectionError())
+		return nil, xerrors.Errorf("failed to send startup: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	// Server responds with negotiation response
 	negotiationMessage, err := conn.ReadMessage(nil)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to receive negotiation message (%s): %w", err.Error(), types.NewConnectionError())
+		return nil, xerrors.Errorf("failed to receive negotiation message: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	if negotiationMessage.Body == nil {
@@ -344,7 +376,7 @@ func (conn *IRODSConnection) connectWithCSNegotiation() (*types.IRODSVersion, er
 		version := message.IRODSMessageVersion{}
 		err = version.FromMessage(negotiationMessage)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to receive negotiation message (%s): %w", err.Error(), types.NewConnectionError())
+			return nil, xerrors.Errorf("failed to receive negotiation message: %w", types.NewConnectionErrorWithCause(err))
 		}
 
 		return version.GetVersion(), nil
@@ -355,12 +387,12 @@ func (conn *IRODSConnection) connectWithCSNegotiation() (*types.IRODSVersion, er
 		negotiation := message.IRODSMessageCSNegotiation{}
 		err = negotiation.FromMessage(negotiationMessage)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to receive negotiation message (%s): %w", err.Error(), types.NewConnectionError())
+			return nil, xerrors.Errorf("failed to receive negotiation message: %w", types.NewConnectionErrorWithCause(err))
 		}
 
 		serverPolicy, err := types.GetCSNegotiationRequire(negotiation.Result)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to parse server policy (%s): %w", err.Error(), types.NewConnectionError())
+			return nil, xerrors.Errorf("failed to parse server policy: %w", types.NewConnectionErrorWithCause(err))
 		}
 
 		logger.Debugf("Client policy - %s, server policy - %s", clientPolicy, serverPolicy)
@@ -378,7 +410,7 @@ func (conn *IRODSConnection) connectWithCSNegotiation() (*types.IRODSVersion, er
 		version := message.IRODSMessageVersion{}
 		err = conn.Request(negotiationResult, &version, nil)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to receive version message (%s): %w", err.Error(), types.NewConnectionError())
+			return nil, xerrors.Errorf("failed to receive version message: %w", types.NewConnectionErrorWithCause(err))
 		}
 
 		if policyResult == types.CSNegotiationUseSSL {
@@ -409,7 +441,7 @@ func (conn *IRODSConnection) connectWithoutCSNegotiation() (*types.IRODSVersion,
 	version := message.IRODSMessageVersion{}
 	err := conn.Request(startup, &version, nil)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to receive version message (%s): %w", err.Error(), types.NewConnectionError())
+		return nil, xerrors.Errorf("failed to receive version message: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	return version.GetVersion(), nil
@@ -429,15 +461,9 @@ func (conn *IRODSConnection) sslStartup() error {
 		return xerrors.Errorf("SSL Configuration is not set: %w", types.NewConnectionConfigError(conn.account))
 	}
 
-	caCertPool, err := irodsSSLConfig.LoadCACert()
+	sslConf, err := irodsSSLConfig.GetTLSConfig(conn.account.Host)
 	if err != nil {
-		return xerrors.Errorf("Failed to load CA Certificates: %w", err)
-	}
-
-	sslConf := &tls.Config{
-		RootCAs:            caCertPool,
-		ServerName:         conn.account.Host,
-		InsecureSkipVerify: true,
+		return xerrors.Errorf("failed to create TLS configuration (%s): %w", err.Error(), types.NewConnectionConfigError(conn.account))
 	}
 
 	// Create a side connection using the existing socket
@@ -445,7 +471,7 @@ func (conn *IRODSConnection) sslStartup() error {
 
 	err = sslSocket.Handshake()
 	if err != nil {
-		return xerrors.Errorf("SSL Handshake error (%s): %w", err.Error(), types.NewConnectionError())
+		return xerrors.Errorf("SSL Handshake error: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	// from now on use ssl socket
@@ -456,21 +482,21 @@ func (conn *IRODSConnection) sslStartup() error {
 	encryptionKey := make([]byte, irodsSSLConfig.EncryptionKeySize)
 	_, err = rand.Read(encryptionKey)
 	if err != nil {
-		return xerrors.Errorf("failed to generate shared secret (%s): %w", err.Error(), types.NewConnectionError())
+		return xerrors.Errorf("failed to generate shared secret: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	// Send a ssl setting
 	sslSetting := message.NewIRODSMessageSSLSettings(irodsSSLConfig.EncryptionAlgorithm, irodsSSLConfig.EncryptionKeySize, irodsSSLConfig.SaltSize, irodsSSLConfig.HashRounds)
 	err = conn.RequestWithoutResponse(sslSetting)
 	if err != nil {
-		return xerrors.Errorf("failed to send ssl setting message (%s): %w", err.Error(), types.NewConnectionError())
+		return xerrors.Errorf("failed to send ssl setting message: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	// Send a shared secret
 	sslSharedSecret := message.NewIRODSMessageSSLSharedSecret(encryptionKey)
 	err = conn.RequestWithoutResponseNoXML(sslSharedSecret)
 	if err != nil {
-		return xerrors.Errorf("failed to send ssl shared secret message (%s): %w", err.Error(), types.NewConnectionError())
+		return xerrors.Errorf("failed to send ssl shared secret message: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	conn.sslSharedSecret = encryptionKey
@@ -478,7 +504,14 @@ func (conn *IRODSConnection) sslStartup() error {
 	return nil
 }
 
-func (conn *IRODSConnection) login(password string) error {
+// LoginWithPassword authenticates the connection with the given password using native challenge-response
+// authenticators can call this to finish authentication with a password obtained from the server, e.g., PAM
+// iRODS 4.3 authentication framework is used if the server supports it
+func (conn *IRODSConnection) LoginWithPassword(password string) error {
+	if conn.SupportAuthFlow() {
+		return NewNativeAuthFlow(password).Run(conn, map[string]interface{}{})
+	}
+
 	// authenticate
 	authRequest := message.NewIRODSMessageAuthRequest()
 	authChallenge := message.IRODSMessageAuthChallengeResponse{}
@@ -506,81 +539,10 @@ func (conn *IRODSConnection) login(password string) error {
 	return nil
 }
 
-func (conn *IRODSConnection) loginNative() error {
-	logger := log.WithFields(log.Fields{
-		"package":  "connection",
-		"struct":   "IRODSConnection",
-		"function": "loginNative",
-	})
-
-	logger.Debug("Logging in using native authentication method")
-	return conn.login(conn.account.Password)
-}
-
-func (conn *IRODSConnection) loginGSI() error {
-	return xerrors.Errorf("GSI login is not yet implemented: %w", types.NewAuthError(conn.account))
-}
-
-func (conn *IRODSConnection) loginPAMWithPassword() error {
-	logger := log.WithFields(log.Fields{
-		"package":  "connection",
-		"struct":   "IRODSConnection",
-		"function": "loginPAMWithPassword",
-	})
-
-	logger.Debug("Logging in using pam authentication method")
-
-	// Check whether ssl has already started, if not, start ssl.
-	if _, ok := conn.socket.(*tls.Conn); !ok {
-		return xerrors.Errorf("connection should be using SSL: %w", types.NewConnectionError())
-	}
-
-	ttl := conn.account.PamTTL
-	if ttl <= 0 {
-		ttl = 1
-	}
-
-	// authenticate
-	pamAuthRequest := message.NewIRODSMessagePamAuthRequest(conn.account.ClientUser, conn.account.Password, ttl)
-	pamAuthResponse := message.IRODSMessagePamAuthResponse{}
-	err := conn.Request(pamAuthRequest, &pamAuthResponse, nil)
-	if err != nil {
-		return xerrors.Errorf("failed to receive an authentication challenge message (%s): %w", err.Error(), types.NewAuthError(conn.account))
-	}
-
-	// save irods generated password for possible future use
-	conn.account.PamToken = pamAuthResponse.GeneratedPassword
-
-	// retry native auth with generated password
-	return conn.login(conn.account.PamToken)
-}
-
-func (conn *IRODSConnection) loginPAMWithToken() error {
-	logger := log.WithFields(log.Fields{
-		"package":  "connection",
-		"struct":   "IRODSConnection",
-		"function": "loginPAMWithToken",
-	})
-
-	logger.Debug("Logging in using pam authentication method")
-
-	// Check whether ssl has already started, if not, start ssl.
-	if _, ok := conn.socket.(*tls.Conn); !ok {
-		return xerrors.Errorf("connection should be using SSL: %w", types.NewConnectionError())
-	}
-
-	ttl := conn.account.PamTTL
-	if ttl <= 0 {
-		ttl = 1
-	}
-
-	// retry native auth with generated password
-	return conn.login(conn.account.PamToken)
-}
-
 // Disconnect disconnects
 func (conn *IRODSConnection) disconnectNow() error {
 	conn.connected = false
+	conn.stopHeartbeat()
 	var err error
 	if conn.socket != nil {
 		err = conn.socket.Close()
@@ -645,7 +607,7 @@ func (conn *IRODSConnection) Send(buffer []byte, size int) error {
 // SendWithTrackerCallBack sends data
 func (conn *IRODSConnection) SendWithTrackerCallBack(buffer []byte, size int, callback common.TrackerCallBack) error {
 	if conn.socket == nil {
-		return xerrors.Errorf("failed to send data - socket closed")
+		return xerrors.Errorf("failed to send data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -656,10 +618,10 @@ func (conn *IRODSConnection) SendWithTrackerCallBack(buffer []byte, size int, ca
 		conn.socket.SetWriteDeadline(time.Now().Add(conn.requestTimeout))
 	}
 
-	err := util.WriteBytesWithTrackerCallBack(conn.socket, buffer, size, callback)
+	err := util.WriteBytesWithTrackerCallBack(conn.getRateLimitedSocket(conn.socket, conn.requestTimeout, &conn.rateLimitWaitTime), buffer, size, callback)
 	if err != nil {
 		conn.socketFail()
-		return xerrors.Errorf("failed to send data: %w", err)
+		return xerrors.Errorf("failed to send data: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	if size > 0 {
@@ -676,7 +638,7 @@ func (conn *IRODSConnection) SendWithTrackerCallBack(buffer []byte, size int, ca
 // SendFromReader sends data from Reader
 func (conn *IRODSConnection) SendFromReader(src io.Reader, size int64) error {
 	if conn.socket == nil {
-		return xerrors.Errorf("failed to send data - socket closed")
+		return xerrors.Errorf("failed to send data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -687,7 +649,7 @@ func (conn *IRODSConnection) SendFromReader(src io.Reader, size int64) error {
 		conn.socket.SetWriteDeadline(time.Now().Add(conn.requestTimeout))
 	}
 
-	copyLen, err := io.CopyN(conn.socket, src, size)
+	copyLen, err := io.CopyN(conn.getRateLimitedSocket(conn.socket, conn.requestTimeout, &conn.rateLimitWaitTime), src, size)
 	if copyLen != size {
 		return xerrors.Errorf("failed to send data. src returned EOF (requested %d, copied %d)", size, copyLen)
 	}
@@ -701,7 +663,7 @@ func (conn *IRODSConnection) SendFromReader(src io.Reader, size int64) error {
 	if err != nil {
 		if err != io.EOF {
 			conn.socketFail()
-			return xerrors.Errorf("failed to send data: %w", err)
+			return xerrors.Errorf("failed to send data: %w", types.NewConnectionErrorWithCause(err))
 		}
 	}
 
@@ -718,7 +680,7 @@ func (conn *IRODSConnection) Recv(buffer []byte, size int) (int, error) {
 // Recv receives a message
 func (conn *IRODSConnection) RecvWithTrackerCallBack(buffer []byte, size int, callback common.TrackerCallBack) (int, error) {
 	if conn.socket == nil {
-		return 0, xerrors.Errorf("failed to receive data - socket closed")
+		return 0, xerrors.Errorf("failed to receive data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -729,10 +691,10 @@ func (conn *IRODSConnection) RecvWithTrackerCallBack(buffer []byte, size int, ca
 		conn.socket.SetReadDeadline(time.Now().Add(conn.requestTimeout))
 	}
 
-	readLen, err := util.ReadBytesWithTrackerCallBack(conn.socket, buffer, size, callback)
+	readLen, err := util.ReadBytesWithTrackerCallBack(conn.getRateLimitedSocket(conn.socket, conn.requestTimeout, &conn.rateLimitWaitTime), buffer, size, callback)
 	if err != nil {
 		conn.socketFail()
-		return readLen, xerrors.Errorf("failed to receive data: %w", err)
+		return readLen, xerrors.Errorf("failed to receive data: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	if readLen > 0 {
@@ -749,7 +711,7 @@ func (conn *IRODSConnection) RecvWithTrackerCallBack(buffer []byte, size int, ca
 // RecvToWriter receives a message to Writer
 func (conn *IRODSConnection) RecvToWriter(writer io.Writer, size int64) (int64, error) {
 	if conn.socket == nil {
-		return 0, xerrors.Errorf("failed to receive data - socket closed")
+		return 0, xerrors.Errorf("failed to receive data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -760,7 +722,7 @@ func (conn *IRODSConnection) RecvToWriter(writer io.Writer, size int64) (int64,
 		conn.socket.SetReadDeadline(time.Now().Add(conn.requestTimeout))
 	}
 
-	copyLen, err := io.CopyN(writer, conn.socket, size)
+	copyLen, err := io.CopyN(writer, conn.getRateLimitedSocket(conn.socket, conn.requestTimeout, &conn.rateLimitWaitTime), size)
 	if copyLen > 0 {
 		if conn.metrics != nil {
 			conn.metrics.IncreaseBytesReceived(uint64(copyLen))
@@ -770,7 +732,7 @@ func (conn *IRODSConnection) RecvToWriter(writer io.Writer, size int64) (int64,
 	if err != nil {
 		if err != io.EOF {
 			conn.socketFail()
-			return copyLen, xerrors.Errorf("failed to receive data: %w", err)
+			return copyLen, xerrors.Errorf("failed to receive data: %w", types.NewConnectionErrorWithCause(err))
 		}
 	}
 
@@ -1031,6 +993,31 @@ func (conn *IRODSConnection) poorMansEndTransaction(dummyCol string, commit bool
 	return nil
 }
 
+// CheckHealth checks if the connection is still usable by sending a cheap request (GET_MISC_SVR_INFO)
+// that does not touch the catalog. The connection must be locked before use.
+func (conn *IRODSConnection) CheckHealth() error {
+	if !conn.locked {
+		return xerrors.Errorf("connection must be locked before use")
+	}
+
+	if !conn.IsConnected() {
+		return xerrors.Errorf("connection is closed: %w", types.NewConnectionError())
+	}
+
+	// the request does not open a database transaction, keep the flag as it was
+	dirtyTransaction := conn.IsTransactionDirty()
+	defer conn.SetTransactionDirty(dirtyTransaction)
+
+	request := message.NewIRODSMessageGetMiscServerInfoRequest()
+	response := message.IRODSMessageGetMiscServerInfoResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to check connection health: %w", err)
+	}
+
+	return nil
+}
+
 // RawBind binds an IRODSConnection to a raw net.Conn socket - to be used for e.g. a proxy server setup
 func (conn *IRODSConnection) RawBind(socket net.Conn) {
 	conn.connected = true
@@ -0,0 +1,334 @@
+package connection
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Dialer dials a network connection to iRODS servers
+// implement this to reach servers through proxies, tunnels or custom transports
+type Dialer interface {
+	DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
+// DialerFunc is an adapter to use a function as a Dialer, e.g., ssh.Client.Dial for SSH tunnels
+type DialerFunc func(ctx context.Context, network string, address string) (net.Conn, error)
+
+// DialContext calls the function
+func (dialerFunc DialerFunc) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	return dialerFunc(ctx, network, address)
+}
+
+// NewDirectDialer creates a Dialer that connects directly
+func NewDirectDialer() Dialer {
+	return &net.Dialer{}
+}
+
+// dialWithDialer dials with the dialer, or directly if the dialer is nil
+func dialWithDialer(ctx context.Context, dialer Dialer, network string, address string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = NewDirectDialer()
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+// setDeadlineFromContext applies the context deadline to the socket for proxy handshakes
+func setDeadlineFromContext(ctx context.Context, socket net.Conn) {
+	if deadline, ok := ctx.Deadline(); ok {
+		socket.SetDeadline(deadline)
+	}
+}
+
+const (
+	socks5Version              byte = 0x05
+	socks5AuthNone             byte = 0x00
+	socks5AuthUsernamePassword byte = 0x02
+	socks5AuthNoAcceptable     byte = 0xff
+	socks5CommandConnect       byte = 0x01
+	socks5AddressIPv4          byte = 0x01
+	socks5AddressDomain        byte = 0x03
+	socks5AddressIPv6          byte = 0x04
+)
+
+// SOCKS5Dialer dials through a SOCKS5 proxy (RFC 1928), with optional username/password authentication (RFC 1929)
+type SOCKS5Dialer struct {
+	ProxyAddress string // host:port of the proxy
+	Username     string
+	Password     string
+	Forward      Dialer // dialer to reach the proxy, nil connects directly
+}
+
+// NewSOCKS5Dialer creates a SOCKS5Dialer
+func NewSOCKS5Dialer(proxyAddress string, username string, password string) *SOCKS5Dialer {
+	return &SOCKS5Dialer{
+		ProxyAddress: proxyAddress,
+		Username:     username,
+		Password:     password,
+		Forward:      nil,
+	}
+}
+
+// DialContext connects to the address through the proxy
+func (dialer *SOCKS5Dialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, xerrors.Errorf("unsupported network %s for socks5 proxy", network)
+	}
+
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse address %s: %w", address, err)
+	}
+
+	port, err := strconv.Atoi(portString)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, xerrors.Errorf("invalid port in address %s", address)
+	}
+
+	socket, err := dialWithDialer(ctx, dialer.Forward, "tcp", dialer.ProxyAddress)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to connect to socks5 proxy %s: %w", dialer.ProxyAddress, err)
+	}
+
+	setDeadlineFromContext(ctx, socket)
+
+	err = dialer.handshake(socket, host, port)
+	if err != nil {
+		socket.Close()
+		return nil, xerrors.Errorf("failed to connect to %s through socks5 proxy %s: %w", address, dialer.ProxyAddress, err)
+	}
+
+	// clear deadline
+	socket.SetDeadline(time.Time{})
+	return socket, nil
+}
+
+func (dialer *SOCKS5Dialer) handshake(socket net.Conn, host string, port int) error {
+	// greeting
+	methods := []byte{socks5AuthNone}
+	if len(dialer.Username) > 0 {
+		methods = append(methods, socks5AuthUsernamePassword)
+	}
+
+	greeting := []byte{socks5Version, byte(len(methods))}
+	greeting = append(greeting, methods...)
+	_, err := socket.Write(greeting)
+	if err != nil {
+		return xerrors.Errorf("failed to send greeting: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(socket, reply)
+	if err != nil {
+		return xerrors.Errorf("failed to read greeting reply: %w", err)
+	}
+
+	if reply[0] != socks5Version {
+		return xerrors.Errorf("unexpected socks version %d", reply[0])
+	}
+
+	switch reply[1] {
+	case socks5AuthNone:
+		// pass
+	case socks5AuthUsernamePassword:
+		err = dialer.authenticate(socket)
+		if err != nil {
+			return err
+		}
+	case socks5AuthNoAcceptable:
+		return xerrors.Errorf("no acceptable authentication methods")
+	default:
+		return xerrors.Errorf("unsupported authentication method %d", reply[1])
+	}
+
+	// connect request
+	request := []byte{socks5Version, socks5CommandConnect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, socks5AddressIPv4)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, socks5AddressIPv6)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return xerrors.Errorf("host name %s is too long", host)
+		}
+
+		// let the proxy resolve the host name
+		request = append(request, socks5AddressDomain, byte(len(host)))
+		request = append(request, host...)
+	}
+
+	portBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBytes, uint16(port))
+	request = append(request, portBytes...)
+
+	_, err = socket.Write(request)
+	if err != nil {
+		return xerrors.Errorf("failed to send connect request: %w", err)
+	}
+
+	// reply header: version, status, reserved, address type
+	header := make([]byte, 4)
+	_, err = io.ReadFull(socket, header)
+	if err != nil {
+		return xerrors.Errorf("failed to read connect reply: %w", err)
+	}
+
+	if header[1] != 0x00 {
+		return xerrors.Errorf("proxy refused to connect, status %d", header[1])
+	}
+
+	// skip bound address and port
+	var addressLen int
+	switch header[3] {
+	case socks5AddressIPv4:
+		addressLen = net.IPv4len
+	case socks5AddressIPv6:
+		addressLen = net.IPv6len
+	case socks5AddressDomain:
+		lenBytes := make([]byte, 1)
+		_, err = io.ReadFull(socket, lenBytes)
+		if err != nil {
+			return xerrors.Errorf("failed to read bound address: %w", err)
+		}
+		addressLen = int(lenBytes[0])
+	default:
+		return xerrors.Errorf("unknown bound address type %d", header[3])
+	}
+
+	_, err = io.ReadFull(socket, make([]byte, addressLen+2))
+	if err != nil {
+		return xerrors.Errorf("failed to read bound address: %w", err)
+	}
+
+	return nil
+}
+
+func (dialer *SOCKS5Dialer) authenticate(socket net.Conn) error {
+	if len(dialer.Username) > 255 || len(dialer.Password) > 255 {
+		return xerrors.Errorf("username or password is too long")
+	}
+
+	request := []byte{0x01, byte(len(dialer.Username))}
+	request = append(request, dialer.Username...)
+	request = append(request, byte(len(dialer.Password)))
+	request = append(request, dialer.Password...)
+
+	_, err := socket.Write(request)
+	if err != nil {
+		return xerrors.Errorf("failed to send authentication request: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(socket, reply)
+	if err != nil {
+		return xerrors.Errorf("failed to read authentication reply: %w", err)
+	}
+
+	if reply[1] != 0x00 {
+		return xerrors.Errorf("proxy authentication failed, status %d", reply[1])
+	}
+
+	return nil
+}
+
+// HTTPConnectDialer dials through an HTTP proxy using CONNECT method, with optional basic authentication
+type HTTPConnectDialer struct {
+	ProxyAddress string // host:port of the proxy
+	Username     string
+	Password     string
+	Forward      Dialer // dialer to reach the proxy, nil connects directly
+}
+
+// NewHTTPConnectDialer creates a HTTPConnectDialer
+func NewHTTPConnectDialer(proxyAddress string, username string, password string) *HTTPConnectDialer {
+	return &HTTPConnectDialer{
+		ProxyAddress: proxyAddress,
+		Username:     username,
+		Password:     password,
+		Forward:      nil,
+	}
+}
+
+// DialContext connects to the address through the proxy
+func (dialer *HTTPConnectDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, xerrors.Errorf("unsupported network %s for http proxy", network)
+	}
+
+	socket, err := dialWithDialer(ctx, dialer.Forward, "tcp", dialer.ProxyAddress)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to connect to http proxy %s: %w", dialer.ProxyAddress, err)
+	}
+
+	setDeadlineFromContext(ctx, socket)
+
+	proxySocket, err := dialer.handshake(socket, address)
+	if err != nil {
+		socket.Close()
+		return nil, xerrors.Errorf("failed to connect to %s through http proxy %s: %w", address, dialer.ProxyAddress, err)
+	}
+
+	// clear deadline
+	socket.SetDeadline(time.Time{})
+	return proxySocket, nil
+}
+
+func (dialer *HTTPConnectDialer) handshake(socket net.Conn, address string) (net.Conn, error) {
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if len(dialer.Username) > 0 {
+		credential := base64.StdEncoding.EncodeToString([]byte(dialer.Username + ":" + dialer.Password))
+		request += fmt.Sprintf("Proxy-Authorization: Basic %s\r\n", credential)
+	}
+	request += "\r\n"
+
+	_, err := socket.Write([]byte(request))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to send connect request: %w", err)
+	}
+
+	reader := bufio.NewReader(socket)
+	response, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read connect response: %w", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("proxy refused to connect, status %s", response.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		// the server spoke first, keep what the reader has buffered
+		return &bufferedConn{
+			Conn:   socket,
+			reader: reader,
+		}, nil
+	}
+
+	return socket, nil
+}
+
+// bufferedConn is a net.Conn that reads buffered data first
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads from the buffer, then from the connection
+func (conn *bufferedConn) Read(buffer []byte) (int, error) {
+	return conn.reader.Read(buffer)
+}
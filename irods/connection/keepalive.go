@@ -0,0 +1,105 @@
+package connection
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SetTCPKeepAlivePeriod sets TCP keepalive period of the control connection and resource server connections
+// 0 uses the OS default. It must be set before Connect.
+func (conn *IRODSConnection) SetTCPKeepAlivePeriod(period time.Duration) {
+	conn.tcpKeepAlivePeriod = period
+}
+
+// GetTCPKeepAlivePeriod returns TCP keepalive period
+func (conn *IRODSConnection) GetTCPKeepAlivePeriod() time.Duration {
+	return conn.tcpKeepAlivePeriod
+}
+
+// SetHeartbeatInterval sets an interval to send heartbeats when the connection is idle, 0 disables heartbeats
+// heartbeats keep idle control connections from being dropped by stateful firewalls, e.g., during long transfers
+// through resource server redirection. It must be set before Connect.
+func (conn *IRODSConnection) SetHeartbeatInterval(interval time.Duration) {
+	conn.heartbeatInterval = interval
+}
+
+// GetHeartbeatInterval returns heartbeat interval
+func (conn *IRODSConnection) GetHeartbeatInterval() time.Duration {
+	return conn.heartbeatInterval
+}
+
+// startHeartbeat starts sending heartbeats in background, the connection must be locked
+func (conn *IRODSConnection) startHeartbeat() {
+	if conn.heartbeatInterval <= 0 || conn.heartbeatTerminate != nil {
+		return
+	}
+
+	terminate := make(chan bool)
+	conn.heartbeatTerminate = terminate
+
+	go conn.runHeartbeat(conn.heartbeatInterval, terminate)
+}
+
+// stopHeartbeat stops sending heartbeats, the connection must be locked
+func (conn *IRODSConnection) stopHeartbeat() {
+	if conn.heartbeatTerminate != nil {
+		close(conn.heartbeatTerminate)
+		conn.heartbeatTerminate = nil
+	}
+}
+
+func (conn *IRODSConnection) runHeartbeat(interval time.Duration, terminate chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminate:
+			return
+		case <-ticker.C:
+			conn.sendHeartbeat(interval)
+		}
+	}
+}
+
+// sendHeartbeat sends a heartbeat if the connection has been idle for the interval
+// connections in use are skipped, they are not idle
+func (conn *IRODSConnection) sendHeartbeat(interval time.Duration) {
+	logger := log.WithFields(log.Fields{
+		"package":  "connection",
+		"struct":   "IRODSConnection",
+		"function": "sendHeartbeat",
+	})
+
+	if !conn.TryLock() {
+		return
+	}
+	defer conn.Unlock()
+
+	if !conn.connected {
+		return
+	}
+
+	lastAccess := conn.lastSuccessfulAccess
+	if time.Since(lastAccess) < interval {
+		return
+	}
+
+	if conn.metrics != nil {
+		conn.metrics.IncreaseCounterForHeartbeats(1)
+	}
+
+	err := conn.CheckHealth()
+	if err != nil {
+		logger.WithError(err).Warn("failed to send a heartbeat")
+
+		if conn.metrics != nil {
+			conn.metrics.IncreaseCounterForHeartbeatFailures(1)
+		}
+		return
+	}
+
+	// heartbeats are not activities, idle timeout of the pool still applies
+	conn.lastSuccessfulAccess = lastAccess
+}
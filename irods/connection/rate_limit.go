@@ -0,0 +1,151 @@
+package connection
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+)
+
+const (
+	// rateLimitedIOBlockSize is the max size of a socket read or write while rate limiting, for smooth throughput
+	rateLimitedIOBlockSize int = 64 * 1024
+)
+
+// SetRateLimiters sets rate limiters shared with other connections, e.g., a limit of a session, nil disables it
+// they apply to the connection and its resource server connections
+func (conn *IRODSConnection) SetRateLimiters(sendLimiter *util.RateLimiter, recvLimiter *util.RateLimiter) {
+	conn.sendRateLimiter = sendLimiter
+	conn.recvRateLimiter = recvLimiter
+}
+
+// GetRateLimiters returns rate limiters for sending and receiving
+func (conn *IRODSConnection) GetRateLimiters() (*util.RateLimiter, *util.RateLimiter) {
+	return conn.sendRateLimiter, conn.recvRateLimiter
+}
+
+// SetTransferRateLimiters sets rate limiters of a transfer using the connection, applied on top of shared rate limiters
+// the transfer must clear them by passing nil before the connection is returned to a pool
+func (conn *IRODSConnection) SetTransferRateLimiters(sendLimiter *util.RateLimiter, recvLimiter *util.RateLimiter) {
+	conn.transferSendRateLimiter = sendLimiter
+	conn.transferRecvRateLimiter = recvLimiter
+}
+
+// GetTransferRateLimiters returns rate limiters of a transfer for sending and receiving
+func (conn *IRODSConnection) GetTransferRateLimiters() (*util.RateLimiter, *util.RateLimiter) {
+	return conn.transferSendRateLimiter, conn.transferRecvRateLimiter
+}
+
+// GetRateLimitWaitTime returns total time spent waiting for rate limiters while sending or receiving
+// transfers subtract it from elapsed time to measure throughput of the network
+func (conn *IRODSConnection) GetRateLimitWaitTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&conn.rateLimitWaitTime))
+}
+
+// GetRateLimitWaitTime returns total time spent waiting for rate limiters while sending or receiving
+func (conn *IRODSResourceServerConnection) GetRateLimitWaitTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&conn.rateLimitWaitTime))
+}
+
+// getRateLimitedSocket returns the socket throttled by rate limiters of the connection
+// time spent waiting for the limiters is added to waitTime
+func (conn *IRODSConnection) getRateLimitedSocket(socket net.Conn, timeout time.Duration, waitTime *int64) net.Conn {
+	sendLimiters := collectRateLimiters(conn.sendRateLimiter, conn.transferSendRateLimiter)
+	recvLimiters := collectRateLimiters(conn.recvRateLimiter, conn.transferRecvRateLimiter)
+
+	if len(sendLimiters) == 0 && len(recvLimiters) == 0 {
+		return socket
+	}
+
+	return &rateLimitedSocket{
+		Conn:         socket,
+		timeout:      timeout,
+		sendLimiters: sendLimiters,
+		recvLimiters: recvLimiters,
+		waitTime:     waitTime,
+	}
+}
+
+// getRateLimitedSocket returns the socket throttled by rate limiters of the control connection
+func (conn *IRODSResourceServerConnection) getRateLimitedSocket() net.Conn {
+	return conn.controlConnection.getRateLimitedSocket(conn.socket, conn.controlConnection.requestTimeout, &conn.rateLimitWaitTime)
+}
+
+func collectRateLimiters(limiters ...*util.RateLimiter) []*util.RateLimiter {
+	collected := []*util.RateLimiter{}
+	for _, limiter := range limiters {
+		if limiter != nil {
+			collected = append(collected, limiter)
+		}
+	}
+	return collected
+}
+
+// rateLimitedSocket throttles reads and writes of a socket
+// deadlines are extended at each block as waiting for the limiters is not a timeout of the server
+type rateLimitedSocket struct {
+	net.Conn
+	timeout      time.Duration
+	sendLimiters []*util.RateLimiter
+	recvLimiters []*util.RateLimiter
+	waitTime     *int64
+}
+
+// wait waits for the limiters to transfer size bytes
+func (socket *rateLimitedSocket) wait(limiters []*util.RateLimiter, size int) {
+	if len(limiters) == 0 || size <= 0 {
+		return
+	}
+
+	waitStartTime := time.Now()
+	for _, limiter := range limiters {
+		limiter.Wait(size)
+	}
+
+	if socket.waitTime != nil {
+		atomic.AddInt64(socket.waitTime, int64(time.Since(waitStartTime)))
+	}
+}
+
+// Write writes data in blocks, waiting for the send limiters before each block
+func (socket *rateLimitedSocket) Write(buffer []byte) (int, error) {
+	totalWritten := 0
+	for totalWritten < len(buffer) {
+		blockLen := len(buffer) - totalWritten
+		if blockLen > rateLimitedIOBlockSize {
+			blockLen = rateLimitedIOBlockSize
+		}
+
+		socket.wait(socket.sendLimiters, blockLen)
+
+		if socket.timeout > 0 {
+			socket.Conn.SetWriteDeadline(time.Now().Add(socket.timeout))
+		}
+
+		written, err := socket.Conn.Write(buffer[totalWritten : totalWritten+blockLen])
+		totalWritten += written
+		if err != nil {
+			return totalWritten, err
+		}
+	}
+
+	return totalWritten, nil
+}
+
+// Read reads data up to a block, waiting for the receive limiters after the block is read
+func (socket *rateLimitedSocket) Read(buffer []byte) (int, error) {
+	if len(buffer) > rateLimitedIOBlockSize {
+		buffer = buffer[:rateLimitedIOBlockSize]
+	}
+
+	if socket.timeout > 0 {
+		socket.Conn.SetReadDeadline(time.Now().Add(socket.timeout))
+	}
+
+	readLen, err := socket.Conn.Read(buffer)
+
+	socket.wait(socket.recvLimiters, readLen)
+
+	return readLen, err
+}
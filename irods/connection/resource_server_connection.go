@@ -24,6 +24,8 @@ type IRODSResourceServerConnection struct {
 	serverInfo        *types.IRODSRedirectionInfo
 	tcpBufferSize     int
 
+	rateLimitWaitTime int64 // nanoseconds spent waiting for rate limiters, accessed atomically
+
 	connected            bool
 	socket               net.Conn
 	creationTime         time.Time
@@ -119,6 +121,9 @@ func (conn *IRODSResourceServerConnection) setSocketOpt(socket net.Conn, bufferS
 		//tcpSocket.SetNoDelay(true)
 
 		tcpSocket.SetKeepAlive(true)
+		if conn.controlConnection != nil && conn.controlConnection.tcpKeepAlivePeriod > 0 {
+			tcpSocket.SetKeepAlivePeriod(conn.controlConnection.tcpKeepAlivePeriod)
+		}
 
 		// TCP buffer size
 		if bufferSize <= 0 {
@@ -162,13 +167,18 @@ func (conn *IRODSResourceServerConnection) Connect() error {
 	logger.Debugf("Connecting to %s", server)
 
 	// must connect to the server in 10 sec
-	var dialer net.Dialer
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
-	socket, err := dialer.DialContext(ctx, "tcp", server)
+	// use the same dialer as the control connection to go through the same proxy
+	var dialer Dialer
+	if conn.controlConnection != nil {
+		dialer = conn.controlConnection.GetDialer()
+	}
+
+	socket, err := dialWithDialer(ctx, dialer, "tcp", server)
 	if err != nil {
-		connErr := xerrors.Errorf("failed to connect to specified host %s and port %d (%s): %w", conn.serverInfo.Host, conn.serverInfo.Port, err.Error(), types.NewConnectionError())
+		connErr := xerrors.Errorf("failed to connect to specified host %s and port %d: %w", conn.serverInfo.Host, conn.serverInfo.Port, types.NewConnectionErrorWithCause(err))
 		logger.Errorf("%+v", connErr)
 
 		if conn.metrics != nil {
@@ -188,7 +198,7 @@ func (conn *IRODSResourceServerConnection) Connect() error {
 	auth := message.NewIRODSMessageResourceServerAuth(conn.serverInfo)
 	authBytes, err := auth.GetBytes()
 	if err != nil {
-		connErr := xerrors.Errorf("failed to make authentication request (%s): %w", err.Error(), types.NewConnectionError())
+		connErr := xerrors.Errorf("failed to make authentication request: %w", types.NewConnectionErrorWithCause(err))
 		logger.Errorf("%+v", connErr)
 		_ = conn.disconnectNow()
 		if conn.metrics != nil {
@@ -272,7 +282,7 @@ func (conn *IRODSResourceServerConnection) Send(buffer []byte, size int) error {
 // SendWithTrackerCallBack sends data
 func (conn *IRODSResourceServerConnection) SendWithTrackerCallBack(buffer []byte, size int, callback common.TrackerCallBack) error {
 	if conn.socket == nil {
-		return xerrors.Errorf("failed to send data - socket closed")
+		return xerrors.Errorf("failed to send data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -283,10 +293,10 @@ func (conn *IRODSResourceServerConnection) SendWithTrackerCallBack(buffer []byte
 		conn.socket.SetWriteDeadline(time.Now().Add(conn.controlConnection.requestTimeout))
 	}
 
-	err := util.WriteBytesWithTrackerCallBack(conn.socket, buffer, size, callback)
+	err := util.WriteBytesWithTrackerCallBack(conn.getRateLimitedSocket(), buffer, size, callback)
 	if err != nil {
 		conn.socketFail()
-		return xerrors.Errorf("failed to send data: %w", err)
+		return xerrors.Errorf("failed to send data: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	if size > 0 {
@@ -303,7 +313,7 @@ func (conn *IRODSResourceServerConnection) SendWithTrackerCallBack(buffer []byte
 // SendFromReader sends data from Reader
 func (conn *IRODSResourceServerConnection) SendFromReader(src io.Reader, size int64) error {
 	if conn.socket == nil {
-		return xerrors.Errorf("failed to send data - socket closed")
+		return xerrors.Errorf("failed to send data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -314,7 +324,7 @@ func (conn *IRODSResourceServerConnection) SendFromReader(src io.Reader, size in
 		conn.socket.SetWriteDeadline(time.Now().Add(conn.controlConnection.requestTimeout))
 	}
 
-	copyLen, err := io.CopyN(conn.socket, src, size)
+	copyLen, err := io.CopyN(conn.getRateLimitedSocket(), src, size)
 	if copyLen != size {
 		return xerrors.Errorf("failed to send data. failed to send data fully (requested %d vs sent %d)", size, copyLen)
 	}
@@ -328,7 +338,7 @@ func (conn *IRODSResourceServerConnection) SendFromReader(src io.Reader, size in
 	if err != nil {
 		if err != io.EOF {
 			conn.socketFail()
-			return xerrors.Errorf("failed to send data: %w", err)
+			return xerrors.Errorf("failed to send data: %w", types.NewConnectionErrorWithCause(err))
 		}
 	}
 
@@ -345,7 +355,7 @@ func (conn *IRODSResourceServerConnection) Recv(buffer []byte, size int) (int, e
 // RecvWithTrackerCallBack receives a message
 func (conn *IRODSResourceServerConnection) RecvWithTrackerCallBack(buffer []byte, size int, callback common.TrackerCallBack) (int, error) {
 	if conn.socket == nil {
-		return 0, xerrors.Errorf("failed to receive data - socket closed")
+		return 0, xerrors.Errorf("failed to receive data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -356,10 +366,10 @@ func (conn *IRODSResourceServerConnection) RecvWithTrackerCallBack(buffer []byte
 		conn.socket.SetReadDeadline(time.Now().Add(conn.controlConnection.requestTimeout))
 	}
 
-	readLen, err := util.ReadBytesWithTrackerCallBack(conn.socket, buffer, size, callback)
+	readLen, err := util.ReadBytesWithTrackerCallBack(conn.getRateLimitedSocket(), buffer, size, callback)
 	if err != nil {
 		conn.socketFail()
-		return readLen, xerrors.Errorf("failed to receive data: %w", err)
+		return readLen, xerrors.Errorf("failed to receive data: %w", types.NewConnectionErrorWithCause(err))
 	}
 
 	if readLen > 0 {
@@ -376,7 +386,7 @@ func (conn *IRODSResourceServerConnection) RecvWithTrackerCallBack(buffer []byte
 // RecvToWriter receives a message to Writer
 func (conn *IRODSResourceServerConnection) RecvToWriter(writer io.Writer, size int64) (int64, error) {
 	if conn.socket == nil {
-		return 0, xerrors.Errorf("failed to receive data - socket closed")
+		return 0, xerrors.Errorf("failed to receive data - socket closed: %w", types.NewConnectionError())
 	}
 
 	if !conn.locked {
@@ -387,7 +397,7 @@ func (conn *IRODSResourceServerConnection) RecvToWriter(writer io.Writer, size i
 		conn.socket.SetReadDeadline(time.Now().Add(conn.controlConnection.requestTimeout))
 	}
 
-	copyLen, err := io.CopyN(writer, conn.socket, size)
+	copyLen, err := io.CopyN(writer, conn.getRateLimitedSocket(), size)
 	if copyLen > 0 {
 		if conn.metrics != nil {
 			conn.metrics.IncreaseBytesReceived(uint64(copyLen))
@@ -397,7 +407,7 @@ func (conn *IRODSResourceServerConnection) RecvToWriter(writer io.Writer, size i
 	if err != nil {
 		if err != io.EOF {
 			conn.socketFail()
-			return copyLen, xerrors.Errorf("failed to receive data: %w", err)
+			return copyLen, xerrors.Errorf("failed to receive data: %w", types.NewConnectionErrorWithCause(err))
 		}
 	}
 
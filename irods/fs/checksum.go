@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"fmt"
+
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/message"
@@ -10,6 +12,15 @@ import (
 
 // GetDataObjectChecksum returns a data object checksum for the path
 func GetDataObjectChecksum(conn *connection.IRODSConnection, path string, resource string) (*types.IRODSChecksum, error) {
+	return getDataObjectChecksum(conn, path, resource, -1, false)
+}
+
+// ComputeDataObjectReplicaChecksum computes a checksum of a replica of a data object for the path again, even if the catalog has one
+func ComputeDataObjectReplicaChecksum(conn *connection.IRODSConnection, path string, replicaNumber int64) (*types.IRODSChecksum, error) {
+	return getDataObjectChecksum(conn, path, "", replicaNumber, true)
+}
+
+func getDataObjectChecksum(conn *connection.IRODSConnection, path string, resource string, replicaNumber int64, force bool) (*types.IRODSChecksum, error) {
 	if conn == nil || !conn.IsConnected() {
 		return nil, xerrors.Errorf("connection is nil or disconnected")
 	}
@@ -30,6 +41,14 @@ func GetDataObjectChecksum(conn *connection.IRODSConnection, path string, resour
 	}
 
 	request := message.NewIRODSMessageChecksumRequest(path, resource)
+	if replicaNumber >= 0 {
+		request.AddKeyVal(common.REPL_NUM_KW, fmt.Sprintf("%d", replicaNumber))
+	}
+
+	if force {
+		request.AddKeyVal(common.FORCE_CHKSUM_KW, "")
+	}
+
 	response := message.IRODSMessageChecksumResponse{}
 	err := conn.RequestAndCheck(request, &response, nil)
 	if err != nil {
@@ -665,6 +665,68 @@ func DeleteCollection(conn *connection.IRODSConnection, path string, recurse boo
 	return nil
 }
 
+// UnregisterCollection unregisters a collection for the path from the catalog, physical files are not deleted
+// if recurse is set, all data objects and sub-collections are unregistered too, otherwise the collection must be empty
+func UnregisterCollection(conn *connection.IRODSConnection, path string, recurse bool, adminFlag bool) error {
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	if recurse {
+		collection, err := GetCollection(conn, path)
+		if err != nil {
+			return err
+		}
+
+		dataObjects, err := ListDataObjectsMasterReplica(conn, collection)
+		if err != nil {
+			return err
+		}
+
+		for _, dataObject := range dataObjects {
+			err = UnregisterDataObject(conn, dataObject.Path, adminFlag)
+			if err != nil {
+				return err
+			}
+		}
+
+		subCollections, err := ListSubCollections(conn, path)
+		if err != nil {
+			return err
+		}
+
+		for _, subCollection := range subCollections {
+			err = UnregisterCollection(conn, subCollection.Path, recurse, adminFlag)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForCollectionDelete(1)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request := message.NewIRODSMessageUnregisterCollectionRequest(path, adminFlag)
+	response := message.IRODSMessageRemoveCollectionResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return xerrors.Errorf("failed to find the collection for path %s: %w", path, types.NewFileNotFoundError(path))
+		} else if types.GetIRODSErrorCode(err) == common.CAT_COLLECTION_NOT_EMPTY {
+			return xerrors.Errorf("the collection for path %s is not empty: %w", path, types.NewCollectionNotEmptyError(path))
+		}
+
+		return xerrors.Errorf("failed to unregister collection: %w", err)
+	}
+	return nil
+}
+
 // MoveCollection moves a collection for the path to another path
 func MoveCollection(conn *connection.IRODSConnection, srcPath string, destPath string) error {
 	if conn == nil || !conn.IsConnected() {
@@ -692,6 +754,30 @@ func MoveCollection(conn *connection.IRODSConnection, srcPath string, destPath s
 	return nil
 }
 
+// ModifyCollectionModifyTime changes the modification time of a collection for the path
+func ModifyCollectionModifyTime(conn *connection.IRODSConnection, path string, modifyTime time.Time) error {
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request := message.NewIRODSMessageModifyCollectionRequest(path)
+	request.AddKeyVal(common.COLLECTION_MTIME_KW, util.GetIRODSDateTimeString(modifyTime))
+
+	response := message.IRODSMessageModifyCollectionResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return xerrors.Errorf("failed to find the collection for path %s: %w", path, types.NewFileNotFoundError(path))
+		}
+		return xerrors.Errorf("failed to modify collection modification time: %w", err)
+	}
+	return nil
+}
+
 // AddCollectionMeta sets metadata of a data object for the path to the given key values.
 // metadata.AVUID is ignored
 func AddCollectionMeta(conn *connection.IRODSConnection, path string, metadata *types.IRODSMeta) error {
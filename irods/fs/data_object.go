@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -135,6 +136,7 @@ func GetDataObject(conn *connection.IRODSConnection, collection *types.IRODSColl
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -170,6 +172,7 @@ func GetDataObject(conn *connection.IRODSConnection, collection *types.IRODSColl
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -328,6 +331,7 @@ func GetDataObjectMasterReplica(conn *connection.IRODSConnection, collection *ty
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -363,6 +367,7 @@ func GetDataObjectMasterReplica(conn *connection.IRODSConnection, collection *ty
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -526,6 +531,7 @@ func ListDataObjects(conn *connection.IRODSConnection, collection *types.IRODSCo
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -562,6 +568,7 @@ func ListDataObjects(conn *connection.IRODSConnection, collection *types.IRODSCo
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -715,6 +722,7 @@ func ListDataObjectsMasterReplica(conn *connection.IRODSConnection, collection *
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -751,6 +759,7 @@ func ListDataObjectsMasterReplica(conn *connection.IRODSConnection, collection *
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -1185,6 +1194,174 @@ func DeleteDataObject(conn *connection.IRODSConnection, path string, force bool)
 	return nil
 }
 
+// ListDataObjectReplicas returns replicas of a data object for the path, sorted by replica number
+func ListDataObjectReplicas(conn *connection.IRODSConnection, collection *types.IRODSCollection, filename string) ([]*types.IRODSReplica, error) {
+	dataObject, err := GetDataObject(conn, collection, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := dataObject.Replicas
+	sort.SliceStable(replicas, func(i int, j int) bool {
+		return replicas[i].Number < replicas[j].Number
+	})
+
+	return replicas, nil
+}
+
+// ModifyDataObjectReplicaStatus changes the status of a replica of a data object for the path
+// replica is selected by replicaNumber, resourceHierarchy is optional. adminFlag requires rodsadmin privilege.
+func ModifyDataObjectReplicaStatus(conn *connection.IRODSConnection, path string, replicaNumber int64, resourceHierarchy string, status types.ReplicaStatus, adminFlag bool) error {
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForDataObjectUpdate(1)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request := message.NewIRODSMessageModifyDataObjectReplicaStatusRequest(path, replicaNumber, resourceHierarchy, string(status), adminFlag)
+	response := message.IRODSMessageModifyDataObjectMetaResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return xerrors.Errorf("failed to find the data object for path %s: %w", path, types.NewFileNotFoundError(path))
+		}
+		return xerrors.Errorf("failed to modify data object replica status: %w", err)
+	}
+	return nil
+}
+
+// ModifyDataObjectModifyTime changes the modification time of all replicas of a data object for the path
+func ModifyDataObjectModifyTime(conn *connection.IRODSConnection, path string, modifyTime time.Time) error {
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForDataObjectUpdate(1)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request := message.NewIRODSMessageModifyDataObjectModifyTimeRequest(path, util.GetIRODSDateTimeString(modifyTime))
+	response := message.IRODSMessageModifyDataObjectMetaResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return xerrors.Errorf("failed to find the data object for path %s: %w", path, types.NewFileNotFoundError(path))
+		}
+		return xerrors.Errorf("failed to modify data object modification time: %w", err)
+	}
+	return nil
+}
+
+// UnregisterDataObject unregisters all replicas of a data object for the path from the catalog, physical files are not deleted
+func UnregisterDataObject(conn *connection.IRODSConnection, path string, adminFlag bool) error {
+	return UnregisterDataObjectReplica(conn, path, -1, adminFlag)
+}
+
+// UnregisterDataObjectReplica unregisters a replica of a data object for the path from the catalog, physical files are not deleted
+// all replicas are unregistered if replicaNumber is negative
+func UnregisterDataObjectReplica(conn *connection.IRODSConnection, path string, replicaNumber int64, adminFlag bool) error {
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// the server unregisters a replica by data id and replica number
+	dataID := int64(0)
+	if replicaNumber >= 0 {
+		collection, err := GetCollection(conn, util.GetIRODSPathDirname(path))
+		if err != nil {
+			return xerrors.Errorf("failed to get collection of %s: %w", path, err)
+		}
+
+		dataObject, err := GetDataObject(conn, collection, util.GetIRODSPathFileName(path))
+		if err != nil {
+			return xerrors.Errorf("failed to get data object %s: %w", path, err)
+		}
+
+		found := false
+		for _, replica := range dataObject.Replicas {
+			if replica.Number == replicaNumber {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return xerrors.Errorf("failed to find replica %d of data object %s: %w", replicaNumber, path, types.NewFileNotFoundError(path))
+		}
+
+		dataID = dataObject.ID
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForDataObjectDelete(1)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request := message.NewIRODSMessageUnregisterDataObjectRequest(path, dataID, replicaNumber, adminFlag)
+	response := message.IRODSMessageUnregisterDataObjectResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return xerrors.Errorf("failed to find the data object for path %s: %w", path, types.NewFileNotFoundError(path))
+		}
+		return xerrors.Errorf("failed to unregister data object: %w", err)
+	}
+	return nil
+}
+
+// StatPhysicalReplica returns the size of the physical file of a replica in its resource
+// it fails if the file does not exist, the API requires admin
+func StatPhysicalReplica(conn *connection.IRODSConnection, dataObject *types.IRODSDataObject, replica *types.IRODSReplica) (int64, error) {
+	if conn == nil || !conn.IsConnected() {
+		return -1, xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// the physical file is in the leaf resource of the hierarchy
+	leafResourceName := replica.ResourceName
+	if len(replica.ResourceHierarchy) > 0 {
+		hierarchy := strings.Split(replica.ResourceHierarchy, ";")
+		leafResourceName = hierarchy[len(hierarchy)-1]
+	}
+
+	resource, err := GetResource(conn, leafResourceName)
+	if err != nil {
+		return -1, xerrors.Errorf("failed to get resource %s: %w", leafResourceName, err)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	request, err := message.NewIRODSMessageGetFileStatRequest(resource, dataObject, replica)
+	if err != nil {
+		return -1, xerrors.Errorf("failed to make a file stat request: %w", err)
+	}
+
+	response := message.IRODSMessageGetFileStatResponse{}
+	err = conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		return -1, xerrors.Errorf("failed to stat physical file %s of replica %d: %w", replica.Path, replica.Number, err)
+	}
+
+	return response.Size, nil
+}
+
 // MoveDataObject moves a data object for the path to another path
 func MoveDataObject(conn *connection.IRODSConnection, srcPath string, destPath string) error {
 	if conn == nil || !conn.IsConnected() {
@@ -1500,6 +1677,62 @@ func OpenDataObjectWithReplicaToken(conn *connection.IRODSConnection, path strin
 	return handle, offset, nil
 }
 
+// OpenDataObjectReplica opens a specific replica of a data object for the path, returns a file handle
+// replica is selected by resourceHierarchy if given, otherwise by replicaNumber
+func OpenDataObjectReplica(conn *connection.IRODSConnection, path string, replicaNumber int64, resourceHierarchy string, mode string) (*types.IRODSFileHandle, int64, error) {
+	if conn == nil || !conn.IsConnected() {
+		return nil, -1, xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	if replicaNumber < 0 && len(resourceHierarchy) == 0 {
+		return nil, -1, xerrors.Errorf("either replica number or resource hierarchy must be given")
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForDataObjectOpen(1)
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	fileOpenMode := types.FileOpenMode(mode)
+
+	request := message.NewIRODSMessageOpenobjRequestWithReplica(path, fileOpenMode, replicaNumber, resourceHierarchy)
+	response := message.IRODSMessageOpenDataObjectResponse{}
+	err := conn.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_NO_ROWS_FOUND {
+			return nil, -1, xerrors.Errorf("failed to find the data object for path %s: %w", path, types.NewFileNotFoundError(path))
+		}
+		return nil, -1, xerrors.Errorf("failed to open data object replica: %w", err)
+	}
+
+	handle := &types.IRODSFileHandle{
+		FileDescriptor: response.GetFileDescriptor(),
+		Path:           path,
+		OpenMode:       fileOpenMode,
+		Resource:       resourceHierarchy,
+		Oper:           common.OPER_TYPE_NONE,
+	}
+
+	if metrics != nil {
+		metrics.IncreaseCounterForOpenFileHandles(1)
+	}
+
+	// handle seek
+	var offset int64 = 0
+	if fileOpenMode.SeekToEnd() {
+		offset, err = seekDataObject(conn, handle, 0, types.SeekEnd)
+		if err != nil {
+			return handle, -1, err
+		}
+	}
+
+	return handle, offset, nil
+}
+
 // OpenDataObjectWithOperation opens a data object for the path, returns a file handle
 func OpenDataObjectWithOperation(conn *connection.IRODSConnection, path string, resource string, mode string, oper common.OperationType) (*types.IRODSFileHandle, error) {
 	if conn == nil || !conn.IsConnected() {
@@ -2238,6 +2471,7 @@ func SearchDataObjectsByMeta(conn *connection.IRODSConnection, metaName string,
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -2290,6 +2524,7 @@ func SearchDataObjectsByMeta(conn *connection.IRODSConnection, metaName string,
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -2447,6 +2682,7 @@ func SearchDataObjectsMasterReplicaByMeta(conn *connection.IRODSConnection, meta
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -2499,6 +2735,7 @@ func SearchDataObjectsMasterReplicaByMeta(conn *connection.IRODSConnection, meta
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -2664,6 +2901,7 @@ func SearchDataObjectsByMetaWildcard(conn *connection.IRODSConnection, metaName
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -2716,6 +2954,7 @@ func SearchDataObjectsByMetaWildcard(conn *connection.IRODSConnection, metaName
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -2874,6 +3113,7 @@ func SearchDataObjectsMasterReplicaByMetaWildcard(conn *connection.IRODSConnecti
 					replica := &types.IRODSReplica{
 						Number:            -1,
 						Owner:             "",
+						Size:              0,
 						Checksum:          nil,
 						Status:            "",
 						ResourceName:      "",
@@ -2926,6 +3166,7 @@ func SearchDataObjectsMasterReplicaByMetaWildcard(conn *connection.IRODSConnecti
 						return nil, xerrors.Errorf("failed to parse data object size '%s': %w", value, err)
 					}
 					pagenatedDataObjects[row].Size = objSize
+					pagenatedDataObjects[row].Replicas[0].Size = objSize
 				case int(common.ICAT_COLUMN_DATA_TYPE_NAME):
 					pagenatedDataObjects[row].DataType = value
 				case int(common.ICAT_COLUMN_DATA_REPL_NUM):
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
@@ -20,20 +22,31 @@ import (
 
 // CloseDataObjectReplica closes a file handle of a data object replica, only used by parallel upload
 func CloseDataObjectReplica(conn *connection.IRODSConnection, handle *types.IRODSFileHandle) error {
+	return CloseDataObjectReplicaWithOptions(conn, handle, false, false, false)
+}
+
+// CloseDataObjectReplicaWithOptions closes a file handle of a data object replica
+// updateSize and updateStatus control whether the catalog is updated with the replica's size and status on close
+func CloseDataObjectReplicaWithOptions(conn *connection.IRODSConnection, handle *types.IRODSFileHandle, updateSize bool, updateStatus bool, computeChecksum bool) error {
 	if conn == nil || !conn.IsConnected() {
 		return xerrors.Errorf("connection is nil or disconnected")
 	}
 
+	if !conn.HasCapability(types.IRODSServerCapabilityReplicaClose) {
+		return xerrors.Errorf("does not support close replica in current iRODS Version")
+	}
+
+	metrics := conn.GetMetrics()
+	if metrics != nil {
+		metrics.IncreaseCounterForDataObjectClose(1)
+		metrics.DecreaseCounterForOpenFileHandles(1)
+	}
+
 	// lock the connection
 	conn.Lock()
 	defer conn.Unlock()
 
-	if !conn.SupportParallelUpload() {
-		// serial upload
-		return xerrors.Errorf("does not support close replica in current iRODS Version")
-	}
-
-	request := message.NewIRODSMessageCloseDataObjectReplicaRequest(handle.FileDescriptor, false, false, false, false, false)
+	request := message.NewIRODSMessageCloseDataObjectReplicaRequest(handle.FileDescriptor, false, updateSize, updateStatus, computeChecksum, false)
 	response := message.IRODSMessageCloseDataObjectReplicaResponse{}
 	err := conn.RequestAndCheck(request, &response, nil)
 	if err != nil {
@@ -46,14 +59,32 @@ func CloseDataObjectReplica(conn *connection.IRODSConnection, handle *types.IROD
 }
 
 // UploadDataObjectFromBuffer put a data object to the iRODS path from buffer
-func UploadDataObjectFromBuffer(session *session.IRODSSession, buffer bytes.Buffer, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+func UploadDataObjectFromBuffer(session *session.IRODSSession, buffer *bytes.Buffer, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+	reader := bytes.NewReader(buffer.Bytes())
+	return UploadDataObjectFromReader(session, reader, reader.Size(), irodsPath, resource, 1, replicate, callback)
+}
+
+// UploadDataObject put a data object at the local path to the iRODS path
+func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "UploadDataObject",
+	})
+
 	// use default resource when resource param is empty
 	if len(resource) == 0 {
 		account := session.GetAccount()
 		resource = account.DefaultResource
 	}
 
-	fileLength := int64(buffer.Len())
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat file %s: %w", localPath, err)
+	}
+
+	fileLength := stat.Size()
+
+	logger.Debugf("upload data object %s", localPath)
 
 	conn, err := session.AcquireConnection()
 	if err != nil {
@@ -65,6 +96,12 @@ func UploadDataObjectFromBuffer(session *session.IRODSSession, buffer bytes.Buff
 		return xerrors.Errorf("connection is nil or disconnected")
 	}
 
+	f, err := os.OpenFile(localPath, os.O_RDONLY, 0)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer f.Close()
+
 	// open a new file
 	handle, err := OpenDataObjectWithOperation(conn, irodsPath, resource, "w+", common.OPER_TYPE_NONE)
 	if err != nil {
@@ -76,10 +113,38 @@ func UploadDataObjectFromBuffer(session *session.IRODSSession, buffer bytes.Buff
 		callback(totalBytesUploaded, fileLength)
 	}
 
+	// block write call-back
+	blockWriteCallback := func(processed int64, total int64) {
+		if callback != nil {
+			callback(totalBytesUploaded+processed, fileLength)
+		}
+	}
+
 	// copy
-	writeErr := WriteDataObjectWithTrackerCallBack(conn, handle, buffer.Bytes(), nil)
-	if callback != nil {
-		callback(fileLength, fileLength)
+	buffer := make([]byte, common.ReadWriteBufferSize)
+	var writeErr error
+	for {
+		bytesRead, readErr := f.Read(buffer)
+		if bytesRead > 0 {
+			writeErr = WriteDataObjectWithTrackerCallBack(conn, handle, buffer[:bytesRead], blockWriteCallback)
+			if writeErr != nil {
+				break
+			}
+
+			totalBytesUploaded += int64(bytesRead)
+			if callback != nil {
+				callback(totalBytesUploaded, fileLength)
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			} else {
+				writeErr = xerrors.Errorf("failed to read file %s: %w", localPath, readErr)
+				break
+			}
+		}
 	}
 
 	CloseDataObject(conn, handle)
@@ -99,11 +164,60 @@ func UploadDataObjectFromBuffer(session *session.IRODSSession, buffer bytes.Buff
 	return nil
 }
 
-// UploadDataObject put a data object at the local path to the iRODS path
-func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+// UploadDataObjectParallel put a data object at the local path to the iRODS path in parallel
+// Partitions a file into n (taskNum) tasks and uploads in parallel
+func UploadDataObjectParallel(session *session.IRODSSession, localPath string, irodsPath string, resource string, taskNum int, replicate bool, callback common.TrackerCallBack) error {
+	if !session.SupportParallelUpload() {
+		// serial upload
+		return UploadDataObject(session, localPath, irodsPath, resource, replicate, callback)
+	}
+
+	// use default resource when resource param is empty
+	if len(resource) == 0 {
+		account := session.GetAccount()
+		resource = account.DefaultResource
+	}
+
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat file %s: %w", localPath, err)
+	}
+
+	fileLength := stat.Size()
+
+	numTasks := taskNum
+	if numTasks <= 0 {
+		numTasks = session.GetParallelTransferTuner().GetNumTasks(fileLength)
+	}
+
+	if numTasks == 1 || fileLength == 0 {
+		// serial upload
+		return UploadDataObject(session, localPath, irodsPath, resource, replicate, callback)
+	}
+
+	f, err := os.OpenFile(localPath, os.O_RDONLY, 0)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer f.Close()
+
+	return uploadDataObjectFromReaderAtParallel(session, f, fileLength, irodsPath, resource, numTasks, replicate, callback)
+}
+
+// UploadDataObjectResumable put a data object at the local path to the iRODS path with support of transfer resume
+// progress is kept in a transfer status file in statusDir, or in the user cache directory if statusDir is empty.
+// The status is discarded if the size or the modification time of the local file changes.
+// A failed attempt marks the replica stale, and a retry reopens it and continues from data already sent.
+// A replica left locked by a crashed attempt is unlocked, and the retry continues from data recorded in the status.
+// If verifyContent is true, data already sent is verified with the checksum of the replica before resuming.
+func UploadDataObjectResumable(session *session.IRODSSession, localPath string, irodsPath string, resource string, statusDir string, replicate bool, verifyContent bool, callback common.TrackerCallBack) error {
+	return uploadDataObjectResumable(session, localPath, irodsPath, resource, statusDir, replicate, verifyContent, nil, callback)
+}
+
+func uploadDataObjectResumable(session *session.IRODSSession, localPath string, irodsPath string, resource string, statusDir string, replicate bool, verifyContent bool, blockHook DataObjectTransferBlockHook, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "fs",
-		"function": "UploadDataObject",
+		"function": "uploadDataObjectResumable",
 	})
 
 	// use default resource when resource param is empty
@@ -121,6 +235,17 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 
 	logger.Debugf("upload data object %s", localPath)
 
+	// create transfer status
+	transferStatusLocal, err := GetOrNewDataObjectUploadTransferStatusLocal(statusDir, localPath, irodsPath, fileLength, stat.ModTime(), 1)
+	if err != nil {
+		return xerrors.Errorf("failed to read transfer status file for %s: %w", localPath, err)
+	}
+
+	if transferStatusLocal.GetStatus().Threads != 1 {
+		// previous transfer was in parallel
+		transferStatusLocal = NewDataObjectUploadTransferStatusLocal(statusDir, localPath, irodsPath, fileLength, stat.ModTime(), 1)
+	}
+
 	conn, err := session.AcquireConnection()
 	if err != nil {
 		return xerrors.Errorf("failed to get connection: %w", err)
@@ -137,13 +262,48 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 	}
 	defer f.Close()
 
-	// open a new file
-	handle, err := OpenDataObjectWithOperation(conn, irodsPath, resource, "w+", common.OPER_TYPE_NONE)
+	uploadedLengths, err := getUploadedLengths(conn, transferStatusLocal.GetStatus(), f, irodsPath, resource, 1, fileLength, verifyContent)
+	if err != nil {
+		return err
+	}
+
+	lastOffset := uploadedLengths[0]
+
+	reader := newBlockHookReaderAt(f, blockHook)
+
+	// open the existing replica to resume, or a new file
+	openMode := "w+"
+	if lastOffset > 0 {
+		openMode = "w"
+	}
+
+	handle, err := OpenDataObjectWithOperation(conn, irodsPath, resource, openMode, common.OPER_TYPE_NONE)
 	if err != nil {
 		return xerrors.Errorf("failed to open data object %s: %w", irodsPath, err)
 	}
 
-	totalBytesUploaded := int64(0)
+	if lastOffset > 0 {
+		logger.Debugf("resuming uploading data object %s from offset %d", irodsPath, lastOffset)
+
+		newOffset, err := SeekDataObject(conn, handle, lastOffset, types.SeekSet)
+		if err != nil {
+			closeFailedUploadReplica(session, conn, handle, irodsPath, resource, "")
+			return xerrors.Errorf("failed to seek data object %s to offset %d: %w", irodsPath, lastOffset, err)
+		}
+
+		if newOffset != lastOffset {
+			closeFailedUploadReplica(session, conn, handle, irodsPath, resource, "")
+			return xerrors.Errorf("failed to seek data object %s to target offset %d", irodsPath, lastOffset)
+		}
+	}
+
+	err = createUploadTransferStatusFile(transferStatusLocal, uploadedLengths, fileLength, fileLength)
+	if err != nil {
+		closeFailedUploadReplica(session, conn, handle, irodsPath, resource, "")
+		return xerrors.Errorf("failed to create transfer status file for %s: %w", localPath, err)
+	}
+
+	totalBytesUploaded := lastOffset
 	if callback != nil {
 		callback(totalBytesUploaded, fileLength)
 	}
@@ -158,8 +318,8 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 	// copy
 	buffer := make([]byte, common.ReadWriteBufferSize)
 	var writeErr error
-	for {
-		bytesRead, readErr := f.Read(buffer)
+	for totalBytesUploaded < fileLength {
+		bytesRead, readErr := reader.ReadAt(buffer, totalBytesUploaded)
 		if bytesRead > 0 {
 			writeErr = WriteDataObjectWithTrackerCallBack(conn, handle, buffer[:bytesRead], blockWriteCallback)
 			if writeErr != nil {
@@ -167,6 +327,15 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 			}
 
 			totalBytesUploaded += int64(bytesRead)
+
+			// write status
+			transferStatusEntry := &DataObjectTransferStatusEntry{
+				StartOffset:     0,
+				Length:          fileLength,
+				CompletedLength: totalBytesUploaded,
+			}
+			transferStatusLocal.WriteStatus(transferStatusEntry)
+
 			if callback != nil {
 				callback(totalBytesUploaded, fileLength)
 			}
@@ -182,12 +351,25 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 		}
 	}
 
-	CloseDataObject(conn, handle)
+	if writeErr == nil && totalBytesUploaded != fileLength {
+		writeErr = xerrors.Errorf("failed to upload data object %s, expected %d bytes but read %d bytes", irodsPath, fileLength, totalBytesUploaded)
+	}
+
+	transferStatusLocal.CloseStatusFile()
 
 	if writeErr != nil {
+		// keep the status file to resume later
+		closeFailedUploadReplica(session, conn, handle, irodsPath, resource, "")
 		return writeErr
 	}
 
+	err = CloseDataObject(conn, handle)
+	if err != nil {
+		return err
+	}
+
+	transferStatusLocal.DeleteStatusFile()
+
 	// replicate
 	if replicate {
 		replErr := ReplicateDataObject(conn, irodsPath, "", true, false)
@@ -199,17 +381,22 @@ func UploadDataObject(session *session.IRODSSession, localPath string, irodsPath
 	return nil
 }
 
-// UploadDataObjectParallel put a data object at the local path to the iRODS path in parallel
-// Partitions a file into n (taskNum) tasks and uploads in parallel
-func UploadDataObjectParallel(session *session.IRODSSession, localPath string, irodsPath string, resource string, taskNum int, replicate bool, callback common.TrackerCallBack) error {
-	logger := log.WithFields(log.Fields{
-		"package":  "fs",
-		"function": "UploadDataObjectParallel",
-	})
+// UploadDataObjectParallelResumable put a data object at the local path to the iRODS path in parallel with support of transfer resume
+// progress of each task is kept in a transfer status file in statusDir, or in the user cache directory if statusDir is empty.
+// The status is discarded if the size or the modification time of the local file changes.
+// A failed attempt marks the replica stale, and a retry reopens it and each task continues from data already sent.
+// A replica left locked by a crashed attempt is unlocked, and the retry continues from data recorded in the status.
+// If verifyContent is true, data already sent is verified with the checksum of the replica before resuming.
+func UploadDataObjectParallelResumable(session *session.IRODSSession, localPath string, irodsPath string, resource string, statusDir string, taskNum int, replicate bool, verifyContent bool, callback common.TrackerCallBack) error {
+	return UploadDataObjectParallelResumableWithBlockHook(session, localPath, irodsPath, resource, statusDir, taskNum, replicate, verifyContent, nil, callback)
+}
 
+// UploadDataObjectParallelResumableWithBlockHook put a data object in parallel with support of transfer resume
+// blockHook is called by every task before it reads a block, it can throttle the task or stop it by returning an error
+func UploadDataObjectParallelResumableWithBlockHook(session *session.IRODSSession, localPath string, irodsPath string, resource string, statusDir string, taskNum int, replicate bool, verifyContent bool, blockHook DataObjectTransferBlockHook, callback common.TrackerCallBack) error {
 	if !session.SupportParallelUpload() {
 		// serial upload
-		return UploadDataObject(session, localPath, irodsPath, resource, replicate, callback)
+		return uploadDataObjectResumable(session, localPath, irodsPath, resource, statusDir, replicate, verifyContent, blockHook, callback)
 	}
 
 	// use default resource when resource param is empty
@@ -227,251 +414,342 @@ func UploadDataObjectParallel(session *session.IRODSSession, localPath string, i
 
 	numTasks := taskNum
 	if numTasks <= 0 {
-		numTasks = util.GetNumTasksForParallelTransfer(fileLength)
+		numTasks = session.GetParallelTransferTuner().GetNumTasks(fileLength)
 	}
 
-	if numTasks == 1 {
+	if numTasks == 1 || fileLength == 0 {
 		// serial upload
-		return UploadDataObject(session, localPath, irodsPath, resource, replicate, callback)
+		return uploadDataObjectResumable(session, localPath, irodsPath, resource, statusDir, replicate, verifyContent, blockHook, callback)
 	}
 
-	conn, err := session.AcquireUnmanagedConnection()
+	// create transfer status
+	transferStatusLocal, err := GetOrNewDataObjectUploadTransferStatusLocal(statusDir, localPath, irodsPath, fileLength, stat.ModTime(), numTasks)
 	if err != nil {
-		return xerrors.Errorf("failed to get connection: %w", err)
+		return xerrors.Errorf("failed to read transfer status file for %s: %w", localPath, err)
 	}
-	defer session.DiscardConnection(conn)
 
-	if conn == nil || !conn.IsConnected() {
-		return xerrors.Errorf("connection is nil or disconnected")
+	// if previous transfer used different number of threads, use old value
+	numTasks = transferStatusLocal.GetStatus().Threads
+
+	if numTasks == 1 {
+		// serial upload
+		return uploadDataObjectResumable(session, localPath, irodsPath, resource, statusDir, replicate, verifyContent, blockHook, callback)
 	}
 
-	logger.Debugf("upload data object in parallel %s, size(%d), threads(%d)", irodsPath, fileLength, numTasks)
+	lengthPerThread := fileLength / int64(numTasks)
+	if fileLength%int64(numTasks) > 0 {
+		lengthPerThread++
+	}
 
-	// open a new file
-	handle, err := OpenDataObjectForPutParallel(conn, irodsPath, resource, "w+", common.OPER_TYPE_NONE, numTasks, fileLength)
+	f, err := os.OpenFile(localPath, os.O_RDONLY, 0)
 	if err != nil {
-		return err
+		return xerrors.Errorf("failed to open file %s: %w", localPath, err)
 	}
+	defer f.Close()
 
-	replicaToken, resourceHierarchy, err := GetReplicaAccessInfo(conn, handle)
+	conn, err := session.AcquireConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+
+	uploadedLengths, err := getUploadedLengths(conn, transferStatusLocal.GetStatus(), f, irodsPath, resource, numTasks, lengthPerThread, verifyContent)
+	session.ReturnConnection(conn)
 	if err != nil {
-		CloseDataObject(conn, handle)
 		return err
 	}
 
-	logger.Debugf("replicaToken %s, resourceHierarchy %s", replicaToken, resourceHierarchy)
+	err = createUploadTransferStatusFile(transferStatusLocal, uploadedLengths, lengthPerThread, fileLength)
+	if err != nil {
+		return xerrors.Errorf("failed to create transfer status file for %s: %w", localPath, err)
+	}
 
-	errChan := make(chan error, numTasks)
-	taskWaitGroup := sync.WaitGroup{}
+	statusMutex := sync.Mutex{}
+	rangeCallback := func(taskOffset int64, taskLength int64, completedLength int64) {
+		statusMutex.Lock()
+		defer statusMutex.Unlock()
 
-	totalBytesUploaded := int64(0)
-	if callback != nil {
-		callback(totalBytesUploaded, fileLength)
+		transferStatusLocal.WriteStatus(&DataObjectTransferStatusEntry{
+			StartOffset:     taskOffset,
+			Length:          taskLength,
+			CompletedLength: completedLength,
+		})
 	}
 
-	uploadTask := func(taskOffset int64, taskLength int64) {
-		defer taskWaitGroup.Done()
+	reader := newBlockHookReaderAt(f, blockHook)
 
-		// we will not reuse connection from the pool, as it should use fresh one
-		taskConn, taskErr := session.AcquireUnmanagedConnection()
-		if taskErr != nil {
-			errChan <- xerrors.Errorf("failed to get connection: %w", taskErr)
-			return
-		}
-		defer session.DiscardConnection(taskConn)
+	err = uploadDataObjectFromReaderAtParallelWithResume(session, reader, fileLength, irodsPath, resource, numTasks, uploadedLengths, rangeCallback, replicate, callback)
 
-		if taskConn == nil || !taskConn.IsConnected() {
-			errChan <- xerrors.Errorf("connection is nil or disconnected")
-			return
-		}
+	transferStatusLocal.CloseStatusFile()
 
-		// open the file with read-write mode
-		// to not seek to end
-		taskHandle, _, taskErr := OpenDataObjectWithReplicaToken(taskConn, irodsPath, resource, "w", replicaToken, resourceHierarchy, numTasks, fileLength)
-		if taskErr != nil {
-			errChan <- taskErr
-			return
-		}
-		defer func() {
-			errClose := CloseDataObjectReplica(taskConn, taskHandle)
-			if errClose != nil {
-				errChan <- errClose
-			}
-		}()
+	if err != nil {
+		// keep the status file to resume later
+		return err
+	}
 
-		f, taskErr := os.OpenFile(localPath, os.O_RDONLY, 0)
-		if taskErr != nil {
-			errChan <- xerrors.Errorf("failed to open file %s: %w", localPath, taskErr)
-			return
+	transferStatusLocal.DeleteStatusFile()
+	return nil
+}
+
+// findUploadReplica returns the replica of a data object an upload writes to
+// the replica is selected by resourceHierarchy if given, otherwise the replica last modified in the resource is selected
+func findUploadReplica(conn *connection.IRODSConnection, irodsPath string, resource string, resourceHierarchy string) (*types.IRODSReplica, error) {
+	collection, err := GetCollection(conn, util.GetIRODSPathDirname(irodsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := ListDataObjectReplicas(conn, collection, util.GetIRODSPathFileName(irodsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadReplica *types.IRODSReplica
+	for _, replica := range replicas {
+		if len(resourceHierarchy) > 0 {
+			if replica.ResourceHierarchy == resourceHierarchy {
+				return replica, nil
+			}
+			continue
 		}
-		defer f.Close()
 
-		taskNewOffset, taskErr := SeekDataObject(taskConn, taskHandle, taskOffset, types.SeekSet)
-		if taskErr != nil {
-			errChan <- taskErr
-			return
+		if replica.ResourceHierarchy == resource || strings.HasPrefix(replica.ResourceHierarchy, resource+";") {
+			if uploadReplica == nil || replica.ModifyTime.After(uploadReplica.ModifyTime) {
+				uploadReplica = replica
+			}
 		}
+	}
+
+	if uploadReplica == nil {
+		return nil, xerrors.Errorf("failed to find a replica of data object %s in resource %s: %w", irodsPath, resource, types.NewFileNotFoundError(irodsPath))
+	}
+
+	return uploadReplica, nil
+}
+
+// closeFailedUploadReplica closes a replica of a failed upload and marks it stale, so partial data is not taken as good
+// if the connection is broken, another connection of the session is used to mark the replica
+func closeFailedUploadReplica(session *session.IRODSSession, conn *connection.IRODSConnection, handle *types.IRODSFileHandle, irodsPath string, resource string, resourceHierarchy string) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "closeFailedUploadReplica",
+	})
 
-		if taskNewOffset != taskOffset {
-			errChan <- xerrors.Errorf("failed to seek to target offset %d", taskOffset)
+	err := CloseDataObject(conn, handle)
+	if err != nil {
+		logger.WithError(err).Debugf("failed to close data object %s", irodsPath)
+	}
+
+	if !conn.IsConnected() {
+		newConn, err := session.AcquireConnection()
+		if err != nil {
+			logger.WithError(err).Warnf("failed to get connection to mark data object %s stale", irodsPath)
 			return
 		}
+		defer session.ReturnConnection(newConn)
 
-		taskRemain := taskLength
+		conn = newConn
+	}
 
-		// copy
-		buffer := make([]byte, common.ReadWriteBufferSize)
-		var taskWriteErr error
-		for taskRemain > 0 {
-			bufferLen := common.ReadWriteBufferSize
-			if taskRemain < int64(bufferLen) {
-				bufferLen = int(taskRemain)
-			}
+	replica, err := findUploadReplica(conn, irodsPath, resource, resourceHierarchy)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to find replica of data object %s to mark stale", irodsPath)
+		return
+	}
 
-			bytesRead, taskReadErr := f.ReadAt(buffer[:bufferLen], taskOffset+(taskLength-taskRemain))
-			if bytesRead > 0 {
-				taskWriteErr = WriteDataObjectWithTrackerCallBack(taskConn, taskHandle, buffer[:bytesRead], nil)
-				if taskWriteErr != nil {
-					break
-				}
+	if replica.IsStale() {
+		return
+	}
 
-				atomic.AddInt64(&totalBytesUploaded, int64(bytesRead))
-				if callback != nil {
-					callback(totalBytesUploaded, fileLength)
-				}
+	err = ModifyDataObjectReplicaStatus(conn, irodsPath, replica.Number, replica.ResourceHierarchy, types.ReplicaStatusStale, false)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to mark replica %d of data object %s stale", replica.Number, irodsPath)
+	}
+}
 
-				taskRemain -= int64(bytesRead)
-			}
+// getUploadedLengths returns lengths of task ranges sent previously, checked against the replica
+// ranges beyond the replica size are not counted, and nothing is counted if verifyContent is true and the checksum does not match.
+// a replica left intermediate by an upload that crashed is assumed to be of the upload the status is for, as the status file is not shared.
+// it is marked stale to unlock it, and as its size in the catalog is not updated, ranges recorded in the status are taken as sent.
+func getUploadedLengths(conn *connection.IRODSConnection, transferStatus *DataObjectTransferStatus, f *os.File, irodsPath string, resource string, numTasks int, lengthPerTask int64, verifyContent bool) ([]int64, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "getUploadedLengths",
+	})
 
-			if taskReadErr != nil {
-				if taskReadErr == io.EOF {
-					break
-				} else {
-					taskWriteErr = xerrors.Errorf("failed to read file %s: %w", localPath, taskReadErr)
-					break
-				}
-			}
-		}
+	uploadedLengths := make([]int64, numTasks)
+	if len(transferStatus.StatusMap) == 0 {
+		return uploadedLengths, nil
+	}
 
-		if taskWriteErr != nil {
-			errChan <- taskWriteErr
-		}
+	replica, err := findUploadReplica(conn, irodsPath, resource, "")
+	if err != nil {
+		// e.g., the data object is removed, upload from the beginning
+		logger.WithError(err).Debugf("failed to find replica of data object %s to resume, uploading from the beginning", irodsPath)
+		return uploadedLengths, nil
 	}
 
-	lengthPerThread := fileLength / int64(numTasks)
-	if fileLength%int64(numTasks) > 0 {
-		lengthPerThread++
+	replicaSize := replica.Size
+
+	switch replica.GetStatus() {
+	case types.ReplicaStatusIntermediate, types.ReplicaStatusWriteLocked:
+		// the replica is locked since the upload crashed before closing it
+		err = ModifyDataObjectReplicaStatus(conn, irodsPath, replica.Number, replica.ResourceHierarchy, types.ReplicaStatusStale, false)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unlock replica %d of data object %s left by an unfinished upload: %w", replica.Number, irodsPath, err)
+		}
+
+		// data is written before it is recorded in the status
+		replicaSize = getTransferStatusEnd(transferStatus)
+
+		logger.Debugf("replica %d of data object %s is left by an unfinished upload, resuming from the transfer status", replica.Number, irodsPath)
 	}
 
-	offset := int64(0)
+	totalUploaded := int64(0)
+	for i := 0; i < numTasks; i++ {
+		taskOffset := lengthPerTask * int64(i)
+
+		transferStatusEntry, ok := transferStatus.StatusMap[taskOffset]
+		if !ok {
+			continue
+		}
+
+		uploadedLength := transferStatusEntry.CompletedLength
+		if taskOffset+uploadedLength > replicaSize {
+			uploadedLength = replicaSize - taskOffset
+		}
+
+		if uploadedLength <= 0 {
+			continue
+		}
+
+		uploadedLengths[i] = uploadedLength
+		totalUploaded += uploadedLength
+	}
 
-	for i := 0; i < numTasks; i++ {
-		taskWaitGroup.Add(1)
+	if verifyContent && totalUploaded > 0 {
+		verified, err := verifyUploadedRanges(conn, irodsPath, replica.Number, f, uploadedLengths, lengthPerTask, replicaSize)
+		if err != nil {
+			logger.WithError(err).Debugf("failed to verify data of data object %s, uploading from the beginning", irodsPath)
+			return make([]int64, numTasks), nil
+		}
 
-		go uploadTask(offset, lengthPerThread)
-		offset += lengthPerThread
+		if !verified {
+			logger.Debugf("data object %s differs from the local file, uploading from the beginning", irodsPath)
+			return make([]int64, numTasks), nil
+		}
 	}
 
-	taskWaitGroup.Wait()
+	return uploadedLengths, nil
+}
 
-	if len(errChan) > 0 {
-		CloseDataObject(conn, handle)
-		return <-errChan
+// getTransferStatusEnd returns the end of data recorded in the status
+func getTransferStatusEnd(transferStatus *DataObjectTransferStatus) int64 {
+	end := int64(0)
+	for _, transferStatusEntry := range transferStatus.StatusMap {
+		entryEnd := transferStatusEntry.StartOffset + transferStatusEntry.CompletedLength
+		if entryEnd > end {
+			end = entryEnd
+		}
 	}
 
-	err = CloseDataObject(conn, handle)
+	return end
+}
+
+// verifyUploadedRanges compares the checksum of the replica with the checksum of the local data sent previously
+// parts of task ranges not sent are expected to be empty, as the replica is truncated when the upload starts
+func verifyUploadedRanges(conn *connection.IRODSConnection, irodsPath string, replicaNumber int64, f *os.File, uploadedLengths []int64, lengthPerTask int64, replicaSize int64) (bool, error) {
+	checksum, err := ComputeDataObjectReplicaChecksum(conn, irodsPath, replicaNumber)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	// replicate
-	if replicate {
-		err = ReplicateDataObject(conn, irodsPath, "", true, false)
-		if err != nil {
-			return err
-		}
+	if len(checksum.Checksum) == 0 {
+		return false, xerrors.Errorf("failed to get checksum of data object %s", irodsPath)
 	}
 
-	return nil
-}
+	readers := []io.Reader{}
+	for i, uploadedLength := range uploadedLengths {
+		taskOffset := lengthPerTask * int64(i)
+		if taskOffset >= replicaSize {
+			break
+		}
 
-// DownloadDataObjectToBuffer downloads a data object at the iRODS path to buffer
-func DownloadDataObjectToBuffer(session *session.IRODSSession, irodsPath string, resource string, buffer bytes.Buffer, dataObjectLength int64, callback common.TrackerCallBack) error {
-	logger := log.WithFields(log.Fields{
-		"package":  "fs",
-		"function": "DownloadDataObject",
-	})
+		if uploadedLength > 0 {
+			readers = append(readers, io.NewSectionReader(f, taskOffset, uploadedLength))
+		}
 
-	logger.Debugf("download data object %s", irodsPath)
+		taskEnd := taskOffset + lengthPerTask
+		if taskEnd > replicaSize {
+			taskEnd = replicaSize
+		}
 
-	// use default resource when resource param is empty
-	if len(resource) == 0 {
-		account := session.GetAccount()
-		resource = account.DefaultResource
+		emptyLength := taskEnd - (taskOffset + uploadedLength)
+		if emptyLength > 0 {
+			readers = append(readers, io.LimitReader(zeroReader{}, emptyLength))
+		}
 	}
 
-	conn, err := session.AcquireConnection()
+	localChecksum, err := util.HashReader(io.MultiReader(readers...), string(checksum.Algorithm))
 	if err != nil {
-		return xerrors.Errorf("failed to get connection: %w", err)
+		return false, xerrors.Errorf("failed to compute checksum of file %s: %w", f.Name(), err)
 	}
-	defer session.ReturnConnection(conn)
 
-	if conn == nil || !conn.IsConnected() {
-		return xerrors.Errorf("connection is nil or disconnected")
+	return bytes.Equal(localChecksum, checksum.Checksum), nil
+}
+
+// zeroReader reads zeros
+type zeroReader struct{}
+
+func (reader zeroReader) Read(buffer []byte) (int, error) {
+	for i := range buffer {
+		buffer[i] = 0
 	}
+	return len(buffer), nil
+}
 
-	handle, _, err := OpenDataObject(conn, irodsPath, resource, "r")
+// createUploadTransferStatusFile creates the status file with lengths uploaded so far
+func createUploadTransferStatusFile(transferStatusLocal *DataObjectTransferStatusLocal, uploadedLengths []int64, lengthPerTask int64, fileLength int64) error {
+	err := transferStatusLocal.CreateStatusFile()
 	if err != nil {
-		return xerrors.Errorf("failed to open data object %s: %w", irodsPath, err)
+		return err
 	}
-	defer CloseDataObject(conn, handle)
 
-	totalBytesDownloaded := int64(0)
-	if callback != nil {
-		callback(totalBytesDownloaded, dataObjectLength)
+	err = transferStatusLocal.WriteHeader()
+	if err != nil {
+		transferStatusLocal.CloseStatusFile()
+		return err
 	}
 
-	// block read call-back
-	var blockReadCallback common.TrackerCallBack
-	if callback != nil {
-		blockReadCallback = func(processed int64, total int64) {
-			callback(totalBytesDownloaded+processed, dataObjectLength)
+	for i, uploadedLength := range uploadedLengths {
+		if uploadedLength <= 0 {
+			continue
 		}
-	}
-
-	buffer2 := make([]byte, common.ReadWriteBufferSize)
-	var writeErr error
-	// copy
-	for {
-		bytesRead, readErr := ReadDataObjectWithTrackerCallBack(conn, handle, buffer2, blockReadCallback)
-		if bytesRead > 0 {
-			_, writeErr := buffer.Write(buffer2[:bytesRead])
-			if writeErr != nil {
-				break
-			}
 
-			totalBytesDownloaded += int64(bytesRead)
-			if callback != nil {
-				callback(totalBytesDownloaded, dataObjectLength)
-			}
+		taskOffset := lengthPerTask * int64(i)
+		taskLength := lengthPerTask
+		if taskOffset+taskLength > fileLength {
+			taskLength = fileLength - taskOffset
 		}
 
-		if readErr != nil {
-			if readErr == io.EOF {
-				break
-			} else {
-				return xerrors.Errorf("failed to read data object %s: %w", irodsPath, readErr)
-			}
+		err = transferStatusLocal.WriteStatus(&DataObjectTransferStatusEntry{
+			StartOffset:     taskOffset,
+			Length:          taskLength,
+			CompletedLength: uploadedLength,
+		})
+		if err != nil {
+			transferStatusLocal.CloseStatusFile()
+			return err
 		}
 	}
 
-	if writeErr != nil {
-		return writeErr
-	}
-
 	return nil
 }
 
+// DownloadDataObjectToBuffer downloads a data object at the iRODS path to buffer
+func DownloadDataObjectToBuffer(session *session.IRODSSession, irodsPath string, resource string, buffer *bytes.Buffer, dataObjectLength int64, callback common.TrackerCallBack) error {
+	return DownloadDataObjectToWriter(session, irodsPath, resource, buffer, dataObjectLength, 1, callback)
+}
+
 // DownloadDataObject downloads a data object at the iRODS path to the local path
 func DownloadDataObject(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
@@ -555,11 +833,45 @@ func DownloadDataObject(session *session.IRODSSession, irodsPath string, resourc
 	return nil
 }
 
+// DataObjectTransferBlockHook is called before a block of the size is transferred, returning an error stops the transfer
+type DataObjectTransferBlockHook func(size int) error
+
+// blockHookReaderAt calls the block hook before every read
+type blockHookReaderAt struct {
+	reader    io.ReaderAt
+	blockHook DataObjectTransferBlockHook
+}
+
+// newBlockHookReaderAt returns the reader as is if blockHook is nil
+func newBlockHookReaderAt(reader io.ReaderAt, blockHook DataObjectTransferBlockHook) io.ReaderAt {
+	if blockHook == nil {
+		return reader
+	}
+
+	return &blockHookReaderAt{
+		reader:    reader,
+		blockHook: blockHook,
+	}
+}
+
+func (reader *blockHookReaderAt) ReadAt(buffer []byte, offset int64) (int, error) {
+	err := reader.blockHook(len(buffer))
+	if err != nil {
+		return 0, err
+	}
+
+	return reader.reader.ReadAt(buffer, offset)
+}
+
 // DownloadDataObjectResumable downloads a data object at the iRODS path to the local path with support of transfer resume
 func DownloadDataObjectResumable(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, callback common.TrackerCallBack) error {
+	return downloadDataObjectResumable(session, irodsPath, resource, localPath, fileLength, nil, callback)
+}
+
+func downloadDataObjectResumable(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, blockHook DataObjectTransferBlockHook, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "fs",
-		"function": "DownloadDataObjectResumable",
+		"function": "downloadDataObjectResumable",
 	})
 
 	// use default resource when resource param is empty
@@ -661,6 +973,13 @@ func DownloadDataObjectResumable(session *session.IRODSSession, irodsPath string
 	buffer := make([]byte, common.ReadWriteBufferSize)
 	var writeErr error
 	for {
+		if blockHook != nil {
+			writeErr = blockHook(len(buffer))
+			if writeErr != nil {
+				break
+			}
+		}
+
 		bytesRead, readErr := ReadDataObjectWithTrackerCallBack(conn, handle, buffer, blockReadCallback)
 		if bytesRead > 0 {
 			_, writeErr = f.Write(buffer[:bytesRead])
@@ -708,11 +1027,6 @@ func DownloadDataObjectResumable(session *session.IRODSSession, irodsPath string
 // DownloadDataObjectParallel downloads a data object at the iRODS path to the local path in parallel
 // Partitions a file into n (taskNum) tasks and downloads in parallel
 func DownloadDataObjectParallel(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, taskNum int, callback common.TrackerCallBack) error {
-	logger := log.WithFields(log.Fields{
-		"package":  "fs",
-		"function": "DownloadDataObjectParallel",
-	})
-
 	// use default resource when resource param is empty
 	if len(resource) == 0 {
 		account := session.GetAccount()
@@ -721,155 +1035,33 @@ func DownloadDataObjectParallel(session *session.IRODSSession, irodsPath string,
 
 	numTasks := taskNum
 	if numTasks <= 0 {
-		numTasks = util.GetNumTasksForParallelTransfer(fileLength)
+		numTasks = session.GetParallelTransferTuner().GetNumTasks(fileLength)
 	}
 
 	if numTasks > session.GetConfig().ConnectionMax {
 		numTasks = session.GetConfig().ConnectionMax
 	}
 
-	if numTasks == 1 {
+	if numTasks == 1 || fileLength == 0 {
 		// serial download
 		return DownloadDataObject(session, irodsPath, resource, localPath, fileLength, callback)
 	}
 
-	logger.Debugf("download data object in parallel %s, size(%d), threads(%d)", irodsPath, fileLength, numTasks)
-
 	// create an empty file
 	f, err := os.Create(localPath)
 	if err != nil {
 		return xerrors.Errorf("failed to create file %s: %w", localPath, err)
 	}
-	f.Close()
-
-	errChan := make(chan error, numTasks)
-	taskWaitGroup := sync.WaitGroup{}
-
-	totalBytesDownloaded := int64(0)
-	if callback != nil {
-		callback(totalBytesDownloaded, fileLength)
-	}
 
-	// task progress
-	taskProgress := make([]int64, numTasks)
-
-	// get connections
-	connections, err := session.AcquireConnectionsMulti(numTasks)
+	err = downloadDataObjectToWriterAtParallel(session, irodsPath, resource, f, fileLength, numTasks, callback)
 	if err != nil {
-		return xerrors.Errorf("failed to get connection: %w", err)
-	}
-
-	downloadTask := func(taskID int, taskOffset int64, taskLength int64) {
-		taskProgress[taskID] = 0
-		taskConn := connections[taskID]
-
-		defer taskWaitGroup.Done()
-
-		defer session.ReturnConnection(taskConn)
-
-		if taskConn == nil || !taskConn.IsConnected() {
-			errChan <- xerrors.Errorf("connection is nil or disconnected")
-			return
-		}
-
-		taskHandle, _, taskErr := OpenDataObject(taskConn, irodsPath, resource, "r")
-		if taskErr != nil {
-			errChan <- taskErr
-			return
-		}
-		defer func() {
-			errClose := CloseDataObject(taskConn, taskHandle)
-			if errClose != nil {
-				errChan <- errClose
-			}
-		}()
-
-		f, taskErr := os.OpenFile(localPath, os.O_WRONLY, 0)
-		if taskErr != nil {
-			errChan <- xerrors.Errorf("failed to open file %s: %w", localPath, taskErr)
-			return
-		}
-		defer f.Close()
-
-		taskNewOffset, taskErr := SeekDataObject(taskConn, taskHandle, taskOffset, types.SeekSet)
-		if taskErr != nil {
-			errChan <- taskErr
-			return
-		}
-
-		if taskNewOffset != taskOffset {
-			errChan <- xerrors.Errorf("failed to seek to target offset %d", taskOffset)
-			return
-		}
-
-		blockReadCallback := func(processed int64, total int64) {
-			if processed > 0 {
-				delta := processed - taskProgress[taskID]
-				taskProgress[taskID] = processed
-
-				if callback != nil {
-					callback(totalBytesDownloaded+delta, fileLength)
-				}
-			}
-		}
-
-		taskRemain := taskLength
-
-		// copy
-		buffer := make([]byte, common.ReadWriteBufferSize)
-		var taskWriteErr error
-		for taskRemain > 0 {
-			bufferLen := common.ReadWriteBufferSize
-			if taskRemain < int64(bufferLen) {
-				bufferLen = int(taskRemain)
-			}
-
-			taskProgress[taskID] = 0
-
-			bytesRead, taskReadErr := ReadDataObjectWithTrackerCallBack(taskConn, taskHandle, buffer[:bufferLen], blockReadCallback)
-			if bytesRead > 0 {
-				_, taskWriteErr = f.WriteAt(buffer[:bytesRead], taskOffset+(taskLength-taskRemain))
-				if taskWriteErr != nil {
-					break
-				}
-
-				atomic.AddInt64(&totalBytesDownloaded, int64(bytesRead))
-
-				taskRemain -= int64(bytesRead)
-			}
-
-			if taskReadErr != nil {
-				if taskReadErr == io.EOF {
-					break
-				} else {
-					taskWriteErr = xerrors.Errorf("failed to read data object %s: %w", irodsPath, taskReadErr)
-				}
-			}
-		}
-
-		if taskWriteErr != nil {
-			errChan <- taskWriteErr
-		}
-	}
-
-	lengthPerThread := fileLength / int64(numTasks)
-	if fileLength%int64(numTasks) > 0 {
-		lengthPerThread++
-	}
-
-	offset := int64(0)
-
-	for i := 0; i < numTasks; i++ {
-		taskWaitGroup.Add(1)
-
-		go downloadTask(i, offset, lengthPerThread)
-		offset += lengthPerThread
+		f.Close()
+		return err
 	}
 
-	taskWaitGroup.Wait()
-
-	if len(errChan) > 0 {
-		return <-errChan
+	err = f.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to close file %s: %w", localPath, err)
 	}
 
 	return nil
@@ -878,9 +1070,15 @@ func DownloadDataObjectParallel(session *session.IRODSSession, irodsPath string,
 // DownloadDataObjectParallelResumable downloads a data object at the iRODS path to the local path in parallel with support of transfer resume
 // Partitions a file into n (taskNum) tasks and downloads in parallel
 func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, taskNum int, callback common.TrackerCallBack) error {
+	return DownloadDataObjectParallelResumableWithBlockHook(session, irodsPath, resource, localPath, fileLength, taskNum, nil, callback)
+}
+
+// DownloadDataObjectParallelResumableWithBlockHook downloads a data object in parallel with support of transfer resume
+// blockHook is called by every task before it reads a block, it can throttle the task or stop it by returning an error
+func DownloadDataObjectParallelResumableWithBlockHook(session *session.IRODSSession, irodsPath string, resource string, localPath string, fileLength int64, taskNum int, blockHook DataObjectTransferBlockHook, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "fs",
-		"function": "DownloadDataObjectParallelResumable",
+		"function": "DownloadDataObjectParallelResumableWithBlockHook",
 	})
 
 	// use default resource when resource param is empty
@@ -891,7 +1089,7 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 
 	numTasks := taskNum
 	if numTasks <= 0 {
-		numTasks = util.GetNumTasksForParallelTransfer(fileLength)
+		numTasks = session.GetParallelTransferTuner().GetNumTasks(fileLength)
 	}
 
 	if numTasks > session.GetConfig().ConnectionMax {
@@ -900,7 +1098,7 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 
 	if numTasks == 1 {
 		// serial download
-		return DownloadDataObjectResumable(session, irodsPath, resource, localPath, fileLength, callback)
+		return downloadDataObjectResumable(session, irodsPath, resource, localPath, fileLength, blockHook, callback)
 	}
 
 	logger.Debugf("downloading data object in parallel %s, size(%d)", irodsPath, fileLength)
@@ -918,7 +1116,7 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 
 	if numTasks == 1 {
 		// serial download
-		return DownloadDataObjectResumable(session, irodsPath, resource, localPath, fileLength, callback)
+		return downloadDataObjectResumable(session, irodsPath, resource, localPath, fileLength, blockHook, callback)
 	}
 
 	err = transferStatusLocal.CreateStatusFile()
@@ -939,7 +1137,11 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 	}
 	f.Close()
 
-	errChan := make(chan error, numTasks)
+	probe := session.GetParallelTransferTuner().NewProbe(numTasks)
+	defer probe.Done()
+
+	// a task may report its error and an error closing the data object
+	errChan := make(chan error, numTasks*2)
 	taskWaitGroup := sync.WaitGroup{}
 
 	totalBytesDownloaded := int64(0)
@@ -953,16 +1155,28 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 	// get connections
 	connections, err := session.AcquireConnectionsMulti(numTasks)
 	if err != nil {
+		transferStatusLocal.CloseStatusFile()
 		return xerrors.Errorf("failed to get connection: %w", err)
 	}
 
+	if len(connections) == 0 {
+		transferStatusLocal.CloseStatusFile()
+		return xerrors.Errorf("failed to get connection, no connection is available")
+	}
+
+	defer func() {
+		for _, conn := range connections {
+			session.ReturnConnection(conn)
+		}
+	}()
+
 	downloadTask := func(taskID int, taskOffset int64, taskLength int64) {
 		taskProgress[taskID] = 0
-		taskConn := connections[taskID]
 
-		defer taskWaitGroup.Done()
+		// the number of tasks is kept in the transfer status, tasks share connections if fewer are available
+		taskConn := connections[taskID%len(connections)]
 
-		defer session.ReturnConnection(taskConn)
+		defer taskWaitGroup.Done()
 
 		if taskConn == nil || !taskConn.IsConnected() {
 			errChan <- xerrors.Errorf("connection is nil or disconnected")
@@ -1039,20 +1253,36 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 		}
 
 		// copy
-		buffer := make([]byte, common.ReadWriteBufferSize)
+		var buffer []byte
 		var taskWriteErr error
 		for taskRemain > 0 {
-			bufferLen := common.ReadWriteBufferSize
+			bufferLen := probe.GetBlockSize()
+			if len(buffer) < bufferLen {
+				buffer = make([]byte, bufferLen)
+			}
+
 			if taskRemain < int64(bufferLen) {
 				bufferLen = int(taskRemain)
 			}
 
+			if blockHook != nil {
+				taskWriteErr = blockHook(bufferLen)
+				if taskWriteErr != nil {
+					break
+				}
+			}
+
 			taskProgress[taskID] = 0
 
+			blockStartTime := time.Now()
+			blockWaitTime := taskConn.GetRateLimitWaitTime()
 			bytesRead, taskReadErr := ReadDataObjectWithTrackerCallBack(taskConn, taskHandle, buffer[:bufferLen], blockReadCallback)
+			// exclude time waiting for rate limiters
+			probe.Record(taskID, int64(bytesRead), time.Since(blockStartTime)-(taskConn.GetRateLimitWaitTime()-blockWaitTime))
 			if bytesRead > 0 {
-				_, taskWriteErr := f.WriteAt(buffer[:bytesRead], taskOffset+(taskLength-taskRemain))
+				_, taskWriteErr = f.WriteAt(buffer[:bytesRead], taskOffset+(taskLength-taskRemain))
 				if taskWriteErr != nil {
+					taskWriteErr = xerrors.Errorf("failed to write to file %s: %w", localPath, taskWriteErr)
 					break
 				}
 
@@ -1075,11 +1305,20 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 
 			if taskReadErr != nil {
 				if taskReadErr == io.EOF {
+					if taskRemain > 0 {
+						taskWriteErr = xerrors.Errorf("failed to read from file %s at offset %d: %w", irodsPath, taskOffset+(taskLength-taskRemain), io.ErrUnexpectedEOF)
+					}
 					break
 				} else {
 					taskWriteErr = xerrors.Errorf("failed to read from file %s: %w", irodsPath, taskReadErr)
+					break
 				}
 			}
+
+			if bytesRead == 0 {
+				taskWriteErr = xerrors.Errorf("failed to read from file %s, unexpected end of data at offset %d", irodsPath, taskOffset+(taskLength-taskRemain))
+				break
+			}
 		}
 
 		if taskWriteErr != nil {
@@ -1095,9 +1334,19 @@ func DownloadDataObjectParallelResumable(session *session.IRODSSession, irodsPat
 	offset := int64(0)
 
 	for i := 0; i < numTasks; i++ {
+		taskLength := lengthPerThread
+		if offset+taskLength > fileLength {
+			// the last task
+			taskLength = fileLength - offset
+		}
+
+		if taskLength < 0 {
+			taskLength = 0
+		}
+
 		taskWaitGroup.Add(1)
 
-		go downloadTask(i, offset, lengthPerThread)
+		go downloadTask(i, offset, taskLength)
 		offset += lengthPerThread
 	}
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
@@ -18,6 +19,12 @@ import (
 
 // GetDataObjectRedirectionInfoForGet returns a redirection info for accessing the data object for downloading
 func GetDataObjectRedirectionInfoForGet(conn *connection.IRODSConnection, path string, resource string, fileLength int64) (*types.IRODSFileOpenRedirectionHandle, error) {
+	return GetDataObjectRedirectionInfoForGetWithThreads(conn, path, resource, fileLength, 0)
+}
+
+// GetDataObjectRedirectionInfoForGetWithThreads returns a redirection info for accessing the data object for downloading
+// threadNum is the number of threads requested to the server, 0 lets the server decide
+func GetDataObjectRedirectionInfoForGetWithThreads(conn *connection.IRODSConnection, path string, resource string, fileLength int64, threadNum int) (*types.IRODSFileOpenRedirectionHandle, error) {
 	if conn == nil || !conn.IsConnected() {
 		return nil, xerrors.Errorf("connection is nil or disconnected")
 	}
@@ -38,6 +45,7 @@ func GetDataObjectRedirectionInfoForGet(conn *connection.IRODSConnection, path s
 	}
 
 	request := message.NewIRODSMessageGetDataObjectRequest(path, resource, fileLength)
+	request.Threads = threadNum
 	response := message.IRODSMessageGetDataObjectResponse{}
 	err := conn.RequestAndCheck(request, &response, nil)
 	if err != nil {
@@ -77,6 +85,12 @@ func GetDataObjectRedirectionInfoForGet(conn *connection.IRODSConnection, path s
 
 // GetDataObjectRedirectionInfoForPut returns a redirection info for accessing the data object for uploading
 func GetDataObjectRedirectionInfoForPut(conn *connection.IRODSConnection, path string, resource string, fileLength int64) (*types.IRODSFileOpenRedirectionHandle, error) {
+	return GetDataObjectRedirectionInfoForPutWithThreads(conn, path, resource, fileLength, 0)
+}
+
+// GetDataObjectRedirectionInfoForPutWithThreads returns a redirection info for accessing the data object for uploading
+// threadNum is the number of threads requested to the server, 0 lets the server decide
+func GetDataObjectRedirectionInfoForPutWithThreads(conn *connection.IRODSConnection, path string, resource string, fileLength int64, threadNum int) (*types.IRODSFileOpenRedirectionHandle, error) {
 	if conn == nil || !conn.IsConnected() {
 		return nil, xerrors.Errorf("connection is nil or disconnected")
 	}
@@ -97,6 +111,7 @@ func GetDataObjectRedirectionInfoForPut(conn *connection.IRODSConnection, path s
 	}
 
 	request := message.NewIRODSMessagePutDataObjectRequest(path, resource, fileLength)
+	request.Threads = threadNum
 	response := message.IRODSMessagePutDataObjectResponse{}
 	err := conn.RequestAndCheck(request, &response, nil)
 	if err != nil {
@@ -166,7 +181,7 @@ func CompleteDataObjectRedirection(conn *connection.IRODSConnection, handle *typ
 	return nil
 }
 
-func downloadDataObjectChunkFromResourceServer(sess *session.IRODSSession, controlConnection *connection.IRODSConnection, handle *types.IRODSFileOpenRedirectionHandle, localPath string, callback common.TrackerCallBack) error {
+func downloadDataObjectChunkFromResourceServer(sess *session.IRODSSession, controlConnection *connection.IRODSConnection, handle *types.IRODSFileOpenRedirectionHandle, localPath string, probe *util.ParallelTransferProbe, taskID int, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "fs",
 		"function": "downloadDataObjectChunkFromResourceServer",
@@ -272,7 +287,11 @@ func downloadDataObjectChunkFromResourceServer(sess *session.IRODSSession, contr
 				//logger.Debugf("encrypted data len %d", encryptedDataLen)
 
 				// read data
+				blockStartTime := time.Now()
+				blockWaitTime := conn.GetRateLimitWaitTime()
 				readLen, err = conn.Recv(encryptedDataBuffer, encryptedDataLen)
+				// exclude time waiting for rate limiters
+				probe.Record(taskID, int64(readLen), time.Since(blockStartTime)-(conn.GetRateLimitWaitTime()-blockWaitTime))
 				if readLen > 0 {
 					// decrypt
 					decryptedDataLen, decErr := conn.Decrypt(encryptionHeader.IV, encryptedDataBuffer[:readLen], dataBuffer)
@@ -316,7 +335,11 @@ func downloadDataObjectChunkFromResourceServer(sess *session.IRODSSession, contr
 					return xerrors.Errorf("failed to seek to offset %d for file %s, new offset %d: %w", curOffset, localPath, newOffset, err)
 				}
 
+				blockStartTime := time.Now()
+				blockWaitTime := conn.GetRateLimitWaitTime()
 				readLen, err := conn.RecvToWriter(f, toGet)
+				// exclude time waiting for rate limiters
+				probe.Record(taskID, readLen, time.Since(blockStartTime)-(conn.GetRateLimitWaitTime()-blockWaitTime))
 				if readLen > 0 {
 					atomic.AddInt64(&totalBytesDownloaded, readLen)
 					if callback != nil {
@@ -342,7 +365,7 @@ func downloadDataObjectChunkFromResourceServer(sess *session.IRODSSession, contr
 	return nil
 }
 
-func uploadDataObjectChunkToResourceServer(sess *session.IRODSSession, controlConnection *connection.IRODSConnection, handle *types.IRODSFileOpenRedirectionHandle, localPath string, callback common.TrackerCallBack) error {
+func uploadDataObjectChunkToResourceServer(sess *session.IRODSSession, controlConnection *connection.IRODSConnection, handle *types.IRODSFileOpenRedirectionHandle, localPath string, probe *util.ParallelTransferProbe, taskID int, callback common.TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "fs",
 		"function": "uploadDataObjectChunkToResourceServer",
@@ -484,11 +507,16 @@ func uploadDataObjectChunkToResourceServer(sess *session.IRODSSession, controlCo
 
 				//logger.Debugf("sending encrypted data")
 				encryptedDataLen := encryptionHeader.Length - encKeysize
+				blockStartTime := time.Now()
+				blockWaitTime := conn.GetRateLimitWaitTime()
 				writeErr := conn.Send(encryptedDataBuffer, encryptedDataLen)
 				if writeErr != nil {
 					return xerrors.Errorf("failed to write data to %s, offset %d: %w", handle.Path, curOffset, writeErr)
 				}
 
+				// exclude time waiting for rate limiters
+				probe.Record(taskID, int64(readLen), time.Since(blockStartTime)-(conn.GetRateLimitWaitTime()-blockWaitTime))
+
 				//logger.Debugf("sent encrypted data")
 
 				atomic.AddInt64(&totalBytesUploaded, int64(readLen))
@@ -510,7 +538,13 @@ func uploadDataObjectChunkToResourceServer(sess *session.IRODSSession, controlCo
 					return xerrors.Errorf("failed to seek to offset %d for file %s, new offset %d: %w", curOffset, localPath, newOffset, err)
 				}
 
+				blockStartTime := time.Now()
+				blockWaitTime := conn.GetRateLimitWaitTime()
 				err = conn.SendFromReader(f, toPut)
+				if err == nil {
+					// exclude time waiting for rate limiters
+					probe.Record(taskID, toPut, time.Since(blockStartTime)-(conn.GetRateLimitWaitTime()-blockWaitTime))
+				}
 				atomic.AddInt64(&totalBytesUploaded, toPut)
 				if callback != nil {
 					callback(totalBytesUploaded, -1)
@@ -559,7 +593,13 @@ func DownloadDataObjectFromResourceServer(session *session.IRODSSession, irodsPa
 		return xerrors.Errorf("connection is nil or disconnected")
 	}
 
-	handle, err := GetDataObjectRedirectionInfoForGet(conn, irodsPath, resource, fileLength)
+	// request the number of threads only when parallel transfer is configured, otherwise the server decides
+	threadNum := 0
+	if session.GetConfig().ParallelTransfer != nil {
+		threadNum = session.GetParallelTransferTuner().GetNumTasks(fileLength)
+	}
+
+	handle, err := GetDataObjectRedirectionInfoForGetWithThreads(conn, irodsPath, resource, fileLength, threadNum)
 	if err != nil {
 		logger.Debugf("failed to get redirection info for data object %s, switch to DownloadDataObjectParallel: %s", irodsPath, err.Error())
 
@@ -590,6 +630,9 @@ func DownloadDataObjectFromResourceServer(session *session.IRODSSession, irodsPa
 		}
 		f.Close()
 
+		probe := session.GetParallelTransferTuner().NewProbe(handle.Threads)
+		defer probe.Done()
+
 		errChan := make(chan error, handle.Threads)
 		taskWaitGroup := sync.WaitGroup{}
 
@@ -619,7 +662,7 @@ func DownloadDataObjectFromResourceServer(session *session.IRODSSession, irodsPa
 				}
 			}
 
-			err = downloadDataObjectChunkFromResourceServer(session, conn, handle, localPath, blockReadCallback)
+			err = downloadDataObjectChunkFromResourceServer(session, conn, handle, localPath, probe, taskID, blockReadCallback)
 			if err != nil {
 				dnErr := xerrors.Errorf("failed to download data object chunk %s from resource server: %w", irodsPath, err)
 				errChan <- dnErr
@@ -676,7 +719,13 @@ func UploadDataObjectToResourceServer(session *session.IRODSSession, localPath s
 		return xerrors.Errorf("connection is nil or disconnected")
 	}
 
-	handle, err := GetDataObjectRedirectionInfoForPut(conn, irodsPath, resource, fileLength)
+	// request the number of threads only when parallel transfer is configured, otherwise the server decides
+	threadNum := 0
+	if session.GetConfig().ParallelTransfer != nil {
+		threadNum = session.GetParallelTransferTuner().GetNumTasks(fileLength)
+	}
+
+	handle, err := GetDataObjectRedirectionInfoForPutWithThreads(conn, irodsPath, resource, fileLength, threadNum)
 	if err != nil {
 		logger.Debugf("failed to get redirection info for data object %s, switch to UploadDataObjctParallel: %s", irodsPath, err.Error())
 
@@ -699,6 +748,9 @@ func UploadDataObjectToResourceServer(session *session.IRODSSession, localPath s
 	} else if handle.RedirectionInfo != nil {
 		logger.Debugf("Redirect to resource: path %s, threads %d, addr %s, port %d, cookie %d", handle.Path, handle.Threads, handle.RedirectionInfo.Host, handle.RedirectionInfo.Port, handle.RedirectionInfo.Cookie)
 		// put to portal
+		probe := session.GetParallelTransferTuner().NewProbe(handle.Threads)
+		defer probe.Done()
+
 		errChan := make(chan error, handle.Threads)
 		taskWaitGroup := sync.WaitGroup{}
 
@@ -728,7 +780,7 @@ func UploadDataObjectToResourceServer(session *session.IRODSSession, localPath s
 				}
 			}
 
-			err = uploadDataObjectChunkToResourceServer(session, conn, handle, localPath, blockWriteCallback)
+			err = uploadDataObjectChunkToResourceServer(session, conn, handle, localPath, probe, taskID, blockWriteCallback)
 			if err != nil {
 				dnErr := xerrors.Errorf("failed to upload data object chunk %s to resource server: %w", localPath, err)
 				errChan <- dnErr
@@ -0,0 +1,577 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DataObjectLengthUnknown is used when the length of data to upload is not known in advance
+	DataObjectLengthUnknown int64 = -1
+)
+
+// UploadDataObjectFromReader puts data read from the reader to the iRODS path
+// size can be DataObjectLengthUnknown, then data is read until EOF and the tracker callback receives -1 as total.
+// If the reader is an io.ReaderAt and the size is known, data is uploaded in parallel with n (taskNum) tasks.
+// taskNum <= 0 decides the number of tasks from the size, 1 forces serial upload.
+func UploadDataObjectFromReader(session *session.IRODSSession, reader io.Reader, size int64, irodsPath string, resource string, taskNum int, replicate bool, callback common.TrackerCallBack) error {
+	// use default resource when resource param is empty
+	if len(resource) == 0 {
+		account := session.GetAccount()
+		resource = account.DefaultResource
+	}
+
+	if readerAt, ok := reader.(io.ReaderAt); ok && size > 0 && session.SupportParallelUpload() {
+		numTasks := taskNum
+		if numTasks <= 0 {
+			numTasks = session.GetParallelTransferTuner().GetNumTasks(size)
+		}
+
+		if numTasks > 1 {
+			return uploadDataObjectFromReaderAtParallel(session, readerAt, size, irodsPath, resource, numTasks, replicate, callback)
+		}
+	}
+
+	return uploadDataObjectFromReader(session, reader, size, irodsPath, resource, replicate, callback)
+}
+
+func uploadDataObjectFromReader(session *session.IRODSSession, reader io.Reader, size int64, irodsPath string, resource string, replicate bool, callback common.TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "uploadDataObjectFromReader",
+	})
+
+	logger.Debugf("upload data object %s from reader, size(%d)", irodsPath, size)
+
+	conn, err := session.AcquireConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer session.ReturnConnection(conn)
+
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// open a new file
+	handle, err := OpenDataObjectWithOperation(conn, irodsPath, resource, "w+", common.OPER_TYPE_NONE)
+	if err != nil {
+		return xerrors.Errorf("failed to open data object %s: %w", irodsPath, err)
+	}
+
+	totalBytesUploaded := int64(0)
+	if callback != nil {
+		callback(totalBytesUploaded, size)
+	}
+
+	// block write call-back
+	blockWriteCallback := func(processed int64, total int64) {
+		if callback != nil {
+			callback(totalBytesUploaded+processed, size)
+		}
+	}
+
+	// copy
+	buffer := make([]byte, common.ReadWriteBufferSize)
+	var writeErr error
+	for {
+		bytesRead, readErr := reader.Read(buffer)
+		if bytesRead > 0 {
+			writeErr = WriteDataObjectWithTrackerCallBack(conn, handle, buffer[:bytesRead], blockWriteCallback)
+			if writeErr != nil {
+				break
+			}
+
+			totalBytesUploaded += int64(bytesRead)
+			if callback != nil {
+				callback(totalBytesUploaded, size)
+			}
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				writeErr = xerrors.Errorf("failed to read data for %s: %w", irodsPath, readErr)
+			}
+			break
+		}
+	}
+
+	CloseDataObject(conn, handle)
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if size >= 0 && totalBytesUploaded != size {
+		return xerrors.Errorf("failed to upload data object %s, expected %d bytes but read %d bytes", irodsPath, size, totalBytesUploaded)
+	}
+
+	// replicate
+	if replicate {
+		replErr := ReplicateDataObject(conn, irodsPath, "", true, false)
+		if replErr != nil {
+			return replErr
+		}
+	}
+
+	return nil
+}
+
+func uploadDataObjectFromReaderAtParallel(session *session.IRODSSession, reader io.ReaderAt, size int64, irodsPath string, resource string, numTasks int, replicate bool, callback common.TrackerCallBack) error {
+	return uploadDataObjectFromReaderAtParallelWithResume(session, reader, size, irodsPath, resource, numTasks, nil, nil, replicate, callback)
+}
+
+// uploadRangeCallback is called with the length of a task range sent so far
+type uploadRangeCallback func(taskOffset int64, taskLength int64, completedLength int64)
+
+// uploadDataObjectFromReaderAtParallelWithResume uploads ranges of the reader in parallel
+// uploadedLengths are lengths of task ranges sent previously, if any is sent the data object is reopened without truncation.
+// if rangeCallback is given, it is called after every block and the replica is marked stale if the upload fails.
+func uploadDataObjectFromReaderAtParallelWithResume(session *session.IRODSSession, reader io.ReaderAt, size int64, irodsPath string, resource string, numTasks int, uploadedLengths []int64, rangeCallback uploadRangeCallback, replicate bool, callback common.TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "uploadDataObjectFromReaderAtParallelWithResume",
+	})
+
+	conn, err := session.AcquireUnmanagedConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer session.DiscardConnection(conn)
+
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	totalBytesUploaded := int64(0)
+	for _, uploadedLength := range uploadedLengths {
+		totalBytesUploaded += uploadedLength
+	}
+
+	logger.Debugf("upload data object in parallel %s from reader, size(%d), threads(%d), uploaded(%d)", irodsPath, size, numTasks, totalBytesUploaded)
+
+	// open the existing replica to resume, or a new file
+	openMode := "w+"
+	if totalBytesUploaded > 0 {
+		openMode = "w"
+	}
+
+	handle, err := OpenDataObjectForPutParallel(conn, irodsPath, resource, openMode, common.OPER_TYPE_NONE, numTasks, size)
+	if err != nil {
+		return err
+	}
+
+	replicaToken, resourceHierarchy, err := GetReplicaAccessInfo(conn, handle)
+	if err != nil {
+		CloseDataObject(conn, handle)
+		return err
+	}
+
+	probe := session.GetParallelTransferTuner().NewProbe(numTasks)
+	defer probe.Done()
+
+	errChan := make(chan error, numTasks*2)
+	taskWaitGroup := sync.WaitGroup{}
+
+	if callback != nil {
+		callback(totalBytesUploaded, size)
+	}
+
+	uploadTask := func(taskID int, taskOffset int64, taskLength int64, uploadedLength int64) {
+		defer taskWaitGroup.Done()
+
+		if uploadedLength >= taskLength {
+			// done already
+			return
+		}
+
+		// we will not reuse connection from the pool, as it should use fresh one
+		taskConn, taskErr := session.AcquireUnmanagedConnection()
+		if taskErr != nil {
+			errChan <- xerrors.Errorf("failed to get connection: %w", taskErr)
+			return
+		}
+		defer session.DiscardConnection(taskConn)
+
+		if taskConn == nil || !taskConn.IsConnected() {
+			errChan <- xerrors.Errorf("connection is nil or disconnected")
+			return
+		}
+
+		// open the file with read-write mode
+		// to not seek to end
+		taskHandle, _, taskErr := OpenDataObjectWithReplicaToken(taskConn, irodsPath, resource, "w", replicaToken, resourceHierarchy, numTasks, size)
+		if taskErr != nil {
+			errChan <- taskErr
+			return
+		}
+		defer func() {
+			errClose := CloseDataObjectReplica(taskConn, taskHandle)
+			if errClose != nil {
+				errChan <- errClose
+			}
+		}()
+
+		lastOffset := taskOffset + uploadedLength
+		if uploadedLength > 0 {
+			logger.Debugf("resuming uploading data object %s for task offset %d from offset %d", irodsPath, taskOffset, lastOffset)
+		}
+
+		taskNewOffset, taskErr := SeekDataObject(taskConn, taskHandle, lastOffset, types.SeekSet)
+		if taskErr != nil {
+			errChan <- taskErr
+			return
+		}
+
+		if taskNewOffset != lastOffset {
+			errChan <- xerrors.Errorf("failed to seek to target offset %d", lastOffset)
+			return
+		}
+
+		taskRemain := taskLength - uploadedLength
+
+		// copy
+		var buffer []byte
+		for taskRemain > 0 {
+			bufferLen := probe.GetBlockSize()
+			if len(buffer) < bufferLen {
+				buffer = make([]byte, bufferLen)
+			}
+
+			if taskRemain < int64(bufferLen) {
+				bufferLen = int(taskRemain)
+			}
+
+			bytesRead, taskReadErr := reader.ReadAt(buffer[:bufferLen], taskOffset+(taskLength-taskRemain))
+			if bytesRead > 0 {
+				blockStartTime := time.Now()
+				blockWaitTime := taskConn.GetRateLimitWaitTime()
+				taskWriteErr := WriteDataObjectWithTrackerCallBack(taskConn, taskHandle, buffer[:bytesRead], nil)
+				if taskWriteErr != nil {
+					errChan <- taskWriteErr
+					return
+				}
+
+				// exclude time waiting for rate limiters
+				probe.Record(taskID, int64(bytesRead), time.Since(blockStartTime)-(taskConn.GetRateLimitWaitTime()-blockWaitTime))
+
+				taskRemain -= int64(bytesRead)
+
+				if rangeCallback != nil {
+					rangeCallback(taskOffset, taskLength, taskLength-taskRemain)
+				}
+
+				newTotal := atomic.AddInt64(&totalBytesUploaded, int64(bytesRead))
+				if callback != nil {
+					callback(newTotal, size)
+				}
+			}
+
+			if taskReadErr != nil {
+				if taskReadErr == io.EOF && taskRemain == 0 {
+					break
+				}
+
+				errChan <- xerrors.Errorf("failed to read data for %s at offset %d: %w", irodsPath, taskOffset+(taskLength-taskRemain), taskReadErr)
+				return
+			}
+		}
+	}
+
+	lengthPerThread := size / int64(numTasks)
+	if size%int64(numTasks) > 0 {
+		lengthPerThread++
+	}
+
+	offset := int64(0)
+	for i := 0; i < numTasks && offset < size; i++ {
+		taskLength := lengthPerThread
+		if offset+taskLength > size {
+			taskLength = size - offset
+		}
+
+		uploadedLength := int64(0)
+		if i < len(uploadedLengths) {
+			uploadedLength = uploadedLengths[i]
+		}
+
+		taskWaitGroup.Add(1)
+
+		go uploadTask(i, offset, taskLength, uploadedLength)
+		offset += taskLength
+	}
+
+	taskWaitGroup.Wait()
+
+	if len(errChan) > 0 {
+		if rangeCallback != nil {
+			closeFailedUploadReplica(session, conn, handle, irodsPath, resource, resourceHierarchy)
+		} else {
+			CloseDataObject(conn, handle)
+		}
+		return <-errChan
+	}
+
+	err = CloseDataObject(conn, handle)
+	if err != nil {
+		return err
+	}
+
+	// replicate
+	if replicate {
+		err = ReplicateDataObject(conn, irodsPath, "", true, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DownloadDataObjectToWriter downloads a data object at the iRODS path to the writer
+// If the writer is an io.WriterAt, data is downloaded in ranges in parallel with n (taskNum) tasks.
+// taskNum <= 0 decides the number of tasks from the length, 1 forces serial download.
+func DownloadDataObjectToWriter(session *session.IRODSSession, irodsPath string, resource string, writer io.Writer, dataObjectLength int64, taskNum int, callback common.TrackerCallBack) error {
+	// use default resource when resource param is empty
+	if len(resource) == 0 {
+		account := session.GetAccount()
+		resource = account.DefaultResource
+	}
+
+	if writerAt, ok := writer.(io.WriterAt); ok && dataObjectLength > 0 {
+		numTasks := taskNum
+		if numTasks <= 0 {
+			numTasks = session.GetParallelTransferTuner().GetNumTasks(dataObjectLength)
+		}
+
+		if numTasks > session.GetConfig().ConnectionMax {
+			numTasks = session.GetConfig().ConnectionMax
+		}
+
+		if numTasks > 1 {
+			return downloadDataObjectToWriterAtParallel(session, irodsPath, resource, writerAt, dataObjectLength, numTasks, callback)
+		}
+	}
+
+	return downloadDataObjectToWriter(session, irodsPath, resource, writer, dataObjectLength, callback)
+}
+
+func downloadDataObjectToWriter(session *session.IRODSSession, irodsPath string, resource string, writer io.Writer, dataObjectLength int64, callback common.TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "downloadDataObjectToWriter",
+	})
+
+	logger.Debugf("download data object %s to writer", irodsPath)
+
+	conn, err := session.AcquireConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer session.ReturnConnection(conn)
+
+	if conn == nil || !conn.IsConnected() {
+		return xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	handle, _, err := OpenDataObject(conn, irodsPath, resource, "r")
+	if err != nil {
+		return xerrors.Errorf("failed to open data object %s: %w", irodsPath, err)
+	}
+	defer CloseDataObject(conn, handle)
+
+	totalBytesDownloaded := int64(0)
+	if callback != nil {
+		callback(totalBytesDownloaded, dataObjectLength)
+	}
+
+	// block read call-back
+	blockReadCallback := func(processed int64, total int64) {
+		if callback != nil {
+			callback(totalBytesDownloaded+processed, dataObjectLength)
+		}
+	}
+
+	// copy
+	buffer := make([]byte, common.ReadWriteBufferSize)
+	for {
+		bytesRead, readErr := ReadDataObjectWithTrackerCallBack(conn, handle, buffer, blockReadCallback)
+		if bytesRead > 0 {
+			_, writeErr := writer.Write(buffer[:bytesRead])
+			if writeErr != nil {
+				return xerrors.Errorf("failed to write data of %s: %w", irodsPath, writeErr)
+			}
+
+			totalBytesDownloaded += int64(bytesRead)
+			if callback != nil {
+				callback(totalBytesDownloaded, dataObjectLength)
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				return nil
+			}
+			return xerrors.Errorf("failed to read data object %s: %w", irodsPath, readErr)
+		}
+	}
+}
+
+func downloadDataObjectToWriterAtParallel(session *session.IRODSSession, irodsPath string, resource string, writer io.WriterAt, dataObjectLength int64, numTasks int, callback common.TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "fs",
+		"function": "downloadDataObjectToWriterAtParallel",
+	})
+
+	// get connections
+	connections, err := session.AcquireConnectionsMulti(numTasks)
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+
+	if len(connections) == 0 {
+		return xerrors.Errorf("failed to get connection")
+	}
+
+	// shared connections are returned once
+	if len(connections) < numTasks {
+		numTasks = len(connections)
+	}
+
+	logger.Debugf("download data object in parallel %s to writer, size(%d), threads(%d)", irodsPath, dataObjectLength, numTasks)
+
+	probe := session.GetParallelTransferTuner().NewProbe(numTasks)
+	defer probe.Done()
+
+	errChan := make(chan error, numTasks*2)
+	taskWaitGroup := sync.WaitGroup{}
+
+	totalBytesDownloaded := int64(0)
+	if callback != nil {
+		callback(totalBytesDownloaded, dataObjectLength)
+	}
+
+	downloadTask := func(taskID int, taskConn *connection.IRODSConnection, taskOffset int64, taskLength int64) {
+		defer taskWaitGroup.Done()
+		defer session.ReturnConnection(taskConn)
+
+		if taskConn == nil || !taskConn.IsConnected() {
+			errChan <- xerrors.Errorf("connection is nil or disconnected")
+			return
+		}
+
+		taskHandle, _, taskErr := OpenDataObject(taskConn, irodsPath, resource, "r")
+		if taskErr != nil {
+			errChan <- taskErr
+			return
+		}
+		defer func() {
+			errClose := CloseDataObject(taskConn, taskHandle)
+			if errClose != nil {
+				errChan <- errClose
+			}
+		}()
+
+		taskNewOffset, taskErr := SeekDataObject(taskConn, taskHandle, taskOffset, types.SeekSet)
+		if taskErr != nil {
+			errChan <- taskErr
+			return
+		}
+
+		if taskNewOffset != taskOffset {
+			errChan <- xerrors.Errorf("failed to seek to target offset %d", taskOffset)
+			return
+		}
+
+		taskRemain := taskLength
+
+		// copy
+		var buffer []byte
+		for taskRemain > 0 {
+			bufferLen := probe.GetBlockSize()
+			if len(buffer) < bufferLen {
+				buffer = make([]byte, bufferLen)
+			}
+
+			if taskRemain < int64(bufferLen) {
+				bufferLen = int(taskRemain)
+			}
+
+			blockStartTime := time.Now()
+			blockWaitTime := taskConn.GetRateLimitWaitTime()
+			bytesRead, taskReadErr := ReadDataObjectWithTrackerCallBack(taskConn, taskHandle, buffer[:bufferLen], nil)
+			// exclude time waiting for rate limiters
+			probe.Record(taskID, int64(bytesRead), time.Since(blockStartTime)-(taskConn.GetRateLimitWaitTime()-blockWaitTime))
+			if bytesRead > 0 {
+				_, taskWriteErr := writer.WriteAt(buffer[:bytesRead], taskOffset+(taskLength-taskRemain))
+				if taskWriteErr != nil {
+					errChan <- xerrors.Errorf("failed to write data of %s: %w", irodsPath, taskWriteErr)
+					return
+				}
+
+				newTotal := atomic.AddInt64(&totalBytesDownloaded, int64(bytesRead))
+				if callback != nil {
+					callback(newTotal, dataObjectLength)
+				}
+
+				taskRemain -= int64(bytesRead)
+			}
+
+			if taskReadErr != nil {
+				if taskReadErr == io.EOF {
+					if taskRemain == 0 {
+						break
+					}
+
+					// the data object is shorter than expected, e.g., truncated meanwhile
+					taskReadErr = io.ErrUnexpectedEOF
+				}
+
+				errChan <- xerrors.Errorf("failed to read data object %s at offset %d: %w", irodsPath, taskOffset+(taskLength-taskRemain), taskReadErr)
+				return
+			}
+		}
+	}
+
+	lengthPerThread := dataObjectLength / int64(numTasks)
+	if dataObjectLength%int64(numTasks) > 0 {
+		lengthPerThread++
+	}
+
+	offset := int64(0)
+	for i := 0; i < numTasks; i++ {
+		taskLength := lengthPerThread
+		if offset+taskLength > dataObjectLength {
+			taskLength = dataObjectLength - offset
+		}
+
+		if taskLength <= 0 {
+			// nothing to read, return unused connections
+			session.ReturnConnection(connections[i])
+			continue
+		}
+
+		taskWaitGroup.Add(1)
+
+		go downloadTask(i, connections[i], offset, taskLength)
+		offset += taskLength
+	}
+
+	taskWaitGroup.Wait()
+
+	if len(errChan) > 0 {
+		return <-errChan
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
@@ -11,6 +12,31 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// GetServerInfo returns information of the server that the connection is connected to
+func GetServerInfo(conn *connection.IRODSConnection) (*types.IRODSServerInfo, error) {
+	if conn == nil || !conn.IsConnected() {
+		return nil, xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	// the request does not open a database transaction, keep the flag as it was
+	dirtyTransaction := conn.IsTransactionDirty()
+	defer conn.SetTransactionDirty(dirtyTransaction)
+
+	req := message.NewIRODSMessageGetMiscServerInfoRequest()
+	res := message.IRODSMessageGetMiscServerInfoResponse{}
+	err := conn.RequestAndCheck(req, &res, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("received get misc server info error: %w", err)
+	}
+
+	bootTime := time.Unix(res.ServerBootTime, 0)
+	return types.NewIRODSServerInfo(types.GetIRODSServerType(res.ServerType), res.RodsZone, bootTime, res.ReleaseVersion, res.APIVersion), nil
+}
+
 // StatProcess stats processes.
 func StatProcess(conn *connection.IRODSConnection, address string, zone string) ([]*types.IRODSProcess, error) {
 	// lock the connection
@@ -3,12 +3,16 @@ package fs
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/phdavis1027/go-irodsclient/irods/util"
 	"golang.org/x/xerrors"
 )
@@ -31,6 +35,7 @@ type DataObjectTransferStatus struct {
 	StatusFilePath string                                   `json:"status_file_path"`
 	Size           int64                                    `json:"size"`
 	Threads        int                                      `json:"threads"`
+	SourceModTime  int64                                    `json:"source_mod_time,omitempty"` // of the local file of uploads, in unix nano
 	StatusMap      map[int64]*DataObjectTransferStatusEntry `json:"-"`
 }
 
@@ -65,6 +70,34 @@ func GetDataObjectTransferStatusFilePath(p string) string {
 	return util.Join(dir, statusFilename)
 }
 
+// getDefaultUploadTransferStatusDir returns the directory of upload transfer status files in the user cache directory,
+// or the directory of the local file if the user cache directory is unknown
+func getDefaultUploadTransferStatusDir(localPath string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Dir(localPath)
+	}
+
+	return filepath.Join(cacheDir, "go-irodsclient", "transfer_status")
+}
+
+// GetDataObjectUploadTransferStatusFilePath returns transfer status file path of uploading the local file to the iRODS path
+// the status file is in statusDir, or in the user cache directory if statusDir is empty
+func GetDataObjectUploadTransferStatusFilePath(statusDir string, localPath string, irodsPath string) string {
+	if len(statusDir) == 0 {
+		statusDir = getDefaultUploadTransferStatusDir(localPath)
+	}
+
+	// name the status file after both paths, so uploads of a local file to different paths do not share it
+	hash, err := util.HashStrings([]string{localPath, "\n", irodsPath}, string(types.ChecksumAlgorithmSHA1))
+	if err != nil {
+		hash = []byte(filepath.Base(localPath))
+	}
+
+	statusFilename := fmt.Sprintf("%s%s%s", DataObjectTransferStatusFilePrefix, hex.EncodeToString(hash), DataObjectTransferStatusFileSuffix)
+	return filepath.Join(statusDir, statusFilename)
+}
+
 // NewDataObjectTransferStatus creates new DataObjectTransferStatus
 func NewDataObjectTransferStatus(path string, size int64, threads int) *DataObjectTransferStatus {
 	return &DataObjectTransferStatus{
@@ -146,7 +179,14 @@ func (status *DataObjectTransferStatusLocal) GetStatus() *DataObjectTransferStat
 }
 
 func (status *DataObjectTransferStatusLocal) CreateStatusFile() error {
-	handle, err := os.Create(status.status.StatusFilePath)
+	statusDir := filepath.Dir(status.status.StatusFilePath)
+	err := os.MkdirAll(statusDir, 0700)
+	if err != nil {
+		return xerrors.Errorf("failed to make dir %s: %w", statusDir, err)
+	}
+
+	// the status file is readable only by the user
+	handle, err := os.OpenFile(status.status.StatusFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
 		return xerrors.Errorf("failed to create file %s: %w", status.status.StatusFilePath, err)
 	}
@@ -202,8 +242,10 @@ func (status *DataObjectTransferStatusLocal) WriteStatus(entry *DataObjectTransf
 
 // GetDataObjectTransferStatusLocal returns DataObjectTransferStatusLocal in local disk
 func GetDataObjectTransferStatusLocal(localPath string) (*DataObjectTransferStatusLocal, error) {
-	statusFilePath := GetDataObjectTransferStatusFilePath(localPath)
+	return readDataObjectTransferStatusLocal(GetDataObjectTransferStatusFilePath(localPath))
+}
 
+func readDataObjectTransferStatusLocal(statusFilePath string) (*DataObjectTransferStatusLocal, error) {
 	_, err := os.Stat(statusFilePath)
 	if err != nil {
 		return nil, err
@@ -216,9 +258,12 @@ func GetDataObjectTransferStatusLocal(localPath string) (*DataObjectTransferStat
 
 	status, err := newDataObjectTransferFromBytes(data)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to create transfer status for %s: %w", localPath, err)
+		return nil, xerrors.Errorf("failed to create transfer status from %s: %w", statusFilePath, err)
 	}
 
+	// keep the path the status is read from
+	status.StatusFilePath = statusFilePath
+
 	return &DataObjectTransferStatusLocal{
 		status:     status,
 		fileHandle: nil,
@@ -246,3 +291,40 @@ func GetOrNewDataObjectTransferStatusLocal(localPath string, size int64, threads
 
 	return status, nil
 }
+
+// NewDataObjectUploadTransferStatusLocal creates new DataObjectTransferStatusLocal for uploading the local file to the iRODS path
+// the status is for the iRODS path and the size and modification time of the local file,
+// the status file is in statusDir or in the user cache directory if statusDir is empty
+func NewDataObjectUploadTransferStatusLocal(statusDir string, localPath string, irodsPath string, size int64, modTime time.Time, threads int) *DataObjectTransferStatusLocal {
+	status := NewDataObjectTransferStatus(irodsPath, size, threads)
+	status.StatusFilePath = GetDataObjectUploadTransferStatusFilePath(statusDir, localPath, irodsPath)
+	status.SourceModTime = modTime.UnixNano()
+
+	return &DataObjectTransferStatusLocal{
+		status:     status,
+		fileHandle: nil,
+	}
+}
+
+// GetOrNewDataObjectUploadTransferStatusLocal returns DataObjectTransferStatusLocal of uploading the local file to the iRODS path
+// a new status is returned if the local file has a different size or modification time
+func GetOrNewDataObjectUploadTransferStatusLocal(statusDir string, localPath string, irodsPath string, size int64, modTime time.Time, threads int) (*DataObjectTransferStatusLocal, error) {
+	status, err := readDataObjectTransferStatusLocal(GetDataObjectUploadTransferStatusFilePath(statusDir, localPath, irodsPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// status file not found
+			status := NewDataObjectUploadTransferStatusLocal(statusDir, localPath, irodsPath, size, modTime, threads)
+			return status, nil
+		}
+
+		return nil, xerrors.Errorf("failed to read transfer status for %s: %w", localPath, err)
+	}
+
+	if !status.status.Validate(irodsPath, size) || status.status.SourceModTime != modTime.UnixNano() {
+		// cannot reuse, e.g., the local file is changed
+		status := NewDataObjectUploadTransferStatusLocal(statusDir, localPath, irodsPath, size, modTime, threads)
+		return status, nil
+	}
+
+	return status, nil
+}
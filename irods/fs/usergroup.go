@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/phdavis1027/go-irodsclient/irods/auth"
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/message"
@@ -508,7 +509,7 @@ func ChangeUserPassword(conn *connection.IRODSConnection, username string, zone
 	account := conn.GetAccount()
 
 	oldPassword := account.Password
-	if account.AuthenticationScheme == types.AuthSchemePAM {
+	if account.AuthenticationScheme == types.AuthSchemePAM || account.AuthenticationScheme == types.AuthSchemePAMPlugin {
 		oldPassword = conn.GetPAMToken()
 	}
 
@@ -523,6 +524,60 @@ func ChangeUserPassword(conn *connection.IRODSConnection, username string, zone
 	return nil
 }
 
+// GetTempPassword returns a temporary password for the current user
+func GetTempPassword(conn *connection.IRODSConnection) (string, error) {
+	if conn == nil || !conn.IsConnected() {
+		return "", xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	req := message.NewIRODSMessageGetTempPasswordRequest()
+	res := message.IRODSMessageGetTempPasswordResponse{}
+
+	err := conn.RequestAndCheck(req, &res, nil)
+	if err != nil {
+		return "", xerrors.Errorf("received get temp password error: %w", err)
+	}
+
+	return auth.GenerateTempPassword(res.StringToHashWith, getAuthPassword(conn)), nil
+}
+
+// GetTempPasswordForOther returns a temporary password for other user, requires rodsadmin privilege
+func GetTempPasswordForOther(conn *connection.IRODSConnection, user string) (string, error) {
+	if conn == nil || !conn.IsConnected() {
+		return "", xerrors.Errorf("connection is nil or disconnected")
+	}
+
+	// lock the connection
+	conn.Lock()
+	defer conn.Unlock()
+
+	req := message.NewIRODSMessageGetTempPasswordForOtherRequest(user)
+	res := message.IRODSMessageGetTempPasswordForOtherResponse{}
+
+	err := conn.RequestAndCheck(req, &res, nil)
+	if err != nil {
+		if types.GetIRODSErrorCode(err) == common.CAT_INVALID_USER {
+			return "", xerrors.Errorf("failed to find the user %s: %w", user, types.NewUserNotFoundError(user))
+		}
+		return "", xerrors.Errorf("received get temp password for other error: %w", err)
+	}
+
+	return auth.GenerateTempPassword(res.StringToHashWith, getAuthPassword(conn)), nil
+}
+
+// getAuthPassword returns the password used for native authentication of the connection
+func getAuthPassword(conn *connection.IRODSConnection) string {
+	account := conn.GetAccount()
+	if account.AuthenticationScheme == types.AuthSchemePAM || account.AuthenticationScheme == types.AuthSchemePAMPlugin {
+		return conn.GetPAMToken()
+	}
+	return account.Password
+}
+
 // ChangeUserType changes the type / role of a user object
 func ChangeUserType(conn *connection.IRODSConnection, username string, zone string, newType string) error {
 	// lock the connection
@@ -3,6 +3,8 @@ package message
 import (
 	"encoding/xml"
 
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"golang.org/x/xerrors"
 )
 
@@ -10,6 +12,9 @@ import (
 type IRODSMessageAuthPluginResponse struct {
 	XMLName xml.Name `xml:"authPlugReqOut_PI"`
 	Result  string   `xml:"result_"`
+
+	// stores error return
+	ReturnCode int `xml:"-"`
 }
 
 // NewIRODSMessageAuthPluginResponse creates a IRODSMessageAuthPluginResponse
@@ -19,6 +24,14 @@ func NewIRODSMessageAuthPluginResponse(result string) *IRODSMessageAuthPluginRes
 	}
 }
 
+// CheckError returns error if server returned an error
+func (msg *IRODSMessageAuthPluginResponse) CheckError() error {
+	if msg.ReturnCode < 0 {
+		return types.NewIRODSError(common.ErrorCode(msg.ReturnCode))
+	}
+	return nil
+}
+
 // GetBytes returns byte array
 func (msg *IRODSMessageAuthPluginResponse) GetBytes() ([]byte, error) {
 	xmlBytes, err := xml.Marshal(msg)
@@ -43,9 +56,13 @@ func (msg *IRODSMessageAuthPluginResponse) FromMessage(msgIn *IRODSMessage) erro
 		return xerrors.Errorf("empty message body")
 	}
 
-	err := msg.FromBytes(msgIn.Body.Message)
-	if err != nil {
-		return xerrors.Errorf("failed to get irods message from message body")
+	msg.ReturnCode = int(msgIn.Body.IntInfo)
+
+	if msgIn.Body.Message != nil {
+		err := msg.FromBytes(msgIn.Body.Message)
+		if err != nil {
+			return xerrors.Errorf("failed to get irods message from message body")
+		}
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package message
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+// IRODSMessageAuthenticationResponse stores authentication response of iRODS 4.3 authentication framework
+type IRODSMessageAuthenticationResponse struct {
+	Body map[string]interface{}
+
+	// stores error return
+	Result int
+}
+
+// CheckError returns error if server returned an error
+func (msg *IRODSMessageAuthenticationResponse) CheckError() error {
+	if msg.Result < 0 {
+		return types.NewIRODSError(common.ErrorCode(msg.Result))
+	}
+	return nil
+}
+
+// FromBytes returns struct from bytes
+func (msg *IRODSMessageAuthenticationResponse) FromBytes(bytes []byte) error {
+	binBytesBuf := IRODSMessageBinBytesBuf{}
+	err := xml.Unmarshal(bytes, &binBytesBuf)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal xml to irods message: %w", err)
+	}
+
+	jsonBody, err := base64.StdEncoding.DecodeString(binBytesBuf.Data)
+	if err != nil {
+		return xerrors.Errorf("failed to decode base64 data: %w", err)
+	}
+
+	// remove trail \x00
+	actualLen := len(jsonBody)
+	for i := len(jsonBody) - 1; i >= 0; i-- {
+		if jsonBody[i] == '\x00' {
+			actualLen = i
+		}
+	}
+	jsonBody = jsonBody[:actualLen]
+
+	err = json.Unmarshal(jsonBody, &msg.Body)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal json to irods message: %w", err)
+	}
+
+	return nil
+}
+
+// FromMessage returns struct from IRODSMessage
+func (msg *IRODSMessageAuthenticationResponse) FromMessage(msgIn *IRODSMessage) error {
+	if msgIn.Body == nil {
+		return xerrors.Errorf("empty message body")
+	}
+
+	msg.Result = int(msgIn.Body.IntInfo)
+
+	if msgIn.Body.Message != nil {
+		err := msg.FromBytes(msgIn.Body.Message)
+		if err != nil {
+			return xerrors.Errorf("failed to get irods message from message body")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package message
+
+import "encoding/xml"
+
+// IRODSMessageDataObjectInfo stores data object info
+type IRODSMessageDataObjectInfo struct {
+	XMLName                  xml.Name                       `xml:"DataObjInfo_PI"`
+	Path                     string                         `xml:"objPath"`
+	ResourceName             string                         `xml:"rescName"`
+	ResourceHierarchy        string                         `xml:"rescHier"`
+	DataType                 string                         `xml:"dataType"`
+	Size                     int64                          `xml:"dataSize"`
+	Checksum                 string                         `xml:"chksum"`
+	Version                  string                         `xml:"version"`
+	PhysicalPath             string                         `xml:"filePath"`
+	DataOwnerName            string                         `xml:"dataOwnerName"`
+	DataOwnerZone            string                         `xml:"dataOwnerZone"`
+	ReplicaNumber            int                            `xml:"replNum"`
+	ReplicaStatus            int                            `xml:"replStatus"`
+	StatusString             string                         `xml:"statusString"`
+	DataID                   int64                          `xml:"dataId"`
+	CollectionID             int64                          `xml:"collId"`
+	DataMapID                int                            `xml:"dataMapId"`
+	Flags                    int                            `xml:"flags"`
+	DataComments             string                         `xml:"dataComments"`
+	DataMode                 string                         `xml:"dataMode"`
+	DataExpiry               string                         `xml:"dataExpiry"`
+	DataCreate               string                         `xml:"dataCreate"`
+	DataModify               string                         `xml:"dataModify"`
+	DataAccess               string                         `xml:"dataAccess"`
+	DataAccessIndex          int                            `xml:"dataAccessInx"`
+	WriteFlag                int                            `xml:"writeFlag"`
+	DestResourceName         string                         `xml:"destRescName"`
+	BackupResourceName       string                         `xml:"backupRescName"`
+	SubPath                  string                         `xml:"subPath"`
+	SpecialCollectionPointer *IRODSMessageSpecialCollection `xml:"SpecColl_PI"`
+	RegisterUserID           int                            `xml:"regUid"`
+	OtherFlags               int                            `xml:"otherFlags"`
+	KeyVals                  IRODSMessageSSKeyVal           `xml:"KeyValPair_PI"`
+	InPDMO                   string                         `xml:"in_pdmo"`
+	ResourceID               int64                          `xml:"rescId"`
+}
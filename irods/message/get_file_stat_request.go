@@ -2,6 +2,7 @@ package message
 
 import (
 	"encoding/xml"
+	"strings"
 
 	"github.com/phdavis1027/go-irodsclient/irods/common"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
@@ -25,8 +26,15 @@ func NewIRODSMessageGetFileStatRequest(resource *types.IRODSResource, obj *types
 		return nil, xerrors.Errorf("failed to create irods host message: %w", err)
 	}
 
-	if resource.Name != replica.ResourceName {
-		return nil, xerrors.Errorf("resource name %s does not match replica resource name %s", resource.Name, replica.ResourceName)
+	// the resource is the leaf of the replica's resource hierarchy
+	leafResourceName := replica.ResourceName
+	if len(replica.ResourceHierarchy) > 0 {
+		hierarchy := strings.Split(replica.ResourceHierarchy, ";")
+		leafResourceName = hierarchy[len(hierarchy)-1]
+	}
+
+	if resource.Name != leafResourceName {
+		return nil, xerrors.Errorf("resource name %s does not match replica resource name %s", resource.Name, leafResourceName)
 	}
 
 	request := &IRODSMessageGetFileStatRequest{
@@ -0,0 +1,36 @@
+package message
+
+import (
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+)
+
+// IRODSMessageGetMiscServerInfoRequest stores misc server info request
+type IRODSMessageGetMiscServerInfoRequest struct {
+	// empty structure
+}
+
+// NewIRODSMessageGetMiscServerInfoRequest creates a IRODSMessageGetMiscServerInfoRequest message
+func NewIRODSMessageGetMiscServerInfoRequest() *IRODSMessageGetMiscServerInfoRequest {
+	return &IRODSMessageGetMiscServerInfoRequest{}
+}
+
+// GetMessage builds a message
+func (msg *IRODSMessageGetMiscServerInfoRequest) GetMessage() (*IRODSMessage, error) {
+	msgHeader := IRODSMessageHeader{
+		Type:       RODS_MESSAGE_API_REQ_TYPE,
+		MessageLen: 0,
+		ErrorLen:   0,
+		BsLen:      0,
+		IntInfo:    int32(common.GET_MISC_SVR_INFO_AN),
+	}
+
+	return &IRODSMessage{
+		Header: &msgHeader,
+		Body:   nil,
+	}, nil
+}
+
+// FromMessage returns struct from IRODSMessage
+func (msg *IRODSMessageGetMiscServerInfoRequest) FromMessage(msgIn *IRODSMessage) error {
+	return nil
+}
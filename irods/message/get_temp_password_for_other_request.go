@@ -0,0 +1,67 @@
+package message
+
+import (
+	"encoding/xml"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"golang.org/x/xerrors"
+)
+
+// IRODSMessageGetTempPasswordForOtherRequest stores temporary password request for other user
+type IRODSMessageGetTempPasswordForOtherRequest struct {
+	XMLName    xml.Name `xml:"getTempPasswordForOtherInp_PI"`
+	TargetUser string   `xml:"targetUser"`
+	Unused     string   `xml:"unused"`
+}
+
+// NewIRODSMessageGetTempPasswordForOtherRequest creates a IRODSMessageGetTempPasswordForOtherRequest message
+func NewIRODSMessageGetTempPasswordForOtherRequest(targetUser string) *IRODSMessageGetTempPasswordForOtherRequest {
+	return &IRODSMessageGetTempPasswordForOtherRequest{
+		TargetUser: targetUser,
+		Unused:     "",
+	}
+}
+
+// GetBytes returns byte array
+func (msg *IRODSMessageGetTempPasswordForOtherRequest) GetBytes() ([]byte, error) {
+	xmlBytes, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal irods message to xml: %w", err)
+	}
+	return xmlBytes, nil
+}
+
+// FromBytes returns struct from bytes
+func (msg *IRODSMessageGetTempPasswordForOtherRequest) FromBytes(bytes []byte) error {
+	err := xml.Unmarshal(bytes, msg)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal xml to irods message: %w", err)
+	}
+	return nil
+}
+
+// GetMessage builds a message
+func (msg *IRODSMessageGetTempPasswordForOtherRequest) GetMessage() (*IRODSMessage, error) {
+	bytes, err := msg.GetBytes()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get bytes from irods message: %w", err)
+	}
+
+	msgBody := IRODSMessageBody{
+		Type:    RODS_MESSAGE_API_REQ_TYPE,
+		Message: bytes,
+		Error:   nil,
+		Bs:      nil,
+		IntInfo: int32(common.GET_TEMP_PASSWORD_FOR_OTHER_AN),
+	}
+
+	msgHeader, err := msgBody.BuildHeader()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to build header from irods message: %w", err)
+	}
+
+	return &IRODSMessage{
+		Header: msgHeader,
+		Body:   &msgBody,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package message
+
+import (
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+)
+
+// IRODSMessageGetTempPasswordRequest stores temporary password request
+type IRODSMessageGetTempPasswordRequest struct {
+	// empty structure
+}
+
+// NewIRODSMessageGetTempPasswordRequest creates a IRODSMessageGetTempPasswordRequest message
+func NewIRODSMessageGetTempPasswordRequest() *IRODSMessageGetTempPasswordRequest {
+	return &IRODSMessageGetTempPasswordRequest{}
+}
+
+// GetMessage builds a message
+func (msg *IRODSMessageGetTempPasswordRequest) GetMessage() (*IRODSMessage, error) {
+	msgHeader := IRODSMessageHeader{
+		Type:       RODS_MESSAGE_API_REQ_TYPE,
+		MessageLen: 0,
+		ErrorLen:   0,
+		BsLen:      0,
+		IntInfo:    int32(common.GET_TEMP_PASSWORD_AN),
+	}
+
+	return &IRODSMessage{
+		Header: &msgHeader,
+		Body:   nil,
+	}, nil
+}
+
+// FromMessage returns struct from IRODSMessage
+func (msg *IRODSMessageGetTempPasswordRequest) FromMessage(msgIn *IRODSMessage) error {
+	return nil
+}
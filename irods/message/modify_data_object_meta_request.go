@@ -0,0 +1,104 @@
+package message
+
+import (
+	"encoding/xml"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"golang.org/x/xerrors"
+)
+
+// IRODSMessageModifyDataObjectMetaRequest stores data object system metadata modification request
+type IRODSMessageModifyDataObjectMetaRequest struct {
+	XMLName        xml.Name                   `xml:"ModDataObjMeta_PI"`
+	DataObjectInfo IRODSMessageDataObjectInfo `xml:"DataObjInfo_PI"`
+	KeyVals        IRODSMessageSSKeyVal       `xml:"KeyValPair_PI"`
+}
+
+// NewIRODSMessageModifyDataObjectMetaRequest creates a IRODSMessageModifyDataObjectMetaRequest message
+func NewIRODSMessageModifyDataObjectMetaRequest(path string, replicaNumber int64, resourceHierarchy string) *IRODSMessageModifyDataObjectMetaRequest {
+	request := &IRODSMessageModifyDataObjectMetaRequest{
+		DataObjectInfo: IRODSMessageDataObjectInfo{
+			Path:              path,
+			ResourceHierarchy: resourceHierarchy,
+			ReplicaNumber:     int(replicaNumber),
+			KeyVals: IRODSMessageSSKeyVal{
+				Length: 0,
+			},
+		},
+		KeyVals: IRODSMessageSSKeyVal{
+			Length: 0,
+		},
+	}
+
+	return request
+}
+
+// NewIRODSMessageModifyDataObjectReplicaStatusRequest creates a IRODSMessageModifyDataObjectMetaRequest message for changing replica status
+func NewIRODSMessageModifyDataObjectReplicaStatusRequest(path string, replicaNumber int64, resourceHierarchy string, status string, adminFlag bool) *IRODSMessageModifyDataObjectMetaRequest {
+	request := NewIRODSMessageModifyDataObjectMetaRequest(path, replicaNumber, resourceHierarchy)
+	request.AddKeyVal(common.REPL_STATUS_KW, status)
+
+	if adminFlag {
+		request.AddKeyVal(common.ADMIN_KW, "")
+	}
+
+	return request
+}
+
+// NewIRODSMessageModifyDataObjectModifyTimeRequest creates a IRODSMessageModifyDataObjectMetaRequest message for changing modification time of all replicas
+func NewIRODSMessageModifyDataObjectModifyTimeRequest(path string, modifyTime string) *IRODSMessageModifyDataObjectMetaRequest {
+	request := NewIRODSMessageModifyDataObjectMetaRequest(path, 0, "")
+	request.AddKeyVal(common.DATA_MODIFY_KW, modifyTime)
+	request.AddKeyVal(common.ALL_KW, "")
+
+	return request
+}
+
+// AddKeyVal adds a key-value pair
+func (msg *IRODSMessageModifyDataObjectMetaRequest) AddKeyVal(key common.KeyWord, val string) {
+	msg.KeyVals.Add(string(key), val)
+}
+
+// GetBytes returns byte array
+func (msg *IRODSMessageModifyDataObjectMetaRequest) GetBytes() ([]byte, error) {
+	xmlBytes, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal irods message to xml: %w", err)
+	}
+	return xmlBytes, nil
+}
+
+// FromBytes returns struct from bytes
+func (msg *IRODSMessageModifyDataObjectMetaRequest) FromBytes(bytes []byte) error {
+	err := xml.Unmarshal(bytes, msg)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal xml to irods message: %w", err)
+	}
+	return nil
+}
+
+// GetMessage builds a message
+func (msg *IRODSMessageModifyDataObjectMetaRequest) GetMessage() (*IRODSMessage, error) {
+	bytes, err := msg.GetBytes()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get bytes from irods message: %w", err)
+	}
+
+	msgBody := IRODSMessageBody{
+		Type:    RODS_MESSAGE_API_REQ_TYPE,
+		Message: bytes,
+		Error:   nil,
+		Bs:      nil,
+		IntInfo: int32(common.MOD_DATA_OBJ_META_AN),
+	}
+
+	msgHeader, err := msgBody.BuildHeader()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to build header from irods message: %w", err)
+	}
+
+	return &IRODSMessage{
+		Header: msgHeader,
+		Body:   &msgBody,
+	}, nil
+}
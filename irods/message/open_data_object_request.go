@@ -109,6 +109,32 @@ func NewIRODSMessageOpenobjRequestWithReplicaToken(path string, mode types.FileO
 	return request
 }
 
+// NewIRODSMessageOpenobjRequestWithReplica creates a IRODSMessageOpenobjRequest message for opening a specific replica
+// resourceHierarchy has priority over replicaNumber, replicaNumber is ignored if negative
+func NewIRODSMessageOpenobjRequestWithReplica(path string, mode types.FileOpenMode, replicaNumber int64, resourceHierarchy string) *IRODSMessageOpenDataObjectRequest {
+	flag := mode.GetFlag()
+	request := &IRODSMessageOpenDataObjectRequest{
+		Path:          path,
+		CreateMode:    0,
+		OpenFlags:     flag,
+		Offset:        0,
+		Size:          -1,
+		Threads:       0,
+		OperationType: 0,
+		KeyVals: IRODSMessageSSKeyVal{
+			Length: 0,
+		},
+	}
+
+	if len(resourceHierarchy) > 0 {
+		request.AddKeyVal(common.RESC_HIER_STR_KW, resourceHierarchy)
+	} else if replicaNumber >= 0 {
+		request.AddKeyVal(common.REPL_NUM_KW, fmt.Sprintf("%d", replicaNumber))
+	}
+
+	return request
+}
+
 // AddKeyVal adds a key-value pair
 func (msg *IRODSMessageOpenDataObjectRequest) AddKeyVal(key common.KeyWord, val string) {
 	msg.KeyVals.Add(string(key), val)
@@ -38,6 +38,18 @@ func NewIRODSMessageRemoveCollectionRequest(name string, recurse bool, force boo
 	return request
 }
 
+// NewIRODSMessageUnregisterCollectionRequest creates a IRODSMessageRemoveCollectionRequest message for unregistering an empty collection
+func NewIRODSMessageUnregisterCollectionRequest(name string, adminFlag bool) *IRODSMessageRemoveCollectionRequest {
+	request := NewIRODSMessageRemoveCollectionRequest(name, false, false)
+	request.AddKeyVal(common.UNREG_COLL_KW, "")
+
+	if adminFlag {
+		request.AddKeyVal(common.ADMIN_KW, "")
+	}
+
+	return request
+}
+
 // AddKeyVal adds a key-value pair
 func (msg *IRODSMessageRemoveCollectionRequest) AddKeyVal(key common.KeyWord, val string) {
 	msg.KeyVals.Add(string(key), val)
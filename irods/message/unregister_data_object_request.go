@@ -0,0 +1,88 @@
+package message
+
+import (
+	"encoding/xml"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"golang.org/x/xerrors"
+)
+
+// IRODSMessageUnregisterDataObjectRequest stores data object unregister request
+type IRODSMessageUnregisterDataObjectRequest struct {
+	XMLName        xml.Name                   `xml:"UnregDataObj_PI"`
+	DataObjectInfo IRODSMessageDataObjectInfo `xml:"DataObjInfo_PI"`
+	KeyVals        IRODSMessageSSKeyVal       `xml:"KeyValPair_PI"`
+}
+
+// NewIRODSMessageUnregisterDataObjectRequest creates a IRODSMessageUnregisterDataObjectRequest message
+// all replicas are unregistered if replicaNumber is negative, dataID is required to unregister a replica
+func NewIRODSMessageUnregisterDataObjectRequest(path string, dataID int64, replicaNumber int64, adminFlag bool) *IRODSMessageUnregisterDataObjectRequest {
+	request := &IRODSMessageUnregisterDataObjectRequest{
+		DataObjectInfo: IRODSMessageDataObjectInfo{
+			Path:          path,
+			DataID:        dataID,
+			ReplicaNumber: int(replicaNumber),
+			KeyVals: IRODSMessageSSKeyVal{
+				Length: 0,
+			},
+		},
+		KeyVals: IRODSMessageSSKeyVal{
+			Length: 0,
+		},
+	}
+
+	if adminFlag {
+		request.AddKeyVal(common.ADMIN_KW, "")
+	}
+
+	return request
+}
+
+// AddKeyVal adds a key-value pair
+func (msg *IRODSMessageUnregisterDataObjectRequest) AddKeyVal(key common.KeyWord, val string) {
+	msg.KeyVals.Add(string(key), val)
+}
+
+// GetBytes returns byte array
+func (msg *IRODSMessageUnregisterDataObjectRequest) GetBytes() ([]byte, error) {
+	xmlBytes, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal irods message to xml: %w", err)
+	}
+	return xmlBytes, nil
+}
+
+// FromBytes returns struct from bytes
+func (msg *IRODSMessageUnregisterDataObjectRequest) FromBytes(bytes []byte) error {
+	err := xml.Unmarshal(bytes, msg)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal xml to irods message: %w", err)
+	}
+	return nil
+}
+
+// GetMessage builds a message
+func (msg *IRODSMessageUnregisterDataObjectRequest) GetMessage() (*IRODSMessage, error) {
+	bytes, err := msg.GetBytes()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get bytes from irods message: %w", err)
+	}
+
+	msgBody := IRODSMessageBody{
+		Type:    RODS_MESSAGE_API_REQ_TYPE,
+		Message: bytes,
+		Error:   nil,
+		Bs:      nil,
+		IntInfo: int32(common.UNREG_DATA_OBJ_AN),
+	}
+
+	msgHeader, err := msgBody.BuildHeader()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to build header from irods message: %w", err)
+	}
+
+	return &IRODSMessage{
+		Header: msgHeader,
+		Body:   &msgBody,
+	}, nil
+}
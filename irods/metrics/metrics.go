@@ -1,6 +1,9 @@
 package metrics
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // IRODSMetrics - contains IRODS metrics
 type IRODSMetrics struct {
@@ -47,6 +50,21 @@ type IRODSMetrics struct {
 	connectionFailures      uint64
 	connectionPoolFailures  uint64
 
+	// connection pool waits
+	connectionPoolWaits        uint64
+	connectionPoolWaitTime     uint64 // in nanoseconds
+	connectionPoolWaitTimeouts uint64
+	connectionPoolWaiters      uint64 // gauge
+
+	// connection health
+	connectionHealthChecks        uint64
+	connectionHealthCheckFailures uint64
+	operationRetries              uint64
+
+	// keepalive
+	heartbeats        uint64
+	heartbeatFailures uint64
+
 	mutex sync.Mutex
 }
 
@@ -870,6 +888,244 @@ func (metrics *IRODSMetrics) GetAndClearCounterForConnectionPoolFailures() uint6
 	return failures
 }
 
+// IncreaseCounterForConnectionPoolWaits increases the counter for connection pool waits
+func (metrics *IRODSMetrics) IncreaseCounterForConnectionPoolWaits(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.connectionPoolWaits += n
+}
+
+// GetCounterForConnectionPoolWaits returns the counter for connection pool waits
+func (metrics *IRODSMetrics) GetCounterForConnectionPoolWaits() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.connectionPoolWaits
+}
+
+// GetAndClearCounterForConnectionPoolWaits returns the counter for connection pool waits then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForConnectionPoolWaits() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	waits := metrics.connectionPoolWaits
+	metrics.connectionPoolWaits = 0
+	return waits
+}
+
+// IncreaseConnectionPoolWaitTime increases the total time spent waiting for pooled connections
+func (metrics *IRODSMetrics) IncreaseConnectionPoolWaitTime(waitTime time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	if waitTime > 0 {
+		metrics.connectionPoolWaitTime += uint64(waitTime)
+	}
+}
+
+// GetConnectionPoolWaitTime returns the total time spent waiting for pooled connections
+func (metrics *IRODSMetrics) GetConnectionPoolWaitTime() time.Duration {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return time.Duration(metrics.connectionPoolWaitTime)
+}
+
+// GetAndClearConnectionPoolWaitTime returns the total time spent waiting for pooled connections then clear
+func (metrics *IRODSMetrics) GetAndClearConnectionPoolWaitTime() time.Duration {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	waitTime := metrics.connectionPoolWaitTime
+	metrics.connectionPoolWaitTime = 0
+	return time.Duration(waitTime)
+}
+
+// IncreaseCounterForConnectionPoolWaitTimeouts increases the counter for connection pool wait timeouts
+func (metrics *IRODSMetrics) IncreaseCounterForConnectionPoolWaitTimeouts(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.connectionPoolWaitTimeouts += n
+}
+
+// GetCounterForConnectionPoolWaitTimeouts returns the counter for connection pool wait timeouts
+func (metrics *IRODSMetrics) GetCounterForConnectionPoolWaitTimeouts() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.connectionPoolWaitTimeouts
+}
+
+// GetAndClearCounterForConnectionPoolWaitTimeouts returns the counter for connection pool wait timeouts then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForConnectionPoolWaitTimeouts() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	timeouts := metrics.connectionPoolWaitTimeouts
+	metrics.connectionPoolWaitTimeouts = 0
+	return timeouts
+}
+
+// IncreaseConnectionPoolWaiters increases the number of requests waiting for pooled connections
+func (metrics *IRODSMetrics) IncreaseConnectionPoolWaiters(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.connectionPoolWaiters += n
+}
+
+// DecreaseConnectionPoolWaiters decreases the number of requests waiting for pooled connections
+func (metrics *IRODSMetrics) DecreaseConnectionPoolWaiters(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	if metrics.connectionPoolWaiters < n {
+		metrics.connectionPoolWaiters = 0
+	} else {
+		metrics.connectionPoolWaiters -= n
+	}
+}
+
+// GetConnectionPoolWaiters returns the number of requests waiting for pooled connections (queue depth)
+func (metrics *IRODSMetrics) GetConnectionPoolWaiters() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.connectionPoolWaiters
+}
+
+// IncreaseCounterForConnectionHealthChecks increases the counter for connection health checks
+func (metrics *IRODSMetrics) IncreaseCounterForConnectionHealthChecks(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.connectionHealthChecks += n
+}
+
+// GetCounterForConnectionHealthChecks returns the counter for connection health checks
+func (metrics *IRODSMetrics) GetCounterForConnectionHealthChecks() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.connectionHealthChecks
+}
+
+// GetAndClearCounterForConnectionHealthChecks returns the counter for connection health checks then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForConnectionHealthChecks() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	checks := metrics.connectionHealthChecks
+	metrics.connectionHealthChecks = 0
+	return checks
+}
+
+// IncreaseCounterForConnectionHealthCheckFailures increases the counter for connection health check failures
+func (metrics *IRODSMetrics) IncreaseCounterForConnectionHealthCheckFailures(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.connectionHealthCheckFailures += n
+}
+
+// GetCounterForConnectionHealthCheckFailures returns the counter for connection health check failures
+func (metrics *IRODSMetrics) GetCounterForConnectionHealthCheckFailures() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.connectionHealthCheckFailures
+}
+
+// GetAndClearCounterForConnectionHealthCheckFailures returns the counter for connection health check failures then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForConnectionHealthCheckFailures() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	failures := metrics.connectionHealthCheckFailures
+	metrics.connectionHealthCheckFailures = 0
+	return failures
+}
+
+// IncreaseCounterForOperationRetries increases the counter for operation retries
+func (metrics *IRODSMetrics) IncreaseCounterForOperationRetries(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.operationRetries += n
+}
+
+// GetCounterForOperationRetries returns the counter for operation retries
+func (metrics *IRODSMetrics) GetCounterForOperationRetries() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.operationRetries
+}
+
+// GetAndClearCounterForOperationRetries returns the counter for operation retries then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForOperationRetries() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	retries := metrics.operationRetries
+	metrics.operationRetries = 0
+	return retries
+}
+
+// IncreaseCounterForHeartbeats increases the counter for heartbeats sent
+func (metrics *IRODSMetrics) IncreaseCounterForHeartbeats(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.heartbeats += n
+}
+
+// GetCounterForHeartbeats returns the counter for heartbeats sent
+func (metrics *IRODSMetrics) GetCounterForHeartbeats() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.heartbeats
+}
+
+// GetAndClearCounterForHeartbeats returns the counter for heartbeats sent then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForHeartbeats() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	heartbeats := metrics.heartbeats
+	metrics.heartbeats = 0
+	return heartbeats
+}
+
+// IncreaseCounterForHeartbeatFailures increases the counter for heartbeat failures
+func (metrics *IRODSMetrics) IncreaseCounterForHeartbeatFailures(n uint64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.heartbeatFailures += n
+}
+
+// GetCounterForHeartbeatFailures returns the counter for heartbeat failures
+func (metrics *IRODSMetrics) GetCounterForHeartbeatFailures() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	return metrics.heartbeatFailures
+}
+
+// GetAndClearCounterForHeartbeatFailures returns the counter for heartbeat failures then clear
+func (metrics *IRODSMetrics) GetAndClearCounterForHeartbeatFailures() uint64 {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	failures := metrics.heartbeatFailures
+	metrics.heartbeatFailures = 0
+	return failures
+}
+
 func (metrics *IRODSMetrics) Sum(other *IRODSMetrics) {
 	metrics.stat += other.stat
 	metrics.list += other.list
@@ -902,4 +1158,13 @@ func (metrics *IRODSMetrics) Sum(other *IRODSMetrics) {
 	metrics.requestResponseFailures += other.requestResponseFailures
 	metrics.connectionFailures += other.connectionFailures
 	metrics.connectionPoolFailures += other.connectionPoolFailures
+	metrics.connectionPoolWaits += other.connectionPoolWaits
+	metrics.connectionPoolWaitTime += other.connectionPoolWaitTime
+	metrics.connectionPoolWaitTimeouts += other.connectionPoolWaitTimeouts
+	metrics.connectionPoolWaiters += other.connectionPoolWaiters
+	metrics.connectionHealthChecks += other.connectionHealthChecks
+	metrics.connectionHealthCheckFailures += other.connectionHealthCheckFailures
+	metrics.operationRetries += other.operationRetries
+	metrics.heartbeats += other.heartbeats
+	metrics.heartbeatFailures += other.heartbeatFailures
 }
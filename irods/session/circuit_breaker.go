@@ -0,0 +1,344 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// CircuitBreakerFailureThresholdDefault is a default number of consecutive failures to open the circuit
+	CircuitBreakerFailureThresholdDefault = 1
+	// CircuitBreakerSuccessThresholdDefault is a default number of successful probes to close the circuit
+	CircuitBreakerSuccessThresholdDefault = 1
+)
+
+// CircuitState is a state of circuit breaker
+type CircuitState int
+
+const (
+	// CircuitStateClosed allows all requests
+	CircuitStateClosed CircuitState = iota
+	// CircuitStateOpen rejects all requests until cool-down passes
+	CircuitStateOpen
+	// CircuitStateHalfOpen allows a probe request to test if the server has recovered
+	CircuitStateHalfOpen
+)
+
+// String returns a string representation of the state
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitStateClosed:
+		return "closed"
+	case CircuitStateOpen:
+		return "open"
+	case CircuitStateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig is for circuit breaker configuration
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // consecutive failures to open the circuit, permanent failures open it immediately
+	SuccessThreshold int           // successful probes in half-open state to close the circuit
+	CoolDown         time.Duration // time to stay open before allowing a probe
+	AutoProbe        bool          // probe with a new connection in background after cool-down, without waiting for requests
+}
+
+// NewCircuitBreakerConfig creates a CircuitBreakerConfig
+func NewCircuitBreakerConfig(failureThreshold int, successThreshold int, coolDown time.Duration, autoProbe bool) *CircuitBreakerConfig {
+	return &CircuitBreakerConfig{
+		FailureThreshold: failureThreshold,
+		SuccessThreshold: successThreshold,
+		CoolDown:         coolDown,
+		AutoProbe:        autoProbe,
+	}
+}
+
+// NewCircuitBreakerConfigWithDefault creates a CircuitBreakerConfig with a default settings
+func NewCircuitBreakerConfigWithDefault() *CircuitBreakerConfig {
+	return NewCircuitBreakerConfig(CircuitBreakerFailureThresholdDefault, CircuitBreakerSuccessThresholdDefault, IRODSSessionConnectionErrorTimeoutDefault, false)
+}
+
+// CircuitBreakerEvent is an event of circuit state transition
+type CircuitBreakerEvent struct {
+	From  CircuitState
+	To    CircuitState
+	Error error // error caused the transition, nil if recovered
+	Time  time.Time
+}
+
+// CircuitBreakerCallback is called when circuit state changes
+type CircuitBreakerCallback func(event *CircuitBreakerEvent)
+
+// CircuitBreaker is a circuit breaker state machine (closed, open, half-open)
+type CircuitBreaker struct {
+	config               *CircuitBreakerConfig
+	state                CircuitState
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	lastError            error
+	lastErrorTime        time.Time
+	openedTime           time.Time
+	probing              bool
+	probeStartTime       time.Time
+	callbacks            []CircuitBreakerCallback
+	mutex                sync.Mutex
+}
+
+// NewCircuitBreaker creates a CircuitBreaker
+func NewCircuitBreaker(config *CircuitBreakerConfig) *CircuitBreaker {
+	if config == nil {
+		config = NewCircuitBreakerConfigWithDefault()
+	}
+
+	return &CircuitBreaker{
+		config:               config,
+		state:                CircuitStateClosed,
+		consecutiveFailures:  0,
+		consecutiveSuccesses: 0,
+		lastError:            nil,
+		lastErrorTime:        time.Time{},
+		openedTime:           time.Time{},
+		probing:              false,
+		probeStartTime:       time.Time{},
+		callbacks:            []CircuitBreakerCallback{},
+		mutex:                sync.Mutex{},
+	}
+}
+
+// GetConfig returns a configuration
+func (breaker *CircuitBreaker) GetConfig() *CircuitBreakerConfig {
+	return breaker.config
+}
+
+// AddCallback adds a callback that is called when circuit state changes
+func (breaker *CircuitBreaker) AddCallback(callback CircuitBreakerCallback) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.callbacks = append(breaker.callbacks, callback)
+}
+
+// GetState returns current state
+func (breaker *CircuitBreaker) GetState() CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return breaker.state
+}
+
+// GetLastError returns the last error recorded and its time
+func (breaker *CircuitBreaker) GetLastError() (time.Time, error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return breaker.lastErrorTime, breaker.lastError
+}
+
+// ReadyToProbe returns true if the circuit is open and cool-down has passed
+func (breaker *CircuitBreaker) ReadyToProbe() bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return breaker.state == CircuitStateOpen && breaker.coolDownPassed(time.Now())
+}
+
+// Allow checks if a request can proceed, returns an error if the circuit is open
+// in half-open state, only one probe request is allowed at a time
+func (breaker *CircuitBreaker) Allow() error {
+	notification, err := breaker.allow()
+	notification.fire()
+	return err
+}
+
+// ReleaseProbe gives up the probe slot taken by Allow without recording a result,
+// so the next request can probe the server
+func (breaker *CircuitBreaker) ReleaseProbe() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.state == CircuitStateHalfOpen {
+		breaker.probing = false
+	}
+}
+
+// RecordSuccess records a successful request
+func (breaker *CircuitBreaker) RecordSuccess() {
+	breaker.recordSuccess().fire()
+}
+
+// RecordFailure records a failed request
+func (breaker *CircuitBreaker) RecordFailure(err error) {
+	breaker.recordFailure(err).fire()
+}
+
+// Reset closes the circuit and clears errors
+func (breaker *CircuitBreaker) Reset() {
+	breaker.reset().fire()
+}
+
+func (breaker *CircuitBreaker) allow() (*circuitNotification, error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	now := time.Now()
+	var event *CircuitBreakerEvent
+	var err error
+
+	switch breaker.state {
+	case CircuitStateClosed:
+		// pass
+	case CircuitStateOpen:
+		if breaker.coolDownPassed(now) {
+			event = breaker.transit(CircuitStateHalfOpen, nil, now)
+			breaker.startProbe(now)
+		} else {
+			err = xerrors.Errorf("circuit is open: %w", breaker.lastError)
+		}
+	case CircuitStateHalfOpen:
+		// a probe may never report if the caller gave up, let another probe in after cool-down
+		if breaker.probing && !breaker.probeStartTime.Add(breaker.config.CoolDown).Before(now) {
+			err = xerrors.Errorf("circuit is half-open, waiting for probe result: %w", breaker.lastError)
+		} else {
+			breaker.startProbe(now)
+		}
+	}
+
+	return breaker.newNotification(event), err
+}
+
+func (breaker *CircuitBreaker) recordSuccess() *circuitNotification {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	var event *CircuitBreakerEvent
+	breaker.consecutiveFailures = 0
+
+	switch breaker.state {
+	case CircuitStateHalfOpen, CircuitStateOpen:
+		breaker.probing = false
+		breaker.consecutiveSuccesses++
+		if breaker.consecutiveSuccesses >= breaker.config.SuccessThreshold {
+			breaker.lastError = nil
+			breaker.lastErrorTime = time.Time{}
+			event = breaker.transit(CircuitStateClosed, nil, time.Now())
+		}
+	}
+
+	return breaker.newNotification(event)
+}
+
+func (breaker *CircuitBreaker) recordFailure(err error) *circuitNotification {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	now := time.Now()
+	var event *CircuitBreakerEvent
+
+	breaker.lastError = err
+	breaker.lastErrorTime = now
+	breaker.consecutiveSuccesses = 0
+	breaker.consecutiveFailures++
+
+	switch breaker.state {
+	case CircuitStateClosed:
+		if types.IsPermanantFailure(err) || breaker.consecutiveFailures >= breaker.config.FailureThreshold {
+			event = breaker.transit(CircuitStateOpen, err, now)
+		}
+	case CircuitStateHalfOpen:
+		// probe failed
+		event = breaker.transit(CircuitStateOpen, err, now)
+	case CircuitStateOpen:
+		// failed background probe, restart cool-down
+		breaker.openedTime = now
+		breaker.probing = false
+	}
+
+	return breaker.newNotification(event)
+}
+
+func (breaker *CircuitBreaker) reset() *circuitNotification {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.consecutiveFailures = 0
+	breaker.consecutiveSuccesses = 0
+	breaker.lastError = nil
+	breaker.lastErrorTime = time.Time{}
+	breaker.probing = false
+
+	var event *CircuitBreakerEvent
+	if breaker.state != CircuitStateClosed {
+		event = breaker.transit(CircuitStateClosed, nil, time.Now())
+	}
+
+	return breaker.newNotification(event)
+}
+
+func (breaker *CircuitBreaker) coolDownPassed(now time.Time) bool {
+	return breaker.openedTime.Add(breaker.config.CoolDown).Before(now)
+}
+
+func (breaker *CircuitBreaker) startProbe(now time.Time) {
+	breaker.probing = true
+	breaker.probeStartTime = now
+}
+
+func (breaker *CircuitBreaker) transit(state CircuitState, err error, now time.Time) *CircuitBreakerEvent {
+	event := &CircuitBreakerEvent{
+		From:  breaker.state,
+		To:    state,
+		Error: err,
+		Time:  now,
+	}
+
+	breaker.state = state
+	breaker.probing = false
+	breaker.consecutiveSuccesses = 0
+
+	if state == CircuitStateOpen {
+		breaker.openedTime = now
+	}
+
+	if state == CircuitStateClosed {
+		breaker.consecutiveFailures = 0
+	}
+
+	return event
+}
+
+// circuitNotification is a state transition to be notified to callbacks once all locks are released
+type circuitNotification struct {
+	callbacks []CircuitBreakerCallback
+	event     *CircuitBreakerEvent
+}
+
+func (breaker *CircuitBreaker) newNotification(event *CircuitBreakerEvent) *circuitNotification {
+	if event == nil || len(breaker.callbacks) == 0 {
+		return nil
+	}
+
+	callbacks := make([]CircuitBreakerCallback, len(breaker.callbacks))
+	copy(callbacks, breaker.callbacks)
+
+	return &circuitNotification{
+		callbacks: callbacks,
+		event:     event,
+	}
+}
+
+// fire calls callbacks, must be called without holding locks, so callbacks can call the breaker or the session
+func (notification *circuitNotification) fire() {
+	if notification == nil {
+		return
+	}
+
+	for _, callback := range notification.callbacks {
+		callback(notification.event)
+	}
+}
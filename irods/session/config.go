@@ -2,6 +2,10 @@ package session
 
 import (
 	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
 )
 
 const (
@@ -33,6 +37,51 @@ type IRODSSessionConfig struct {
 	ConnectionMaxIdle      int
 	TcpBufferSize          int
 	StartNewTransaction    bool
+	// ConnectionWaitTimeout is how long AcquireConnection waits for a pooled connection when the pool is full.
+	// 0 keeps the legacy behavior that shares an in-use connection instead of waiting.
+	ConnectionWaitTimeout time.Duration
+	// ConnectionMaxPerCaller limits connections held by a single caller id, 0 means no limit
+	ConnectionMaxPerCaller int
+	// ConnectionPriority is the lane AcquireConnection waits in, lanes compete only within the session's pool,
+	// sessions created by NewIRODSSessionSharingConnectionPool share the pool
+	ConnectionPriority ConnectionPriority
+	// ConnectionMaxPerPriority limits connections held by requests of a priority, absent means ConnectionMax
+	// e.g., limiting the io lane keeps connections for metadata requests
+	ConnectionMaxPerPriority map[ConnectionPriority]int
+	// ConnectionValidateOnBorrow checks an idle connection with a no-op request before handing it out
+	ConnectionValidateOnBorrow bool
+	// ConnectionHealthCheckInterval is an interval to check idle connections in background, 0 disables it
+	ConnectionHealthCheckInterval time.Duration
+	// RetryPolicy defines how operations run by ExecuteIdempotent are retried, nil (default) disables retry.
+	// FileSystem runs read-only catalog operations (stat, list, query, ticket, ACL and metadata listing) with it,
+	// operations that change the server state and data transfers are never retried
+	RetryPolicy *RetryPolicy
+	// CircuitBreaker configures the circuit breaker that stops connection attempts after failures
+	// if nil, the circuit opens at the first failure and allows a probe after ConnectionErrorTimeout
+	CircuitBreaker *CircuitBreakerConfig
+	// Endpoints lists catalog service providers to spread connections over
+	// if empty, only the account's host and port are used
+	Endpoints []Endpoint
+	// LoadBalancingStrategy determines how an endpoint is selected for a new connection
+	LoadBalancingStrategy LoadBalancingStrategy
+	// EndpointRetryInterval is how long a failed endpoint is skipped before it is tried again
+	EndpointRetryInterval time.Duration
+	// Dialer is used to connect to the server and resource servers, e.g., through SOCKS5 or HTTP CONNECT proxies
+	// nil connects directly
+	Dialer connection.Dialer
+	// ConnectionTCPKeepAlivePeriod is a TCP keepalive period of connections, 0 uses the OS default
+	ConnectionTCPKeepAlivePeriod time.Duration
+	// ConnectionHeartbeatInterval is an interval to send heartbeats on idle connections to keep them
+	// from being dropped by firewalls, 0 disables it
+	ConnectionHeartbeatInterval time.Duration
+	// SendRateLimiter limits bytes sent by all connections of the session, nil disables it
+	// the limit can be changed at runtime and the limiter can be shared by sessions
+	SendRateLimiter *util.RateLimiter
+	// RecvRateLimiter limits bytes received by all connections of the session, nil disables it
+	RecvRateLimiter *util.RateLimiter
+	// ParallelTransfer configures the number of tasks and block size of parallel transfers
+	// if nil, fixed default limits are used
+	ParallelTransfer *util.ParallelTransferConfig
 }
 
 // NewIRODSSessionConfig create a IRODSSessionConfig
@@ -42,31 +91,105 @@ func NewIRODSSessionConfig(applicationName string, connectionErrorTimeout time.D
 	}
 
 	return &IRODSSessionConfig{
-		ApplicationName:        applicationName,
-		ConnectionErrorTimeout: connectionErrorTimeout,
-		ConnectionLifespan:     connectionLifespan,
-		OperationTimeout:       operationTimeout,
-		ConnectionIdleTimeout:  idleTimeout,
-		ConnectionMax:          connectionMax,
-		ConnectionInitNumber:   connectionInitNumber,
-		ConnectionMaxIdle:      IRODSSessionConnectionMaxMin,
-		TcpBufferSize:          tcpBufferSize,
-		StartNewTransaction:    startNewTransaction,
+		ApplicationName:               applicationName,
+		ConnectionErrorTimeout:        connectionErrorTimeout,
+		ConnectionLifespan:            connectionLifespan,
+		OperationTimeout:              operationTimeout,
+		ConnectionIdleTimeout:         idleTimeout,
+		ConnectionMax:                 connectionMax,
+		ConnectionInitNumber:          connectionInitNumber,
+		ConnectionMaxIdle:             IRODSSessionConnectionMaxMin,
+		TcpBufferSize:                 tcpBufferSize,
+		StartNewTransaction:           startNewTransaction,
+		ConnectionWaitTimeout:         0,
+		ConnectionMaxPerCaller:        0,
+		ConnectionPriority:            ConnectionPriorityMetadata,
+		ConnectionMaxPerPriority:      map[ConnectionPriority]int{},
+		ConnectionValidateOnBorrow:    false,
+		ConnectionHealthCheckInterval: 0,
+		RetryPolicy:                   nil,
+		CircuitBreaker:                nil,
+		Endpoints:                     nil,
+		LoadBalancingStrategy:         LoadBalancingRoundRobin,
+		EndpointRetryInterval:         EndpointRetryIntervalDefault,
+		Dialer:                        nil,
+		ConnectionTCPKeepAlivePeriod:  0,
+		ConnectionHeartbeatInterval:   0,
+		SendRateLimiter:               nil,
+		RecvRateLimiter:               nil,
+		ParallelTransfer:              nil,
 	}
 }
 
 // NewIRODSSessionConfigWithDefault create a IRODSSessionConfig with a default settings
 func NewIRODSSessionConfigWithDefault(applicationName string) *IRODSSessionConfig {
 	return &IRODSSessionConfig{
-		ApplicationName:        applicationName,
-		ConnectionErrorTimeout: IRODSSessionConnectionErrorTimeoutDefault,
-		ConnectionLifespan:     IRODSSessionConnectionLifespanDefault,
-		OperationTimeout:       IRODSSessionTimeoutDefault,
-		ConnectionIdleTimeout:  IRODSSessionTimeoutDefault,
-		ConnectionMax:          IRODSSessionConnectionMaxDefault,
-		ConnectionInitNumber:   IRODSSessionConnectionInitNumberDefault,
-		ConnectionMaxIdle:      IRODSSessionConnectionMaxMin,
-		TcpBufferSize:          IRODSSessionTCPBufferSizeDefault,
-		StartNewTransaction:    true,
+		ApplicationName:               applicationName,
+		ConnectionErrorTimeout:        IRODSSessionConnectionErrorTimeoutDefault,
+		ConnectionLifespan:            IRODSSessionConnectionLifespanDefault,
+		OperationTimeout:              IRODSSessionTimeoutDefault,
+		ConnectionIdleTimeout:         IRODSSessionTimeoutDefault,
+		ConnectionMax:                 IRODSSessionConnectionMaxDefault,
+		ConnectionInitNumber:          IRODSSessionConnectionInitNumberDefault,
+		ConnectionMaxIdle:             IRODSSessionConnectionMaxMin,
+		TcpBufferSize:                 IRODSSessionTCPBufferSizeDefault,
+		StartNewTransaction:           true,
+		ConnectionWaitTimeout:         0,
+		ConnectionMaxPerCaller:        0,
+		ConnectionPriority:            ConnectionPriorityMetadata,
+		ConnectionMaxPerPriority:      map[ConnectionPriority]int{},
+		ConnectionValidateOnBorrow:    false,
+		ConnectionHealthCheckInterval: 0,
+		RetryPolicy:                   nil,
+		CircuitBreaker:                nil,
+		Endpoints:                     nil,
+		LoadBalancingStrategy:         LoadBalancingRoundRobin,
+		EndpointRetryInterval:         EndpointRetryIntervalDefault,
+		Dialer:                        nil,
+		ConnectionTCPKeepAlivePeriod:  0,
+		ConnectionHeartbeatInterval:   0,
+		SendRateLimiter:               nil,
+		RecvRateLimiter:               nil,
+		ParallelTransfer:              nil,
+	}
+}
+
+// GetCircuitBreakerConfig returns circuit breaker config, derived from ConnectionErrorTimeout if not set
+func (config *IRODSSessionConfig) GetCircuitBreakerConfig() *CircuitBreakerConfig {
+	if config.CircuitBreaker != nil {
+		return config.CircuitBreaker
+	}
+
+	return NewCircuitBreakerConfig(CircuitBreakerFailureThresholdDefault, CircuitBreakerSuccessThresholdDefault, config.ConnectionErrorTimeout, false)
+}
+
+// GetConnectionMaxForPriority returns max connections requests of the priority can hold
+func (config *IRODSSessionConfig) GetConnectionMaxForPriority(priority ConnectionPriority) int {
+	if maxCap, ok := config.ConnectionMaxPerPriority[priority]; ok && maxCap > 0 && maxCap < config.ConnectionMax {
+		return maxCap
+	}
+
+	return config.ConnectionMax
+}
+
+// GetEndpoints returns endpoints to connect, the account's host and port are used if no endpoints are configured
+// endpoints without a port use the account's port
+func (config *IRODSSessionConfig) GetEndpoints(account *types.IRODSAccount) []Endpoint {
+	if len(config.Endpoints) == 0 {
+		return []Endpoint{
+			{
+				Host: account.Host,
+				Port: account.Port,
+			},
+		}
+	}
+
+	endpoints := []Endpoint{}
+	for _, endpoint := range config.Endpoints {
+		if endpoint.Port <= 0 {
+			endpoint.Port = account.Port
+		}
+		endpoints = append(endpoints, endpoint)
 	}
+	return endpoints
 }
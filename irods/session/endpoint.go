@@ -0,0 +1,281 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+)
+
+const (
+	// EndpointFailureThresholdDefault is a default number of consecutive failures to mark an endpoint down
+	EndpointFailureThresholdDefault = 1
+	// EndpointRetryIntervalDefault is a default time an endpoint stays down before it is tried again
+	EndpointRetryIntervalDefault = 30 * time.Second
+)
+
+// LoadBalancingStrategy determines how an endpoint is selected for a new connection
+type LoadBalancingStrategy string
+
+const (
+	// LoadBalancingRoundRobin rotates healthy endpoints
+	LoadBalancingRoundRobin LoadBalancingStrategy = "round_robin"
+	// LoadBalancingLeastConnections selects a healthy endpoint with the fewest open connections
+	LoadBalancingLeastConnections LoadBalancingStrategy = "least_connections"
+	// LoadBalancingFailover selects the first healthy endpoint in the given order
+	LoadBalancingFailover LoadBalancingStrategy = "failover"
+)
+
+// Endpoint is a catalog service provider address
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// String returns a string representation of the endpoint
+func (endpoint Endpoint) String() string {
+	return fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
+}
+
+// EndpointStatus is a health status of an endpoint
+type EndpointStatus struct {
+	Endpoint            Endpoint
+	Healthy             bool
+	Connections         int // open connections to the endpoint
+	ConsecutiveFailures int
+	LastError           error
+	LastFailureTime     time.Time
+	ServerVersion       *types.IRODSVersion // version of the server, nil until a connection is made
+}
+
+// endpointState is a mutable state of an endpoint
+type endpointState struct {
+	endpoint            Endpoint
+	connections         int
+	consecutiveFailures int
+	lastError           error
+	lastFailureTime     time.Time
+	downUntil           time.Time
+	serverVersion       *types.IRODSVersion
+}
+
+// EndpointSelector selects endpoints for new connections and tracks their health
+type EndpointSelector struct {
+	endpoints        []*endpointState
+	strategy         LoadBalancingStrategy
+	failureThreshold int
+	retryInterval    time.Duration
+	next             int // next start index for round robin
+	mutex            sync.Mutex
+}
+
+// NewEndpointSelector creates a EndpointSelector
+// an endpoint that fails failureThreshold times in a row is skipped for retryInterval
+func NewEndpointSelector(endpoints []Endpoint, strategy LoadBalancingStrategy, failureThreshold int, retryInterval time.Duration) *EndpointSelector {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	if len(strategy) == 0 {
+		strategy = LoadBalancingRoundRobin
+	}
+
+	states := []*endpointState{}
+	for _, endpoint := range endpoints {
+		states = append(states, &endpointState{
+			endpoint: endpoint,
+		})
+	}
+
+	return &EndpointSelector{
+		endpoints:        states,
+		strategy:         strategy,
+		failureThreshold: failureThreshold,
+		retryInterval:    retryInterval,
+		next:             0,
+		mutex:            sync.Mutex{},
+	}
+}
+
+// GetStrategy returns the load balancing strategy
+func (selector *EndpointSelector) GetStrategy() LoadBalancingStrategy {
+	return selector.strategy
+}
+
+// Len returns the number of endpoints
+func (selector *EndpointSelector) Len() int {
+	return len(selector.endpoints)
+}
+
+// Candidates returns endpoints in the order they should be tried for a new connection.
+// Healthy endpoints come first in the order of the strategy, followed by endpoints marked down
+// so a connection is still attempted when all endpoints are down.
+func (selector *EndpointSelector) Candidates() []Endpoint {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	now := time.Now()
+	count := len(selector.endpoints)
+
+	healthy := []*endpointState{}
+	down := []*endpointState{}
+
+	start := 0
+	if selector.strategy == LoadBalancingRoundRobin && count > 0 {
+		start = selector.next % count
+		selector.next = (selector.next + 1) % count
+	}
+
+	for i := 0; i < count; i++ {
+		state := selector.endpoints[(start+i)%count]
+		if state.isDown(now) {
+			down = append(down, state)
+		} else {
+			healthy = append(healthy, state)
+		}
+	}
+
+	if selector.strategy == LoadBalancingLeastConnections {
+		sort.SliceStable(healthy, func(i int, j int) bool {
+			return healthy[i].connections < healthy[j].connections
+		})
+	}
+
+	// try the endpoint that went down first, it is most likely recovered
+	sort.SliceStable(down, func(i int, j int) bool {
+		return down[i].downUntil.Before(down[j].downUntil)
+	})
+
+	candidates := []Endpoint{}
+	for _, state := range healthy {
+		candidates = append(candidates, state.endpoint)
+	}
+	for _, state := range down {
+		candidates = append(candidates, state.endpoint)
+	}
+	return candidates
+}
+
+// RecordSuccess records a successful connection to the endpoint
+func (selector *EndpointSelector) RecordSuccess(endpoint Endpoint) {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	state := selector.find(endpoint)
+	if state == nil {
+		return
+	}
+
+	state.consecutiveFailures = 0
+	state.downUntil = time.Time{}
+}
+
+// RecordFailure records a failed connection to the endpoint
+func (selector *EndpointSelector) RecordFailure(endpoint Endpoint, err error) {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	state := selector.find(endpoint)
+	if state == nil {
+		return
+	}
+
+	now := time.Now()
+	state.consecutiveFailures++
+	state.lastError = err
+	state.lastFailureTime = now
+
+	if state.consecutiveFailures >= selector.failureThreshold {
+		state.downUntil = now.Add(selector.retryInterval)
+	}
+}
+
+// AddConnection increases the number of open connections to the endpoint
+func (selector *EndpointSelector) AddConnection(endpoint Endpoint) {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	state := selector.find(endpoint)
+	if state == nil {
+		return
+	}
+
+	state.connections++
+}
+
+// RemoveConnection decreases the number of open connections to the endpoint
+func (selector *EndpointSelector) RemoveConnection(endpoint Endpoint) {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	state := selector.find(endpoint)
+	if state == nil {
+		return
+	}
+
+	if state.connections > 0 {
+		state.connections--
+	}
+}
+
+// SetServerVersion records the server version of the endpoint, reported by a connection to it
+// the version is replaced only if the server is upgraded or downgraded
+func (selector *EndpointSelector) SetServerVersion(endpoint Endpoint, version *types.IRODSVersion) {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	state := selector.find(endpoint)
+	if state == nil {
+		return
+	}
+
+	if state.serverVersion == nil || state.serverVersion.ReleaseVersion != version.ReleaseVersion || state.serverVersion.APIVersion != version.APIVersion {
+		state.serverVersion = version
+	}
+}
+
+// ClearConnections clears the number of open connections of all endpoints
+func (selector *EndpointSelector) ClearConnections() {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	for _, state := range selector.endpoints {
+		state.connections = 0
+	}
+}
+
+// GetStatus returns health status of all endpoints
+func (selector *EndpointSelector) GetStatus() []EndpointStatus {
+	selector.mutex.Lock()
+	defer selector.mutex.Unlock()
+
+	now := time.Now()
+	status := []EndpointStatus{}
+	for _, state := range selector.endpoints {
+		status = append(status, EndpointStatus{
+			Endpoint:            state.endpoint,
+			Healthy:             !state.isDown(now),
+			Connections:         state.connections,
+			ConsecutiveFailures: state.consecutiveFailures,
+			LastError:           state.lastError,
+			LastFailureTime:     state.lastFailureTime,
+			ServerVersion:       state.serverVersion,
+		})
+	}
+	return status
+}
+
+func (selector *EndpointSelector) find(endpoint Endpoint) *endpointState {
+	for _, state := range selector.endpoints {
+		if state.endpoint == endpoint {
+			return state
+		}
+	}
+	return nil
+}
+
+func (state *endpointState) isDown(now time.Time) bool {
+	return !state.downUntil.IsZero() && now.Before(state.downUntil)
+}
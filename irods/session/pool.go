@@ -8,6 +8,7 @@ import (
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/metrics"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
 	"golang.org/x/xerrors"
 
 	log "github.com/sirupsen/logrus"
@@ -24,6 +25,26 @@ type ConnectionPoolConfig struct {
 	IdleTimeout      time.Duration // if there's no activity on a connection for the timeout time, the connection will die
 	OperationTimeout time.Duration // if there's no response for the timeout time, the request will fail
 	TcpBufferSize    int
+	WaitTimeout      time.Duration // how long GetWait waits for a connection when the pool is full, 0 fails immediately
+	MaxPerCaller     int           // max connections a single caller can hold, 0 means no limit
+	// MaxCapPerPriority is max connections requests of a priority can hold, absent or 0 means MaxCap
+	MaxCapPerPriority map[ConnectionPriority]int
+	// ValidateOnBorrow checks an idle connection with a no-op request before handing it out
+	ValidateOnBorrow bool
+	// HealthCheckInterval is an interval to check idle connections in background, 0 disables it
+	HealthCheckInterval time.Duration
+	// EndpointSelector selects a host for each new connection, if nil, the account's host is used
+	EndpointSelector *EndpointSelector
+	// Dialer is used to connect to servers, nil connects directly
+	Dialer connection.Dialer
+	// TCPKeepAlivePeriod is a TCP keepalive period of connections, 0 uses the OS default
+	TCPKeepAlivePeriod time.Duration
+	// HeartbeatInterval is an interval to send heartbeats on idle connections, 0 disables it
+	HeartbeatInterval time.Duration
+	// SendRateLimiter limits bytes sent by all connections, nil disables it
+	SendRateLimiter *util.RateLimiter
+	// RecvRateLimiter limits bytes received by all connections, nil disables it
+	RecvRateLimiter *util.RateLimiter
 }
 
 // ConnectionPool is a struct for connection pool
@@ -31,6 +52,12 @@ type ConnectionPool struct {
 	config              *ConnectionPoolConfig
 	idleConnections     *list.List // list of *connection.IRODSConnection
 	occupiedConnections map[*connection.IRODSConnection]bool
+	waiters             [connectionPriorityLanes]*list.List // list of *connectionWaiter, per priority lane
+	reserved            int                                 // slots granted to waiters that have not picked them up yet
+	callers             *connectionCallerTracker
+	priorities          *connectionCallerTracker // counts connections held by each priority lane
+	endpoints           *EndpointSelector
+	connectionEndpoints map[*connection.IRODSConnection]Endpoint // endpoint of each open connection
 	metrics             *metrics.IRODSMetrics
 	mutex               sync.Mutex
 	terminateChan       chan bool
@@ -39,16 +66,35 @@ type ConnectionPool struct {
 
 // NewConnectionPool creates a new ConnectionPool
 func NewConnectionPool(config *ConnectionPoolConfig, metrics *metrics.IRODSMetrics) (*ConnectionPool, error) {
+	endpoints := config.EndpointSelector
+	if endpoints == nil {
+		endpoints = NewEndpointSelector([]Endpoint{
+			{
+				Host: config.Account.Host,
+				Port: config.Account.Port,
+			},
+		}, LoadBalancingFailover, EndpointFailureThresholdDefault, 0)
+	}
+
 	pool := &ConnectionPool{
 		config:              config,
 		idleConnections:     list.New(),
 		occupiedConnections: map[*connection.IRODSConnection]bool{},
+		reserved:            0,
+		callers:             newConnectionCallerTracker(),
+		priorities:          newConnectionCallerTracker(),
+		endpoints:           endpoints,
+		connectionEndpoints: map[*connection.IRODSConnection]Endpoint{},
 		metrics:             metrics,
 		mutex:               sync.Mutex{},
 		terminateChan:       make(chan bool),
 		terminated:          false,
 	}
 
+	for i := range pool.waiters {
+		pool.waiters[i] = list.New()
+	}
+
 	err := pool.init()
 	if err != nil {
 		return nil, xerrors.Errorf("failed to init connection pool: %w", err)
@@ -78,11 +124,11 @@ func NewConnectionPool(config *ConnectionPoolConfig, metrics *metrics.IRODSMetri
 						if idleConn.GetLastSuccessfulAccess().Add(pool.config.IdleTimeout).Before(now) {
 							// timeout
 							pool.idleConnections.Remove(elem)
-							idleConn.Disconnect()
+							pool.disconnectLocked(idleConn)
 						} else if idleConn.GetCreationTime().Add(pool.config.Lifespan).Before(now) {
 							// too old
 							pool.idleConnections.Remove(elem)
-							idleConn.Disconnect()
+							pool.disconnectLocked(idleConn)
 						} else {
 							break
 						}
@@ -97,9 +143,116 @@ func NewConnectionPool(config *ConnectionPoolConfig, metrics *metrics.IRODSMetri
 		}
 	}()
 
+	if pool.config.HealthCheckInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(pool.config.HealthCheckInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-pool.terminateChan:
+					return
+				case <-ticker.C:
+					pool.checkIdleConnections()
+				}
+			}
+		}()
+	}
+
 	return pool, nil
 }
 
+// checkHealth checks if the connection is usable, broken connections are disconnected
+func (pool *ConnectionPool) checkHealth(conn *connection.IRODSConnection) bool {
+	pool.metrics.IncreaseCounterForConnectionHealthChecks(1)
+
+	conn.Lock()
+	err := conn.CheckHealth()
+	conn.Unlock()
+
+	if err != nil {
+		pool.metrics.IncreaseCounterForConnectionHealthCheckFailures(1)
+
+		if conn.IsConnected() {
+			conn.Disconnect()
+		}
+		return false
+	}
+
+	return true
+}
+
+// checkIdleConnections checks idle connections and replaces broken ones with new connections
+func (pool *ConnectionPool) checkIdleConnections() {
+	logger := log.WithFields(log.Fields{
+		"package":  "session",
+		"struct":   "ConnectionPool",
+		"function": "checkIdleConnections",
+	})
+
+	// take idle connections out so others don't get them while checking
+	pool.mutex.Lock()
+	if pool.terminated {
+		pool.mutex.Unlock()
+		return
+	}
+
+	idleConns := []*connection.IRODSConnection{}
+	for pool.idleConnections.Len() > 0 {
+		elem := pool.idleConnections.Front()
+		idleConnObj := pool.idleConnections.Remove(elem)
+		if idleConn, ok := idleConnObj.(*connection.IRODSConnection); ok {
+			idleConns = append(idleConns, idleConn)
+		}
+	}
+	pool.mutex.Unlock()
+
+	healthyConns := []*connection.IRODSConnection{}
+	brokenConns := []*connection.IRODSConnection{}
+	newEndpoints := map[*connection.IRODSConnection]Endpoint{}
+	for _, idleConn := range idleConns {
+		if pool.checkHealth(idleConn) {
+			healthyConns = append(healthyConns, idleConn)
+			continue
+		}
+
+		brokenConns = append(brokenConns, idleConn)
+		logger.Warn("idle connection failed health check, reconnecting...")
+
+		// reconnect, possibly to another endpoint
+		newConn, endpoint, err := pool.connect()
+		if err != nil {
+			pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
+			logger.WithError(err).Warn("failed to reconnect")
+			continue
+		}
+
+		newEndpoints[newConn] = endpoint
+		healthyConns = append(healthyConns, newConn)
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for _, brokenConn := range brokenConns {
+		pool.untrackLocked(brokenConn)
+	}
+
+	for newConn, endpoint := range newEndpoints {
+		pool.trackLocked(newConn, endpoint)
+	}
+
+	for _, healthyConn := range healthyConns {
+		if pool.terminated || pool.idleConnections.Len() >= pool.config.MaxIdle {
+			pool.disconnectLocked(healthyConn)
+			continue
+		}
+
+		// checked connections are more recent than the ones returned meanwhile
+		pool.idleConnections.PushFront(healthyConn)
+	}
+}
+
 // Release releases all resources
 func (pool *ConnectionPool) Release() {
 	pool.mutex.Lock()
@@ -110,7 +263,7 @@ func (pool *ConnectionPool) Release() {
 	}
 
 	pool.terminated = true
-	pool.terminateChan <- true
+	close(pool.terminateChan) // stops all background goroutines
 
 	for pool.idleConnections.Len() > 0 {
 		elem := pool.idleConnections.Front()
@@ -130,25 +283,39 @@ func (pool *ConnectionPool) Release() {
 
 	// clear
 	pool.occupiedConnections = map[*connection.IRODSConnection]bool{}
+	pool.connectionEndpoints = map[*connection.IRODSConnection]Endpoint{}
+	pool.endpoints.ClearConnections()
+	pool.callers.clear()
+	pool.priorities.clear()
+
+	// wake up waiters, they will see the pool is terminated
+	for _, waiters := range pool.waiters {
+		for waiters.Len() > 0 {
+			elem := waiters.Front()
+			waiterObj := waiters.Remove(elem)
+			if waiter, ok := waiterObj.(*connectionWaiter); ok {
+				close(waiter.ready)
+			}
+		}
+	}
+	pool.reserved = 0
 
 	pool.metrics.ClearConnections()
 }
 
 func (pool *ConnectionPool) init() error {
-	pool.mutex.Lock()
-	defer pool.mutex.Unlock()
-
-	// create connections
+	// create connections, dial without the lock as failover may try several endpoints
 	for i := 0; i < pool.config.InitialCap; i++ {
-		newConn := connection.NewIRODSConnectionWithMetrics(pool.config.Account, pool.config.OperationTimeout, pool.config.ApplicationName, pool.metrics)
-		newConn.SetTCPBufferSize(pool.config.TcpBufferSize)
-		err := newConn.Connect()
+		newConn, endpoint, err := pool.connect()
 		if err != nil {
 			pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
 			return xerrors.Errorf("failed to connect to irods server: %w", err)
 		}
 
+		pool.mutex.Lock()
+		pool.trackLocked(newConn, endpoint)
 		pool.idleConnections.PushBack(newConn)
+		pool.mutex.Unlock()
 	}
 
 	return nil
@@ -157,49 +324,222 @@ func (pool *ConnectionPool) init() error {
 // Get gets a new or an idle connection out of the pool
 // the boolean return value indicates if the returned conneciton is new (True) or existing idle (False)
 func (pool *ConnectionPool) Get() (*connection.IRODSConnection, bool, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.usedSlots() >= pool.config.MaxCap {
+		return nil, false, types.NewConnectionPoolFullError(pool.usedSlots(), pool.config.MaxCap)
+	}
+
+	return pool.getLocked()
+}
+
+// GetWithPriority gets a new or an idle connection out of the pool for a request of the priority,
+// it fails immediately if the pool is full or the priority has reached its limit
+func (pool *ConnectionPool) GetWithPriority(priority ConnectionPriority) (*connection.IRODSConnection, bool, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	request := NewConnectionRequest(priority, "")
+	if !pool.canGrantLocked(request) {
+		return nil, false, types.NewConnectionPoolFullError(pool.usedSlots(), pool.config.MaxCap)
+	}
+
+	pool.reserveLocked(request)
+	return pool.getForRequestLocked(request)
+}
+
+// GetWait gets a new or an idle connection out of the pool, waiting in a FIFO queue of the request's priority lane
+// if the pool is full or the caller has reached its limit. Metadata requests are served before I/O requests.
+// It returns ConnectionPoolFullError if no connection becomes available before the deadline.
+func (pool *ConnectionPool) GetWait(request *ConnectionRequest) (*connection.IRODSConnection, bool, error) {
 	logger := log.WithFields(log.Fields{
 		"package":  "session",
 		"struct":   "ConnectionPool",
-		"function": "Get",
+		"function": "GetWait",
 	})
 
+	if request == nil {
+		request = NewConnectionRequest(ConnectionPriorityMetadata, "")
+	}
+
+	pool.mutex.Lock()
+
+	if pool.terminated {
+		pool.mutex.Unlock()
+		return nil, false, xerrors.Errorf("failed to get a connection, the pool is already released")
+	}
+
+	if !pool.hasWaitersAheadLocked(request.Priority) && pool.canGrantLocked(request) {
+		defer pool.mutex.Unlock()
+
+		pool.reserveLocked(request)
+		return pool.getForRequestLocked(request)
+	}
+
+	deadline := request.Deadline
+	if deadline.IsZero() {
+		deadline = time.Now().Add(pool.config.WaitTimeout)
+	}
+
+	waitStart := time.Now()
+	if !deadline.After(waitStart) {
+		pool.mutex.Unlock()
+		pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
+		return nil, false, types.NewConnectionPoolFullError(pool.usedSlots(), pool.config.MaxCap)
+	}
+
+	// enqueue
+	waiter := newConnectionWaiter(request)
+	lane := pool.getLane(request.Priority)
+	elem := lane.PushBack(waiter)
+
+	pool.metrics.IncreaseCounterForConnectionPoolWaits(1)
+	pool.metrics.IncreaseConnectionPoolWaiters(1)
+
+	logger.Debugf("Waiting for a connection in %s lane", request.Priority.String())
+
+	pool.mutex.Unlock()
+
+	timer := time.NewTimer(deadline.Sub(waitStart))
+	defer timer.Stop()
+
+	select {
+	case <-waiter.ready:
+	case <-timer.C:
+	}
+
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	if len(pool.occupiedConnections) >= pool.config.MaxCap {
-		return nil, false, types.NewConnectionPoolFullError(len(pool.occupiedConnections), pool.config.MaxCap)
+	pool.metrics.IncreaseConnectionPoolWaitTime(time.Since(waitStart))
+
+	if !waiter.granted {
+		pool.metrics.DecreaseConnectionPoolWaiters(1)
+
+		if pool.terminated {
+			return nil, false, xerrors.Errorf("failed to get a connection, the pool is already released")
+		}
+
+		// timed out
+		lane.Remove(elem)
+		pool.metrics.IncreaseCounterForConnectionPoolWaitTimeouts(1)
+		pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
+
+		// requests queued behind may be eligible now
+		pool.dispatchLocked()
+
+		return nil, false, xerrors.Errorf("failed to get a connection in %s: %w", time.Since(waitStart).String(), types.NewConnectionPoolFullError(pool.usedSlots(), pool.config.MaxCap))
 	}
 
-	var err error
+	if pool.terminated {
+		return nil, false, xerrors.Errorf("failed to get a connection, the pool is already released")
+	}
+
+	// granted, the slot and the counts of the caller and the priority are reserved for us.
+	// the slot is handed over to the connection in getLocked without releasing the lock,
+	// the counts stay reserved until the connection is returned
+	pool.reserved--
+
+	conn, isNew, err := pool.getForRequestLocked(request)
+	if err != nil {
+		// give the slot to the next waiter
+		pool.dispatchLocked()
+		return nil, false, err
+	}
+
+	return conn, isNew, nil
+}
+
+// reserveLocked reserves the counts of the request's caller and priority, so requests of the caller or the priority
+// cannot exceed their limits while a connection is being created
+func (pool *ConnectionPool) reserveLocked(request *ConnectionRequest) {
+	pool.callers.reserve(request.CallerID)
+	pool.priorities.reserve(request.Priority.String())
+}
+
+// getForRequestLocked gets a connection and assigns it to the request's caller and priority,
+// their counts must be reserved. The reservations are canceled on failure.
+func (pool *ConnectionPool) getForRequestLocked(request *ConnectionRequest) (*connection.IRODSConnection, bool, error) {
+	conn, isNew, err := pool.getLocked()
+	if err != nil {
+		if !pool.terminated {
+			// reservations are cleared on release
+			pool.callers.unreserve(request.CallerID)
+			pool.priorities.unreserve(request.Priority.String())
+		}
+		return nil, false, err
+	}
+
+	pool.callers.assign(conn, request.CallerID)
+	pool.priorities.assign(conn, request.Priority.String())
+	return conn, isNew, nil
+}
+
+// getLocked gets a new or an idle connection, capacity must be checked by caller
+// the lock is released while an idle connection is validated or a new connection is created,
+// its slot is kept meanwhile
+func (pool *ConnectionPool) getLocked() (*connection.IRODSConnection, bool, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "session",
+		"struct":   "ConnectionPool",
+		"function": "getLocked",
+	})
+
 	// check if there's idle connection
-	if pool.idleConnections.Len() > 0 {
+	for pool.idleConnections.Len() > 0 {
 		// there's idle connection
 		// LIFO
 		elem := pool.idleConnections.Back()
-		if elem != nil {
-			idleConnObj := pool.idleConnections.Remove(elem)
-			if idleConn, ok := idleConnObj.(*connection.IRODSConnection); ok {
-				if idleConn.IsConnected() {
-					// move to occupied connections
-					pool.occupiedConnections[idleConn] = true
-					logger.Debug("Reuse an idle connection")
+		if elem == nil {
+			break
+		}
+
+		idleConnObj := pool.idleConnections.Remove(elem)
+		if idleConn, ok := idleConnObj.(*connection.IRODSConnection); ok {
+			if !idleConn.IsConnected() {
+				logger.Warn("failed to reuse an idle connection because it is already disconnected. discarding...")
+				pool.untrackLocked(idleConn)
+				continue
+			}
+
+			if pool.config.ValidateOnBorrow {
+				// a health check is a round trip to the server, do not block others meanwhile
+				pool.occupiedConnections[idleConn] = true
+				pool.mutex.Unlock()
+
+				healthy := pool.checkHealth(idleConn)
+
+				pool.mutex.Lock()
+				delete(pool.occupiedConnections, idleConn)
 
-					pool.metrics.IncreaseConnectionsOccupied(1)
-					return idleConn, false, nil
+				if pool.terminated {
+					if idleConn.IsConnected() {
+						idleConn.Disconnect()
+					}
+					return nil, false, xerrors.Errorf("failed to get a connection, the pool is already released")
 				}
 
-				logger.Warn("failed to reuse an idle connection because it is already disconnected. discarding...")
+				if !healthy {
+					logger.Warn("failed to reuse an idle connection because it failed health check. discarding...")
+					pool.untrackLocked(idleConn)
+					continue
+				}
 			}
+
+			// move to occupied connections
+			pool.occupiedConnections[idleConn] = true
+			logger.Debug("Reuse an idle connection")
+
+			pool.metrics.IncreaseConnectionsOccupied(1)
+			return idleConn, false, nil
 		}
 	}
 
 	// create a new if not exists
-	newConn := connection.NewIRODSConnectionWithMetrics(pool.config.Account, pool.config.OperationTimeout, pool.config.ApplicationName, pool.metrics)
-	newConn.SetTCPBufferSize(pool.config.TcpBufferSize)
-	err = newConn.Connect()
+	newConn, err := pool.dialLocked()
 	if err != nil {
-		pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
-		return nil, false, xerrors.Errorf("failed to connect to irods server: %w", err)
+		return nil, false, err
 	}
 
 	pool.occupiedConnections[newConn] = true
@@ -220,8 +560,8 @@ func (pool *ConnectionPool) GetNew() (*connection.IRODSConnection, error) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	if len(pool.occupiedConnections) >= pool.config.MaxCap {
-		return nil, types.NewConnectionPoolFullError(len(pool.occupiedConnections), pool.config.MaxCap)
+	if pool.usedSlots() >= pool.config.MaxCap {
+		return nil, types.NewConnectionPoolFullError(pool.usedSlots(), pool.config.MaxCap)
 	}
 
 	// full - close an idle connection and create a new one
@@ -231,22 +571,17 @@ func (pool *ConnectionPool) GetNew() (*connection.IRODSConnection, error) {
 		if elem != nil {
 			idleConnObj := pool.idleConnections.Remove(elem)
 			if idleConn, ok := idleConnObj.(*connection.IRODSConnection); ok {
-				if idleConn.IsConnected() {
-					idleConn.Disconnect()
-				}
+				pool.disconnectLocked(idleConn)
 			}
 		}
 	}
 
 	// create a new one
-	if len(pool.occupiedConnections)+pool.idleConnections.Len() < pool.config.MaxCap {
+	if pool.usedSlots()+pool.idleConnections.Len() < pool.config.MaxCap {
 		// create a new one
-		newConn := connection.NewIRODSConnection(pool.config.Account, pool.config.OperationTimeout, pool.config.ApplicationName)
-		newConn.SetTCPBufferSize(pool.config.TcpBufferSize)
-		err := newConn.Connect()
+		newConn, err := pool.dialLocked()
 		if err != nil {
-			pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
-			return nil, xerrors.Errorf("failed to connect to irods server: %w", err)
+			return nil, err
 		}
 
 		pool.occupiedConnections[newConn] = true
@@ -274,7 +609,12 @@ func (pool *ConnectionPool) Return(conn *connection.IRODSConnection) error {
 	if _, ok := pool.occupiedConnections[conn]; ok {
 		// delete
 		delete(pool.occupiedConnections, conn)
+		pool.callers.release(conn)
+		pool.priorities.release(conn)
 		pool.metrics.DecreaseConnectionsOccupied(1)
+
+		// hand the freed slot to a waiter
+		defer pool.dispatchLocked()
 	} else {
 		// cannot find it from occupied map
 		return xerrors.Errorf("failed to find the connection from occupied connection list")
@@ -282,13 +622,14 @@ func (pool *ConnectionPool) Return(conn *connection.IRODSConnection) error {
 
 	if !conn.IsConnected() {
 		logger.Warn("failed to return the connection because it is already closed. discarding...")
+		pool.untrackLocked(conn)
 		return nil
 	}
 
 	// do not return if the connection is too old
 	now := time.Now()
 	if conn.GetCreationTime().Add(pool.config.Lifespan).Before(now) {
-		conn.Disconnect()
+		pool.disconnectLocked(conn)
 		logger.Debug("Returning and destroying an old connection")
 		return nil
 	}
@@ -302,7 +643,7 @@ func (pool *ConnectionPool) Return(conn *connection.IRODSConnection) error {
 		if elem != nil {
 			idleConnObj := pool.idleConnections.Remove(elem)
 			if idleConn, ok := idleConnObj.(*connection.IRODSConnection); ok {
-				idleConn.Disconnect()
+				pool.disconnectLocked(idleConn)
 			}
 		}
 	}
@@ -319,9 +660,150 @@ func (pool *ConnectionPool) Discard(conn *connection.IRODSConnection) {
 
 	// find it from occupied map
 	delete(pool.occupiedConnections, conn)
+	pool.callers.release(conn)
+	pool.priorities.release(conn)
 
 	pool.metrics.DecreaseConnectionsOccupied(1)
 
+	pool.disconnectLocked(conn)
+
+	// hand the freed slot to a waiter
+	pool.dispatchLocked()
+}
+
+// NewUnmanagedConnection creates a new connection that is not managed by the pool.
+// It connects to the account's host only, other endpoints are not tried, endpoint health and
+// connection metrics are not updated.
+func (pool *ConnectionPool) NewUnmanagedConnection() (*connection.IRODSConnection, error) {
+	newConn := pool.newConnection(pool.config.Account, nil)
+	err := newConn.Connect()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to connect to irods server: %w", err)
+	}
+
+	return newConn, nil
+}
+
+// GetEndpointSelector returns the endpoint selector
+func (pool *ConnectionPool) GetEndpointSelector() *EndpointSelector {
+	return pool.endpoints
+}
+
+// GetEndpointStatus returns health status of endpoints
+func (pool *ConnectionPool) GetEndpointStatus() []EndpointStatus {
+	return pool.endpoints.GetStatus()
+}
+
+// connect creates a new connection, trying endpoints in the order of the load balancing strategy
+// until one succeeds. It does not require the pool lock.
+func (pool *ConnectionPool) connect() (*connection.IRODSConnection, Endpoint, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "session",
+		"struct":   "ConnectionPool",
+		"function": "connect",
+	})
+
+	var lastErr error
+	for _, endpoint := range pool.endpoints.Candidates() {
+		account := *pool.config.Account
+		account.Host = endpoint.Host
+		account.Port = endpoint.Port
+
+		newConn := pool.newConnection(&account, pool.metrics)
+		err := newConn.Connect()
+		if err != nil {
+			pool.endpoints.RecordFailure(endpoint, err)
+			lastErr = err
+
+			if types.IsPermanantFailure(err) {
+				// other endpoints will fail the same way
+				break
+			}
+
+			if pool.endpoints.Len() > 1 {
+				logger.WithError(err).Warnf("failed to connect to %s, trying next endpoint", endpoint.String())
+			}
+			continue
+		}
+
+		pool.endpoints.RecordSuccess(endpoint)
+		return newConn, endpoint, nil
+	}
+
+	if lastErr == nil {
+		lastErr = xerrors.Errorf("no endpoints are configured")
+	}
+
+	return nil, Endpoint{}, lastErr
+}
+
+// newConnection creates a connection to the account's host with the pool's connection settings,
+// connMetrics can be nil for connections that are not counted
+func (pool *ConnectionPool) newConnection(account *types.IRODSAccount, connMetrics *metrics.IRODSMetrics) *connection.IRODSConnection {
+	newConn := connection.NewIRODSConnectionWithMetrics(account, pool.config.OperationTimeout, pool.config.ApplicationName, connMetrics)
+	newConn.SetTCPBufferSize(pool.config.TcpBufferSize)
+	newConn.SetDialer(pool.config.Dialer)
+	newConn.SetTCPKeepAlivePeriod(pool.config.TCPKeepAlivePeriod)
+	newConn.SetHeartbeatInterval(pool.config.HeartbeatInterval)
+	newConn.SetRateLimiters(pool.config.SendRateLimiter, pool.config.RecvRateLimiter)
+	return newConn
+}
+
+// dialLocked creates a new connection and tracks it, the lock is released while dialing
+// as failover may try several endpoints. A slot is reserved meanwhile.
+func (pool *ConnectionPool) dialLocked() (*connection.IRODSConnection, error) {
+	pool.reserved++
+	pool.mutex.Unlock()
+
+	newConn, endpoint, err := pool.connect()
+
+	pool.mutex.Lock()
+
+	if pool.terminated {
+		// reservations are cleared on release
+		if err == nil {
+			newConn.Disconnect()
+		}
+		return nil, xerrors.Errorf("failed to get a connection, the pool is already released")
+	}
+
+	pool.reserved--
+
+	if err != nil {
+		pool.metrics.IncreaseCounterForConnectionPoolFailures(1)
+
+		// hand the slot to a waiter
+		pool.dispatchLocked()
+		return nil, xerrors.Errorf("failed to connect to irods server: %w", err)
+	}
+
+	pool.trackLocked(newConn, endpoint)
+	return newConn, nil
+}
+
+// trackLocked records the endpoint of a new connection and the server version of the endpoint
+func (pool *ConnectionPool) trackLocked(conn *connection.IRODSConnection, endpoint Endpoint) {
+	pool.connectionEndpoints[conn] = endpoint
+	pool.endpoints.AddConnection(endpoint)
+
+	// endpoints may run different server versions
+	if version := conn.GetVersion(); version != nil {
+		pool.endpoints.SetServerVersion(endpoint, version)
+	}
+}
+
+// untrackLocked forgets the endpoint of a closed connection
+func (pool *ConnectionPool) untrackLocked(conn *connection.IRODSConnection) {
+	if endpoint, ok := pool.connectionEndpoints[conn]; ok {
+		delete(pool.connectionEndpoints, conn)
+		pool.endpoints.RemoveConnection(endpoint)
+	}
+}
+
+// disconnectLocked disconnects the connection and forgets its endpoint
+func (pool *ConnectionPool) disconnectLocked(conn *connection.IRODSConnection) {
+	pool.untrackLocked(conn)
+
 	if conn.IsConnected() {
 		conn.Disconnect()
 	}
@@ -356,5 +838,95 @@ func (pool *ConnectionPool) AvailableConnections() int {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	return pool.config.MaxCap - len(pool.occupiedConnections)
+	return pool.config.MaxCap - pool.usedSlots()
+}
+
+// WaitingRequests returns the number of requests waiting for connections
+func (pool *ConnectionPool) WaitingRequests() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	waiting := 0
+	for _, waiters := range pool.waiters {
+		waiting += waiters.Len()
+	}
+	return waiting
+}
+
+// usedSlots returns the number of occupied connections including slots reserved for waiters
+func (pool *ConnectionPool) usedSlots() int {
+	return len(pool.occupiedConnections) + pool.reserved
+}
+
+func (pool *ConnectionPool) getLane(priority ConnectionPriority) *list.List {
+	if priority < 0 || int(priority) >= connectionPriorityLanes {
+		return pool.waiters[ConnectionPriorityIO]
+	}
+	return pool.waiters[priority]
+}
+
+// hasWaitersAheadLocked returns true if there are waiters that must be served before the given priority
+func (pool *ConnectionPool) hasWaitersAheadLocked(priority ConnectionPriority) bool {
+	for p := ConnectionPriorityMetadata; p <= priority && int(p) < connectionPriorityLanes; p++ {
+		if pool.waiters[p].Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// canGrantLocked returns true if the request can take a connection now
+func (pool *ConnectionPool) canGrantLocked(request *ConnectionRequest) bool {
+	if pool.usedSlots() >= pool.config.MaxCap {
+		return false
+	}
+
+	if pool.config.MaxPerCaller > 0 && len(request.CallerID) > 0 {
+		if pool.callers.count(request.CallerID) >= pool.config.MaxPerCaller {
+			return false
+		}
+	}
+
+	if maxCap, ok := pool.config.MaxCapPerPriority[request.Priority]; ok && maxCap > 0 {
+		if pool.priorities.count(request.Priority.String()) >= maxCap {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dispatchLocked grants free slots to waiters, metadata lane first, FIFO within a lane.
+// waiters whose caller has reached the per-caller limit are skipped.
+func (pool *ConnectionPool) dispatchLocked() {
+	if pool.terminated {
+		return
+	}
+
+	for _, waiters := range pool.waiters {
+		elem := waiters.Front()
+		for elem != nil {
+			if pool.usedSlots() >= pool.config.MaxCap {
+				return
+			}
+
+			next := elem.Next()
+			if waiter, ok := elem.Value.(*connectionWaiter); ok {
+				if pool.canGrantLocked(waiter.request) {
+					waiters.Remove(elem)
+
+					// reserve the slot until the waiter picks it up, and the counts until the connection is returned
+					pool.reserved++
+					pool.reserveLocked(waiter.request)
+					waiter.granted = true
+					pool.metrics.DecreaseConnectionPoolWaiters(1)
+					close(waiter.ready)
+				}
+			} else {
+				// unknown object, remove it
+				waiters.Remove(elem)
+			}
+			elem = next
+		}
+	}
 }
@@ -0,0 +1,125 @@
+package session
+
+import (
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+)
+
+// ConnectionPriority determines the lane a connection request waits in when the pool is exhausted
+// lanes only order requests waiting for the same pool. FileSystem keeps metadata and I/O connections
+// in separate pools, so metadata operations never wait behind transfers there.
+type ConnectionPriority int
+
+const (
+	// ConnectionPriorityMetadata is for short metadata operations (stat, list, query), served first
+	ConnectionPriorityMetadata ConnectionPriority = iota
+	// ConnectionPriorityIO is for bulk data transfer
+	ConnectionPriorityIO
+)
+
+// connectionPriorityLanes is the number of priority lanes
+const connectionPriorityLanes = 2
+
+// String returns a string representation of the priority
+func (priority ConnectionPriority) String() string {
+	switch priority {
+	case ConnectionPriorityMetadata:
+		return "metadata"
+	case ConnectionPriorityIO:
+		return "io"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnectionRequest describes a request for a pooled connection
+type ConnectionRequest struct {
+	Priority ConnectionPriority
+	// CallerID identifies the caller for per-caller limits, empty means no limit is applied
+	CallerID string
+	// Deadline is the time to give up waiting, zero means the pool's wait timeout is used
+	Deadline time.Time
+}
+
+// NewConnectionRequest creates a ConnectionRequest
+func NewConnectionRequest(priority ConnectionPriority, callerID string) *ConnectionRequest {
+	return &ConnectionRequest{
+		Priority: priority,
+		CallerID: callerID,
+		Deadline: time.Time{},
+	}
+}
+
+// connectionWaiter is a request waiting in a pool lane
+type connectionWaiter struct {
+	request *ConnectionRequest
+	ready   chan bool // closed when a slot is granted or the pool is released
+	granted bool
+}
+
+func newConnectionWaiter(request *ConnectionRequest) *connectionWaiter {
+	return &connectionWaiter{
+		request: request,
+		ready:   make(chan bool),
+		granted: false,
+	}
+}
+
+// connectionCallerTracker counts connections held by each caller
+type connectionCallerTracker struct {
+	connectionCallers map[*connection.IRODSConnection]string
+	callerConnections map[string]int
+}
+
+func newConnectionCallerTracker() *connectionCallerTracker {
+	return &connectionCallerTracker{
+		connectionCallers: map[*connection.IRODSConnection]string{},
+		callerConnections: map[string]int{},
+	}
+}
+
+func (tracker *connectionCallerTracker) count(callerID string) int {
+	return tracker.callerConnections[callerID]
+}
+
+func (tracker *connectionCallerTracker) reserve(callerID string) {
+	if len(callerID) == 0 {
+		return
+	}
+
+	tracker.callerConnections[callerID]++
+}
+
+func (tracker *connectionCallerTracker) unreserve(callerID string) {
+	if len(callerID) == 0 {
+		return
+	}
+
+	count := tracker.callerConnections[callerID] - 1
+	if count <= 0 {
+		delete(tracker.callerConnections, callerID)
+	} else {
+		tracker.callerConnections[callerID] = count
+	}
+}
+
+func (tracker *connectionCallerTracker) assign(conn *connection.IRODSConnection, callerID string) {
+	if len(callerID) == 0 {
+		return
+	}
+
+	tracker.connectionCallers[conn] = callerID
+}
+
+func (tracker *connectionCallerTracker) release(conn *connection.IRODSConnection) {
+	if callerID, ok := tracker.connectionCallers[conn]; ok {
+		delete(tracker.connectionCallers, conn)
+		tracker.unreserve(callerID)
+	}
+}
+
+func (tracker *connectionCallerTracker) clear() {
+	tracker.connectionCallers = map[*connection.IRODSConnection]string{}
+	tracker.callerConnections = map[string]int{}
+}
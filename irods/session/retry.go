@@ -0,0 +1,187 @@
+package session
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+)
+
+const (
+	// RetryMaxAttemptsDefault is a default value of max attempts, including the first attempt
+	RetryMaxAttemptsDefault = 2
+	// RetryInitialBackoffDefault is a default value of backoff before the first retry
+	RetryInitialBackoffDefault = 100 * time.Millisecond
+	// RetryMaxBackoffDefault is a default value of max backoff
+	RetryMaxBackoffDefault = 5 * time.Second
+	// RetryBackoffMultiplierDefault is a default value of backoff multiplier
+	RetryBackoffMultiplierDefault = 2.0
+	// RetryJitterDefault is a default value of jitter, ratio of backoff randomized
+	RetryJitterDefault = 0.2
+)
+
+// RetryClassifier determines if the given error is transient, so the operation can be retried
+type RetryClassifier func(err error) bool
+
+// RetryHook is called before an operation is retried
+// attempt is the number of the attempt that failed, starting from 1
+type RetryHook func(attempt int, err error, backoff time.Duration)
+
+// RetryPolicy defines how idempotent operations are retried
+type RetryPolicy struct {
+	MaxAttempts       int           // total number of attempts, 1 or less disables retry
+	InitialBackoff    time.Duration // backoff before the first retry
+	MaxBackoff        time.Duration // backoff never grows beyond this
+	BackoffMultiplier float64       // backoff grows by this factor after each retry
+	Jitter            float64       // ratio of backoff randomized, between 0 and 1
+	Classifier        RetryClassifier
+	Hooks             []RetryHook
+}
+
+// NewRetryPolicy creates a RetryPolicy
+func NewRetryPolicy(maxAttempts int, initialBackoff time.Duration, maxBackoff time.Duration, backoffMultiplier float64, jitter float64) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       maxAttempts,
+		InitialBackoff:    initialBackoff,
+		MaxBackoff:        maxBackoff,
+		BackoffMultiplier: backoffMultiplier,
+		Jitter:            jitter,
+		Classifier:        IsRetryableError,
+		Hooks:             []RetryHook{},
+	}
+}
+
+// NewRetryPolicyWithDefault creates a RetryPolicy with a default settings
+func NewRetryPolicyWithDefault() *RetryPolicy {
+	return NewRetryPolicy(RetryMaxAttemptsDefault, RetryInitialBackoffDefault, RetryMaxBackoffDefault, RetryBackoffMultiplierDefault, RetryJitterDefault)
+}
+
+// NewNoRetryPolicy creates a RetryPolicy that never retries
+func NewNoRetryPolicy() *RetryPolicy {
+	return NewRetryPolicy(1, 0, 0, 1.0, 0)
+}
+
+// AddHook adds a hook that is called before retry
+func (policy *RetryPolicy) AddHook(hook RetryHook) {
+	policy.Hooks = append(policy.Hooks, hook)
+}
+
+// IsRetryable returns true if the error can be retried
+func (policy *RetryPolicy) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if policy.Classifier != nil {
+		return policy.Classifier(err)
+	}
+
+	return IsRetryableError(err)
+}
+
+// GetBackoff returns backoff to wait after the given attempt failed, attempt starts from 1
+func (policy *RetryPolicy) GetBackoff(attempt int) time.Duration {
+	if attempt < 1 || policy.InitialBackoff <= 0 {
+		return 0
+	}
+
+	multiplier := policy.BackoffMultiplier
+	if multiplier < 1.0 {
+		multiplier = 1.0
+	}
+
+	backoff := float64(policy.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+		if policy.MaxBackoff > 0 && backoff >= float64(policy.MaxBackoff) {
+			backoff = float64(policy.MaxBackoff)
+			break
+		}
+	}
+
+	if policy.Jitter > 0 {
+		jitter := policy.Jitter
+		if jitter > 1.0 {
+			jitter = 1.0
+		}
+
+		// randomize in range [backoff * (1 - jitter), backoff * (1 + jitter)]
+		backoff = backoff * (1.0 - jitter + (2.0 * jitter * rand.Float64()))
+	}
+
+	if policy.MaxBackoff > 0 && backoff > float64(policy.MaxBackoff) {
+		backoff = float64(policy.MaxBackoff)
+	}
+
+	return time.Duration(backoff)
+}
+
+// Execute runs the operation, retrying it while it fails with a retryable error
+// attempt passed to the operation starts from 1
+func (policy *RetryPolicy) Execute(operation func(attempt int) error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = operation(attempt)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts || !policy.IsRetryable(err) {
+			return err
+		}
+
+		backoff := policy.GetBackoff(attempt)
+		for _, hook := range policy.Hooks {
+			hook(attempt, err, backoff)
+		}
+
+		if backoff > 0 {
+			time.Sleep(backoff)
+		}
+	}
+
+	return err
+}
+
+// retryableErrorCodes are iRODS errors caused by transient network or catalog failures
+var retryableErrorCodes = map[common.ErrorCode]bool{
+	common.SYS_HEADER_READ_LEN_ERR:    true,
+	common.SYS_HEADER_WRITE_LEN_ERR:   true,
+	common.SYS_SOCK_READ_ERR:          true,
+	common.SYS_SOCK_READ_TIMEDOUT:     true,
+	common.SYS_SOCK_WRITE_ERR:         true,
+	common.SYS_SOCK_CONNECT_ERR:       true,
+	common.SYS_SOCK_SELECT_ERR:        true,
+	common.SYS_AGENT_INIT_ERR:         true,
+	common.USER_SOCK_OPEN_ERR:         true,
+	common.USER_SOCK_CONNECT_ERR:      true,
+	common.USER_SOCK_CONNECT_TIMEDOUT: true,
+	common.CAT_SQL_ERR:                true, // includes catalog deadlocks
+}
+
+// IsRetryableError is a default RetryClassifier
+// connection errors and iRODS errors caused by transient network or catalog failures are retryable
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if types.IsConnectionError(err) {
+		return true
+	}
+
+	code := types.GetIRODSErrorCode(err)
+	if code == 0 {
+		return false
+	}
+
+	// iRODS appends errno to error codes, e.g., -4104 is SYS_HEADER_READ_LEN_ERR with ECONNRESET
+	mainCode, _ := common.SplitIRODSErrorCode(code)
+	return retryableErrorCodes[mainCode]
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/metrics"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 )
@@ -19,6 +20,7 @@ type IRODSSession struct {
 	account                   *types.IRODSAccount
 	config                    *IRODSSessionConfig
 	connectionPool            *ConnectionPool
+	sharingConnectionPool     bool // the pool is owned and released by another session
 	sharedConnections         map[*connection.IRODSConnection]int
 	startNewTransaction       bool
 	commitFail                bool
@@ -26,12 +28,15 @@ type IRODSSession struct {
 	transactionFailureHandler TransactionFailureHandler
 	addressResolver           AddressResolver
 
-	lastConnectionError     error
-	lastConnectionErrorTime time.Time
+	circuitBreaker          *CircuitBreaker
+	circuitBreakerTerminate chan bool
+	circuitNotifications    []*circuitNotification
 
 	supportParallelUpload    bool
 	supportParallelUploadSet bool
 
+	parallelTransferTuner *util.ParallelTransferTuner
+
 	metrics metrics.IRODSMetrics
 	mutex   sync.Mutex
 }
@@ -43,10 +48,78 @@ func NewIRODSSession(account *types.IRODSAccount, config *IRODSSessionConfig) (*
 
 // NewIRODSSessionWithAddressResolver create a IRODSSession
 func NewIRODSSessionWithAddressResolver(account *types.IRODSAccount, config *IRODSSessionConfig, addressResolver AddressResolver) (*IRODSSession, error) {
-	sess := IRODSSession{
-		account:           account,
-		config:            config,
-		sharedConnections: map[*connection.IRODSConnection]int{},
+	sess := newIRODSSession(account, config, addressResolver)
+
+	// resolve host address
+	poolAccount := *account
+	if addressResolver != nil {
+		poolAccount.Host = addressResolver(poolAccount.Host)
+	}
+
+	endpoints := config.GetEndpoints(account)
+	if addressResolver != nil {
+		for i := range endpoints {
+			endpoints[i].Host = addressResolver(endpoints[i].Host)
+		}
+	}
+
+	poolConfig := ConnectionPoolConfig{
+		Account:             &poolAccount,
+		ApplicationName:     config.ApplicationName,
+		InitialCap:          config.ConnectionInitNumber,
+		MaxIdle:             config.ConnectionMaxIdle,
+		MaxCap:              config.ConnectionMax,
+		Lifespan:            config.ConnectionLifespan,
+		IdleTimeout:         config.ConnectionIdleTimeout,
+		OperationTimeout:    config.OperationTimeout,
+		TcpBufferSize:       config.TcpBufferSize,
+		WaitTimeout:         config.ConnectionWaitTimeout,
+		MaxPerCaller:        config.ConnectionMaxPerCaller,
+		MaxCapPerPriority:   config.ConnectionMaxPerPriority,
+		ValidateOnBorrow:    config.ConnectionValidateOnBorrow,
+		HealthCheckInterval: config.ConnectionHealthCheckInterval,
+		EndpointSelector:    NewEndpointSelector(endpoints, config.LoadBalancingStrategy, EndpointFailureThresholdDefault, config.EndpointRetryInterval),
+		Dialer:              config.Dialer,
+		TCPKeepAlivePeriod:  config.ConnectionTCPKeepAlivePeriod,
+		HeartbeatInterval:   config.ConnectionHeartbeatInterval,
+		SendRateLimiter:     config.SendRateLimiter,
+		RecvRateLimiter:     config.RecvRateLimiter,
+	}
+
+	pool, err := NewConnectionPool(&poolConfig, &sess.metrics)
+	if err != nil {
+		sess.circuitBreaker.RecordFailure(err)
+
+		return nil, xerrors.Errorf("failed to create connection pool: %w", err)
+	}
+	sess.connectionPool = pool
+
+	sess.start()
+
+	return sess, nil
+}
+
+// NewIRODSSessionSharingConnectionPool creates a IRODSSession that acquires connections from the pool of poolSession,
+// so requests of both sessions compete in the priority lanes of the pool.
+// Pool settings in the config are ignored, the pool is released by poolSession.
+func NewIRODSSessionSharingConnectionPool(account *types.IRODSAccount, config *IRODSSessionConfig, poolSession *IRODSSession) (*IRODSSession, error) {
+	sess := newIRODSSession(account, config, poolSession.addressResolver)
+	sess.connectionPool = poolSession.connectionPool
+	sess.sharingConnectionPool = true
+
+	sess.start()
+
+	return sess, nil
+}
+
+// newIRODSSession creates a IRODSSession without a connection pool
+func newIRODSSession(account *types.IRODSAccount, config *IRODSSessionConfig, addressResolver AddressResolver) *IRODSSession {
+	return &IRODSSession{
+		account:               account,
+		config:                config,
+		connectionPool:        nil,
+		sharingConnectionPool: false,
+		sharedConnections:     map[*connection.IRODSConnection]int{},
 
 		// transaction
 		startNewTransaction:       config.StartNewTransaction,
@@ -55,43 +128,27 @@ func NewIRODSSessionWithAddressResolver(account *types.IRODSAccount, config *IRO
 		transactionFailureHandler: nil,
 		addressResolver:           addressResolver,
 
-		lastConnectionError:     nil,
-		lastConnectionErrorTime: time.Time{},
+		circuitBreaker:          NewCircuitBreaker(config.GetCircuitBreakerConfig()),
+		circuitBreakerTerminate: make(chan bool),
+		circuitNotifications:    []*circuitNotification{},
 
 		supportParallelUpload:    false,
 		supportParallelUploadSet: false,
 
+		// parallel transfers run in the io lane
+		parallelTransferTuner: util.NewParallelTransferTunerWithTaskLimit(config.ParallelTransfer, config.GetConnectionMaxForPriority(ConnectionPriorityIO)),
+
 		metrics: metrics.IRODSMetrics{},
 
 		mutex: sync.Mutex{},
 	}
+}
 
-	// resolve host address
-	poolAccount := *account
-	if addressResolver != nil {
-		poolAccount.Host = addressResolver(poolAccount.Host)
-	}
-
-	poolConfig := ConnectionPoolConfig{
-		Account:          &poolAccount,
-		ApplicationName:  config.ApplicationName,
-		InitialCap:       config.ConnectionInitNumber,
-		MaxIdle:          config.ConnectionMaxIdle,
-		MaxCap:           config.ConnectionMax,
-		Lifespan:         config.ConnectionLifespan,
-		IdleTimeout:      config.ConnectionIdleTimeout,
-		OperationTimeout: config.OperationTimeout,
-		TcpBufferSize:    config.TcpBufferSize,
-	}
-
-	pool, err := NewConnectionPool(&poolConfig, &sess.metrics)
-	if err != nil {
-		sess.lastConnectionError = err
-		sess.lastConnectionErrorTime = time.Now()
-
-		return nil, xerrors.Errorf("failed to create connection pool: %w", err)
+// start starts background work of the session
+func (sess *IRODSSession) start() {
+	if sess.circuitBreaker.GetConfig().AutoProbe {
+		go sess.runAutoProbe(sess.circuitBreakerTerminate)
 	}
-	sess.connectionPool = pool
 
 	// set transaction config
 	// when the user is anonymous, we cannot use transaction since we don't have access to home dir
@@ -99,43 +156,112 @@ func NewIRODSSessionWithAddressResolver(account *types.IRODSAccount, config *IRO
 		sess.commitFail = true
 		sess.poormansRollbackFail = true
 	}
-
-	return &sess, nil
 }
 
-// IsConnectionError returns if there is a failure
+// GetLastConnectionError returns the last connection error and its time
 func (sess *IRODSSession) GetLastConnectionError() (time.Time, error) {
-	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
-
-	return sess.lastConnectionErrorTime, sess.lastConnectionError
+	return sess.circuitBreaker.GetLastError()
 }
 
 func (sess *IRODSSession) getPendingError() error {
-	if sess.lastConnectionError == nil {
-		return nil
-	}
+	notification, err := sess.circuitBreaker.allow()
+	sess.queueCircuitNotification(notification)
+	return err
+}
 
-	if types.IsPermanantFailure(sess.lastConnectionError) {
-		return sess.lastConnectionError
-	}
+// recordSuccessLocked records a success to the circuit breaker while holding the session lock
+func (sess *IRODSSession) recordSuccessLocked() {
+	sess.queueCircuitNotification(sess.circuitBreaker.recordSuccess())
+}
 
-	// transitive error
-	// check timeout
-	if sess.lastConnectionErrorTime.Add(sess.config.ConnectionErrorTimeout).Before(time.Now()) {
-		// passed timeout
-		return nil
+// recordFailureLocked records a failure to the circuit breaker while holding the session lock
+func (sess *IRODSSession) recordFailureLocked(err error) {
+	sess.queueCircuitNotification(sess.circuitBreaker.recordFailure(err))
+}
+
+func (sess *IRODSSession) queueCircuitNotification(notification *circuitNotification) {
+	if notification != nil {
+		sess.circuitNotifications = append(sess.circuitNotifications, notification)
 	}
+}
 
-	return sess.lastConnectionError
+// unlock releases the session lock, then notifies circuit state changes made while holding it,
+// so callbacks can call the session
+func (sess *IRODSSession) unlock() {
+	notifications := sess.circuitNotifications
+	sess.circuitNotifications = []*circuitNotification{}
+	sess.mutex.Unlock()
+
+	for _, notification := range notifications {
+		notification.fire()
+	}
 }
 
 // IsPermanantFailure returns if there is a failure that is unfixable, permanant
+// the circuit breaker still probes the server after cool-down, so the session can recover
+// if the cause (e.g., password) is fixed on the server side
 func (sess *IRODSSession) IsPermanantFailure() bool {
-	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	_, lastErr := sess.circuitBreaker.GetLastError()
+	return types.IsPermanantFailure(lastErr)
+}
+
+// GetCircuitBreaker returns the circuit breaker guarding connection creation
+func (sess *IRODSSession) GetCircuitBreaker() *CircuitBreaker {
+	return sess.circuitBreaker
+}
+
+// GetCircuitState returns current circuit state
+func (sess *IRODSSession) GetCircuitState() CircuitState {
+	return sess.circuitBreaker.GetState()
+}
+
+// AddCircuitBreakerCallback adds a callback that is called when circuit state changes
+func (sess *IRODSSession) AddCircuitBreakerCallback(callback CircuitBreakerCallback) {
+	sess.circuitBreaker.AddCallback(callback)
+}
+
+// Probe tries a new connection to the server and records the result to the circuit breaker
+func (sess *IRODSSession) Probe() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "session",
+		"struct":   "IRODSSession",
+		"function": "Probe",
+	})
+
+	// probe endpoints as pooled connections do
+	newConn, _, err := sess.connectionPool.connect()
+	if err != nil {
+		logger.WithError(err).Debug("failed to probe the server")
+		sess.circuitBreaker.RecordFailure(err)
+		return err
+	}
+
+	newConn.Disconnect()
+
+	sess.circuitBreaker.RecordSuccess()
+	return nil
+}
+
+// runAutoProbe probes the server in background when the circuit is open and cool-down has passed
+func (sess *IRODSSession) runAutoProbe(terminate chan bool) {
+	interval := sess.circuitBreaker.GetConfig().CoolDown / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
 
-	return types.IsPermanantFailure(sess.lastConnectionError)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminate:
+			return
+		case <-ticker.C:
+			if sess.circuitBreaker.ReadyToProbe() {
+				sess.Probe()
+			}
+		}
+	}
 }
 
 // GetConfig returns a configuration
@@ -223,25 +349,38 @@ func (sess *IRODSSession) endTransaction(conn *connection.IRODSConnection) error
 
 // AcquireConnection returns an idle connection
 func (sess *IRODSSession) AcquireConnection() (*connection.IRODSConnection, error) {
+	conn, _, err := sess.acquireConnection(sess.config.ConnectionPriority)
+	return conn, err
+}
+
+// acquireConnection returns an idle connection for a request of the priority, the boolean return value indicates
+// if the connection is shared with other callers
+func (sess *IRODSSession) acquireConnection(priority ConnectionPriority) (*connection.IRODSConnection, bool, error) {
 	logger := log.WithFields(log.Fields{
 		"package":  "session",
 		"struct":   "IRODSSession",
-		"function": "AcquireConnection",
+		"function": "acquireConnection",
 	})
 
+	if sess.config.ConnectionWaitTimeout > 0 {
+		// wait for a pooled connection rather than sharing
+		conn, err := sess.AcquireConnectionWithRequest(NewConnectionRequest(priority, ""))
+		return conn, false, err
+	}
+
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	// return last error
 	pendingErr := sess.getPendingError()
 	if pendingErr != nil {
-		return nil, xerrors.Errorf("failed to get a connection from the pool because pending error is found: %w", pendingErr)
+		return nil, false, xerrors.Errorf("failed to get a connection from the pool because pending error is found: %w", pendingErr)
 	}
 
 	// check if there are available connections in the pool
 	if sess.connectionPool.AvailableConnections() > 0 {
 		// try to get it from the pool
-		conn, _, err := sess.connectionPool.Get()
+		conn, _, err := sess.connectionPool.GetWithPriority(priority)
 		// ignore error this happens when connections in the pool are all occupied
 		if err != nil {
 			if types.IsConnectionPoolFullError(err) {
@@ -249,10 +388,9 @@ func (sess *IRODSSession) AcquireConnection() (*connection.IRODSConnection, erro
 				// fall below
 			} else {
 				// fail
-				sess.lastConnectionError = err
-				sess.lastConnectionErrorTime = time.Now()
+				sess.recordFailureLocked(err)
 
-				return nil, err
+				return nil, false, err
 			}
 		} else {
 			// put to share
@@ -268,11 +406,16 @@ func (sess *IRODSSession) AcquireConnection() (*connection.IRODSConnection, erro
 				sess.supportParallelUploadSet = true
 			}
 
-			return conn, nil
+			sess.recordSuccessLocked()
+
+			return conn, false, nil
 		}
 	}
 
 	// failed to get connection from pool
+	// sharing a connection tells nothing about the server, let another request probe
+	sess.circuitBreaker.ReleaseProbe()
+
 	// find a connection from shared connection list that has minimum share count
 	logger.Debug("Share an in-use connection as it cannot create a new connection")
 	minShare := 0
@@ -291,14 +434,112 @@ func (sess *IRODSSession) AcquireConnection() (*connection.IRODSConnection, erro
 
 	if minShareConn == nil {
 		sess.metrics.IncreaseCounterForConnectionPoolFailures(1)
-		return nil, xerrors.Errorf("failed to get a shared connection, too many connections created")
+		return nil, false, xerrors.Errorf("failed to get a shared connection, too many connections created")
 	}
 
 	// update
 	minShare++
 	sess.sharedConnections[minShareConn] = minShare
 
-	return minShareConn, nil
+	return minShareConn, true, nil
+}
+
+// AcquireConnectionWithPriority returns an idle connection, waiting in the given priority lane if waiting is enabled
+func (sess *IRODSSession) AcquireConnectionWithPriority(priority ConnectionPriority) (*connection.IRODSConnection, error) {
+	conn, _, err := sess.acquireConnection(priority)
+	return conn, err
+}
+
+// AcquireConnectionWithRequest returns a connection from the pool, waiting in the request's priority lane
+// until a connection is available or the request's deadline passes.
+// If the deadline is not set, ConnectionWaitTimeout in the session config is used.
+func (sess *IRODSSession) AcquireConnectionWithRequest(request *ConnectionRequest) (*connection.IRODSConnection, error) {
+	sess.mutex.Lock()
+
+	// return last error
+	pendingErr := sess.getPendingError()
+	if pendingErr != nil {
+		sess.unlock()
+		return nil, xerrors.Errorf("failed to get a connection from the pool because pending error is found: %w", pendingErr)
+	}
+
+	sess.unlock()
+
+	// do not hold the session lock while waiting, others need it to return connections
+	conn, _, err := sess.connectionPool.GetWait(request)
+
+	sess.mutex.Lock()
+	defer sess.unlock()
+
+	if err != nil {
+		if types.IsConnectionPoolFullError(err) {
+			// the server is not reached, let another request probe
+			sess.circuitBreaker.ReleaseProbe()
+		} else {
+			sess.recordFailureLocked(err)
+		}
+
+		return nil, xerrors.Errorf("failed to get a connection from the pool: %w", err)
+	}
+
+	// put to share
+	if shares, ok := sess.sharedConnections[conn]; ok {
+		shares++
+		sess.sharedConnections[conn] = shares
+	} else {
+		sess.sharedConnections[conn] = 1
+	}
+
+	if !sess.supportParallelUploadSet {
+		sess.supportParallelUpload = conn.SupportParallelUpload()
+		sess.supportParallelUploadSet = true
+	}
+
+	sess.recordSuccessLocked()
+
+	return conn, nil
+}
+
+// IdempotentOperation is an operation that can be safely run again on another connection
+type IdempotentOperation func(conn *connection.IRODSConnection) error
+
+// ExecuteIdempotent acquires a connection and runs the operation with it.
+// If the operation fails with a retryable error, it runs again as configured in the retry policy.
+// Connections broken by connection errors are discarded, so the next attempt uses a fresh connection,
+// unless the connection is shared with other callers, they discard it when they see the error.
+// Use this only for operations that do not change the server state (stat, list, query).
+func (sess *IRODSSession) ExecuteIdempotent(operation IdempotentOperation) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "session",
+		"struct":   "IRODSSession",
+		"function": "ExecuteIdempotent",
+	})
+
+	policy := sess.config.RetryPolicy
+	if policy == nil {
+		policy = NewNoRetryPolicy()
+	}
+
+	return policy.Execute(func(attempt int) error {
+		if attempt > 1 {
+			logger.Debugf("retrying an idempotent operation, attempt %d", attempt)
+			sess.metrics.IncreaseCounterForOperationRetries(1)
+		}
+
+		conn, shared, err := sess.acquireConnection(sess.config.ConnectionPriority)
+		if err != nil {
+			return err
+		}
+
+		err = operation(conn)
+		if err != nil && types.IsConnectionError(err) && !shared {
+			sess.DiscardConnection(conn)
+			return err
+		}
+
+		sess.ReturnConnection(conn)
+		return err
+	})
 }
 
 // AcquireConnectionsMulti returns idle connections
@@ -310,7 +551,7 @@ func (sess *IRODSSession) AcquireConnectionsMulti(number int) ([]*connection.IRO
 	})
 
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	// return last error
 	pendingErr := sess.getPendingError()
@@ -324,15 +565,14 @@ func (sess *IRODSSession) AcquireConnectionsMulti(number int) ([]*connection.IRO
 	for i := 0; i < number; i++ {
 		if sess.connectionPool.AvailableConnections() > 0 {
 			// try to get it from the pool
-			conn, _, err := sess.connectionPool.Get()
+			conn, _, err := sess.connectionPool.GetWithPriority(sess.config.ConnectionPriority)
 			if err != nil {
 				if types.IsConnectionPoolFullError(err) {
 					logger.WithError(err).Debug("failed to get a connection from the pool, the pool is full")
 					// fall below
 				} else {
 					// fail
-					sess.lastConnectionError = err
-					sess.lastConnectionErrorTime = time.Now()
+					sess.recordFailureLocked(err)
 
 					return nil, err
 				}
@@ -384,10 +624,13 @@ func (sess *IRODSSession) AcquireConnectionsMulti(number int) ([]*connection.IRO
 		}
 	}
 
+	sess.recordSuccessLocked()
+
 	return acquiredConnections, nil
 }
 
 // AcquireUnmanagedConnection returns a connection that is not managed
+// it always connects to the account's host, endpoint failover applies to pooled connections only
 func (sess *IRODSSession) AcquireUnmanagedConnection() (*connection.IRODSConnection, error) {
 	logger := log.WithFields(log.Fields{
 		"package":  "session",
@@ -396,7 +639,7 @@ func (sess *IRODSSession) AcquireUnmanagedConnection() (*connection.IRODSConnect
 	})
 
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	// return last error
 	pendingErr := sess.getPendingError()
@@ -405,13 +648,11 @@ func (sess *IRODSSession) AcquireUnmanagedConnection() (*connection.IRODSConnect
 	}
 
 	// create a new one
-	newConn := connection.NewIRODSConnection(sess.account, sess.config.OperationTimeout, sess.config.ApplicationName)
-	err := newConn.Connect()
+	newConn, err := sess.connectionPool.NewUnmanagedConnection()
 	if err != nil {
-		sess.lastConnectionError = err
-		sess.lastConnectionErrorTime = time.Now()
+		sess.recordFailureLocked(err)
 
-		return nil, xerrors.Errorf("failed to connect to irods server: %w", err)
+		return nil, err
 	}
 
 	logger.Debug("Created a new unmanaged connection")
@@ -421,6 +662,8 @@ func (sess *IRODSSession) AcquireUnmanagedConnection() (*connection.IRODSConnect
 		sess.supportParallelUploadSet = true
 	}
 
+	sess.recordSuccessLocked()
+
 	return newConn, nil
 }
 
@@ -433,7 +676,7 @@ func (sess *IRODSSession) ReturnConnection(conn *connection.IRODSConnection) err
 	})
 
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	if share, ok := sess.sharedConnections[conn]; ok {
 		share--
@@ -479,7 +722,7 @@ func (sess *IRODSSession) ReturnConnection(conn *connection.IRODSConnection) err
 // DiscardConnection discards a connection
 func (sess *IRODSSession) DiscardConnection(conn *connection.IRODSConnection) error {
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	if share, ok := sess.sharedConnections[conn]; ok {
 		share--
@@ -505,15 +748,22 @@ func (sess *IRODSSession) DiscardConnection(conn *connection.IRODSConnection) er
 // Release releases all connections
 func (sess *IRODSSession) Release() {
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	// we don't disconnect connections here,
 	// we will disconnect it when calling pool.Release
 	sess.sharedConnections = map[*connection.IRODSConnection]int{}
 
-	sess.lastConnectionError = nil
+	if sess.circuitBreakerTerminate != nil {
+		// stop auto probe
+		close(sess.circuitBreakerTerminate)
+		sess.circuitBreakerTerminate = nil
+	}
+	sess.queueCircuitNotification(sess.circuitBreaker.reset())
 
-	sess.connectionPool.Release()
+	if !sess.sharingConnectionPool {
+		sess.connectionPool.Release()
+	}
 }
 
 // SupportParallelUpload returns if parallel upload is supported
@@ -524,7 +774,7 @@ func (sess *IRODSSession) SupportParallelUpload() bool {
 	})
 
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	// return last error
 	pendingErr := sess.getPendingError()
@@ -535,9 +785,11 @@ func (sess *IRODSSession) SupportParallelUpload() bool {
 	if !sess.supportParallelUploadSet {
 		conn, _, err := sess.connectionPool.Get()
 		if err != nil {
-			if !types.IsConnectionPoolFullError(err) {
-				sess.lastConnectionError = err
-				sess.lastConnectionErrorTime = time.Now()
+			if types.IsConnectionPoolFullError(err) {
+				// the server is not reached, let another request probe
+				sess.circuitBreaker.ReleaseProbe()
+			} else {
+				sess.recordFailureLocked(err)
 			}
 
 			return false
@@ -546,6 +798,8 @@ func (sess *IRODSSession) SupportParallelUpload() bool {
 		conn.Lock()
 
 		// check parallel upload
+		sess.recordSuccessLocked()
+
 		sess.supportParallelUpload = conn.SupportParallelUpload()
 		logger.Debugf("support parallel upload: %t", sess.supportParallelUpload)
 
@@ -553,15 +807,34 @@ func (sess *IRODSSession) SupportParallelUpload() bool {
 
 		sess.connectionPool.Return(conn)
 		sess.supportParallelUploadSet = true
+	} else {
+		// no request is made to the server
+		sess.circuitBreaker.ReleaseProbe()
 	}
 
 	return sess.supportParallelUpload
 }
 
+// GetParallelTransferTuner returns the tuner that chooses the number of tasks and block size of parallel transfers
+func (sess *IRODSSession) GetParallelTransferTuner() *util.ParallelTransferTuner {
+	return sess.parallelTransferTuner
+}
+
+// WaitingRequests returns the number of requests waiting for connections in the pool
+func (sess *IRODSSession) WaitingRequests() int {
+	return sess.connectionPool.WaitingRequests()
+}
+
+// GetEndpointStatus returns health status of catalog endpoints
+func (sess *IRODSSession) GetEndpointStatus() []EndpointStatus {
+	return sess.connectionPool.GetEndpointStatus()
+}
+
 // Connections returns the number of connections in the pool
+// sessions sharing a pool return the same number
 func (sess *IRODSSession) ConnectionTotal() int {
 	sess.mutex.Lock()
-	defer sess.mutex.Unlock()
+	defer sess.unlock()
 
 	return sess.connectionPool.OpenConnections()
 }
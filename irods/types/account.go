@@ -112,6 +112,58 @@ func CreateIRODSProxyAccount(host string, port int, clientUser string, clientZon
 	return account, nil
 }
 
+// CreateIRODSAccountForTempPassword creates IRODSAccount for accessing as the given user with a temporary password
+// connection settings are copied from the given account. The current client user is used if user is empty.
+func CreateIRODSAccountForTempPassword(account *IRODSAccount, user string, tempPassword string) (*IRODSAccount, error) {
+	if len(user) == 0 {
+		user = account.ClientUser
+	}
+
+	tempAccount := &IRODSAccount{
+		AuthenticationScheme:    AuthSchemeNative,
+		ClientServerNegotiation: account.ClientServerNegotiation,
+		CSNegotiationPolicy:     account.CSNegotiationPolicy,
+		Host:                    account.Host,
+		Port:                    account.Port,
+		ClientUser:              user,
+		ClientZone:              account.ClientZone,
+		ProxyUser:               user,
+		ProxyZone:               account.ClientZone,
+		Password:                tempPassword,
+		Ticket:                  "",
+		DefaultResource:         account.DefaultResource,
+		PamTTL:                  PamTTLDefault,
+		PamToken:                "",
+		SSLConfiguration:        account.SSLConfiguration,
+	}
+
+	tempAccount.FixAuthConfiguration()
+
+	return tempAccount, nil
+}
+
+// CreateIRODSAccountForProxyClient creates IRODSAccount for accessing on behalf of the given client user
+// the proxy user (rodsadmin) and its credentials and connection settings are copied from the given account.
+// The proxy user's zone is used if clientZone is empty.
+func CreateIRODSAccountForProxyClient(account *IRODSAccount, clientUser string, clientZone string) (*IRODSAccount, error) {
+	if len(clientUser) == 0 {
+		return nil, xerrors.Errorf("empty client user")
+	}
+
+	if len(clientZone) == 0 {
+		clientZone = account.ProxyZone
+	}
+
+	proxyAccount := *account
+	proxyAccount.ClientUser = clientUser
+	proxyAccount.ClientZone = clientZone
+	proxyAccount.Ticket = ""
+
+	proxyAccount.FixAuthConfiguration()
+
+	return &proxyAccount, nil
+}
+
 // CreateIRODSAccountFromYAML creates IRODSAccount from YAML
 func CreateIRODSAccountFromYAML(yamlBytes []byte) (*IRODSAccount, error) {
 	y := make(map[string]interface{})
@@ -287,12 +339,79 @@ func CreateIRODSAccountFromYAML(yamlBytes []byte) (*IRODSAccount, error) {
 		hashRounds = val.(int)
 	}
 
+	certFile := ""
+	if val, ok := sslConfig["cert_file"]; ok {
+		certFile = val.(string)
+	}
+
+	keyFile := ""
+	if val, ok := sslConfig["key_file"]; ok {
+		keyFile = val.(string)
+	}
+
+	verifyServer := ""
+	if val, ok := sslConfig["verify_server"]; ok {
+		verifyServer = val.(string)
+	}
+
+	serverName := ""
+	if val, ok := sslConfig["server_name"]; ok {
+		serverName = val.(string)
+	}
+
+	minVersion := ""
+	if val, ok := sslConfig["min_version"]; ok {
+		minVersion = val.(string)
+	}
+
+	maxVersion := ""
+	if val, ok := sslConfig["max_version"]; ok {
+		maxVersion = val.(string)
+	}
+
+	cipherSuites := []string{}
+	if val, ok := sslConfig["cipher_suites"]; ok {
+		if suites, ok := val.([]interface{}); ok {
+			for _, suite := range suites {
+				cipherSuites = append(cipherSuites, suite.(string))
+			}
+		}
+	}
+
 	var irodsSSLConfig *IRODSSSLConfig = nil
 	if hasSSLConfig {
 		irodsSSLConfig, err = CreateIRODSSSLConfig(caCertFile, caCertPath, keySize, algorithm, saltSize, hashRounds)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to create irods ssl config: %w", err)
 		}
+
+		irodsSSLConfig.SetClientCertificate(certFile, keyFile)
+		irodsSSLConfig.ServerName = serverName
+
+		if len(verifyServer) > 0 {
+			irodsSSLConfig.VerifyServer, err = GetSSLVerifyServer(verifyServer)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse ssl verify server mode: %w", err)
+			}
+		}
+
+		irodsSSLConfig.MinVersion, err = GetTLSVersion(minVersion)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse ssl min version: %w", err)
+		}
+
+		irodsSSLConfig.MaxVersion, err = GetTLSVersion(maxVersion)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse ssl max version: %w", err)
+		}
+
+		for _, cipherSuite := range cipherSuites {
+			cipherSuiteID, err := GetTLSCipherSuite(cipherSuite)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse ssl cipher suite: %w", err)
+			}
+			irodsSSLConfig.CipherSuites = append(irodsSSLConfig.CipherSuites, cipherSuiteID)
+		}
 	}
 
 	account := &IRODSAccount{
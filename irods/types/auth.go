@@ -2,6 +2,7 @@ package types
 
 import (
 	"strings"
+	"sync"
 )
 
 // AuthScheme defines Authentication Scheme
@@ -14,22 +15,65 @@ const (
 	AuthSchemeGSI AuthScheme = "gsi"
 	// AuthSchemePAM uses PAM authentication scheme
 	AuthSchemePAM AuthScheme = "pam"
+	// AuthSchemePAMPlugin uses PAM authentication scheme through server-side auth plugin
+	AuthSchemePAMPlugin AuthScheme = "pam_plugin"
 	// AuthSchemeUnknown is unknown scheme
 	AuthSchemeUnknown AuthScheme = ""
 )
 
+var (
+	customAuthSchemes      = map[string]AuthScheme{}
+	customAuthSchemesMutex sync.RWMutex
+)
+
+// NormalizeAuthScheme returns the authentication scheme name in lower case without surrounding spaces
+func NormalizeAuthScheme(authScheme string) AuthScheme {
+	return AuthScheme(strings.TrimSpace(strings.ToLower(authScheme)))
+}
+
+// RegisterAuthScheme registers a custom authentication scheme name, so GetAuthScheme can resolve it
+func RegisterAuthScheme(authScheme string) AuthScheme {
+	scheme := NormalizeAuthScheme(authScheme)
+
+	customAuthSchemesMutex.Lock()
+	defer customAuthSchemesMutex.Unlock()
+
+	customAuthSchemes[string(scheme)] = scheme
+	return scheme
+}
+
+// UnregisterAuthScheme unregisters a custom authentication scheme name, built-in schemes are not affected
+func UnregisterAuthScheme(authScheme string) {
+	scheme := NormalizeAuthScheme(authScheme)
+
+	customAuthSchemesMutex.Lock()
+	defer customAuthSchemesMutex.Unlock()
+
+	delete(customAuthSchemes, string(scheme))
+}
+
 // GetAuthScheme returns AuthScheme value from string
 func GetAuthScheme(authScheme string) AuthScheme {
-	switch strings.TrimSpace(strings.ToLower(authScheme)) {
+	scheme := NormalizeAuthScheme(authScheme)
+
+	switch string(scheme) {
 	case string(AuthSchemeNative):
 		return AuthSchemeNative
 	case string(AuthSchemeGSI):
 		return AuthSchemeGSI
 	case string(AuthSchemePAM), "pam_password":
 		return AuthSchemePAM
+	case string(AuthSchemePAMPlugin):
+		return AuthSchemePAMPlugin
 	case string(AuthSchemeUnknown):
-		fallthrough
+		return AuthSchemeUnknown
 	default:
+		customAuthSchemesMutex.RLock()
+		defer customAuthSchemesMutex.RUnlock()
+
+		if customScheme, ok := customAuthSchemes[string(scheme)]; ok {
+			return customScheme
+		}
 		return AuthSchemeUnknown
 	}
 }
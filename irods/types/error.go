@@ -75,6 +75,8 @@ func IsResourceServerConnectionConfigError(err error) bool {
 
 // ConnectionError contains connection error information
 type ConnectionError struct {
+	// Cause is the error from the network or TLS layer, can be nil
+	Cause error
 }
 
 // NewConnectionError creates an error for connection poll full
@@ -82,8 +84,23 @@ func NewConnectionError() error {
 	return &ConnectionError{}
 }
 
+// NewConnectionErrorWithCause creates a connection error caused by an error from the network or TLS layer
+// the cause is returned as is if it is a connection error already
+func NewConnectionErrorWithCause(cause error) error {
+	if IsConnectionError(cause) {
+		return cause
+	}
+
+	return &ConnectionError{
+		Cause: cause,
+	}
+}
+
 // Error returns error message
 func (err *ConnectionError) Error() string {
+	if err.Cause != nil {
+		return fmt.Sprintf("connection error: %s", err.Cause.Error())
+	}
 	return "connection error"
 }
 
@@ -93,6 +110,11 @@ func (err *ConnectionError) Is(other error) bool {
 	return ok
 }
 
+// Unwrap returns the cause
+func (err *ConnectionError) Unwrap() error {
+	return err.Cause
+}
+
 // ToString stringifies the object
 func (err *ConnectionError) ToString() string {
 	return "<ConnectionError>"
@@ -5,6 +5,22 @@ import (
 	"time"
 )
 
+// ReplicaStatus is a status of a replica
+type ReplicaStatus string
+
+const (
+	// ReplicaStatusStale is for stale replica
+	ReplicaStatusStale ReplicaStatus = "0"
+	// ReplicaStatusGood is for good replica
+	ReplicaStatusGood ReplicaStatus = "1"
+	// ReplicaStatusIntermediate is for replica being written
+	ReplicaStatusIntermediate ReplicaStatus = "2"
+	// ReplicaStatusReadLocked is for read-locked replica
+	ReplicaStatusReadLocked ReplicaStatus = "3"
+	// ReplicaStatusWriteLocked is for write-locked replica
+	ReplicaStatusWriteLocked ReplicaStatus = "4"
+)
+
 // IRODSReplica contains irods data object replication information
 type IRODSReplica struct {
 	Number int64
@@ -12,6 +28,9 @@ type IRODSReplica struct {
 	// Owner has the owner's name
 	Owner string
 
+	// Size has the size of the replica
+	Size int64
+
 	Checksum     *IRODSChecksum
 	Status       string
 	ResourceName string
@@ -30,3 +49,18 @@ type IRODSReplica struct {
 func (obj *IRODSReplica) ToString() string {
 	return fmt.Sprintf("<IRODSReplica %d %s %s %s %s>", obj.Number, obj.Status, obj.ResourceName, obj.CreateTime, obj.ModifyTime)
 }
+
+// GetStatus returns replica status
+func (obj *IRODSReplica) GetStatus() ReplicaStatus {
+	return ReplicaStatus(obj.Status)
+}
+
+// IsGood returns true if the replica is good
+func (obj *IRODSReplica) IsGood() bool {
+	return obj.GetStatus() == ReplicaStatusGood
+}
+
+// IsStale returns true if the replica is stale
+func (obj *IRODSReplica) IsStale() bool {
+	return obj.GetStatus() == ReplicaStatusStale
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// IRODSServerType is a type of iRODS server
+type IRODSServerType string
+
+const (
+	// IRODSServerTypeProvider is a catalog service provider, having a connection to the catalog
+	IRODSServerTypeProvider IRODSServerType = "provider"
+	// IRODSServerTypeConsumer is a catalog service consumer
+	IRODSServerTypeConsumer IRODSServerType = "consumer"
+)
+
+// GetIRODSServerType returns IRODSServerType from the server type code in misc server info
+func GetIRODSServerType(serverType int) IRODSServerType {
+	// RCAT_ENABLED is 1, RCAT_NOT_ENABLED is 0
+	if serverType == 1 {
+		return IRODSServerTypeProvider
+	}
+	return IRODSServerTypeConsumer
+}
+
+// IRODSServerCapability is a feature that the server may support
+type IRODSServerCapability string
+
+const (
+	// IRODSServerCapabilityParallelTransfer supports parallel upload using replica tokens
+	IRODSServerCapabilityParallelTransfer IRODSServerCapability = "parallel_transfer"
+	// IRODSServerCapabilityTouch supports touch API
+	IRODSServerCapabilityTouch IRODSServerCapability = "touch"
+	// IRODSServerCapabilityAtomicMetadata supports atomic metadata operations API
+	IRODSServerCapabilityAtomicMetadata IRODSServerCapability = "atomic_metadata"
+	// IRODSServerCapabilityReplicaClose supports replica close API
+	IRODSServerCapabilityReplicaClose IRODSServerCapability = "replica_close"
+	// IRODSServerCapabilityFileDescriptorInfo supports file descriptor info API
+	IRODSServerCapabilityFileDescriptorInfo IRODSServerCapability = "file_descriptor_info"
+	// IRODSServerCapabilityAuthFramework supports iRODS 4.3 authentication framework
+	IRODSServerCapabilityAuthFramework IRODSServerCapability = "auth_framework"
+	// IRODSServerCapabilityGenQuery2 supports GenQuery2 API
+	IRODSServerCapabilityGenQuery2 IRODSServerCapability = "genquery2"
+)
+
+// serverCapabilityVersions is the first release version that supports each capability (major, minor, patch)
+var serverCapabilityVersions = map[IRODSServerCapability][3]int{
+	IRODSServerCapabilityParallelTransfer:   {4, 2, 9},
+	IRODSServerCapabilityTouch:              {4, 2, 9},
+	IRODSServerCapabilityAtomicMetadata:     {4, 2, 8},
+	IRODSServerCapabilityReplicaClose:       {4, 2, 9},
+	IRODSServerCapabilityFileDescriptorInfo: {4, 2, 8},
+	IRODSServerCapabilityAuthFramework:      {4, 3, 0},
+	IRODSServerCapabilityGenQuery2:          {4, 3, 2},
+}
+
+// GetServerCapabilities returns capabilities of the server, derived from its release version
+func GetServerCapabilities(version *IRODSVersion) map[IRODSServerCapability]bool {
+	capabilities := map[IRODSServerCapability]bool{}
+	for capability, minVersion := range serverCapabilityVersions {
+		capabilities[capability] = version != nil && version.HasHigherVersionThan(minVersion[0], minVersion[1], minVersion[2])
+	}
+	return capabilities
+}
+
+// IRODSServerInfo contains information of the server
+type IRODSServerInfo struct {
+	Type           IRODSServerType
+	Zone           string
+	BootTime       time.Time
+	ReleaseVersion string // e.g., "rods4.2.8"
+	APIVersion     string
+	Capabilities   map[IRODSServerCapability]bool
+}
+
+// NewIRODSServerInfo creates IRODSServerInfo, capabilities are derived from the release version
+func NewIRODSServerInfo(serverType IRODSServerType, zone string, bootTime time.Time, releaseVersion string, apiVersion string) *IRODSServerInfo {
+	info := &IRODSServerInfo{
+		Type:           serverType,
+		Zone:           zone,
+		BootTime:       bootTime,
+		ReleaseVersion: releaseVersion,
+		APIVersion:     apiVersion,
+	}
+
+	info.Capabilities = GetServerCapabilities(info.GetVersion())
+	return info
+}
+
+// GetVersion returns version info
+func (info *IRODSServerInfo) GetVersion() *IRODSVersion {
+	return &IRODSVersion{
+		ReleaseVersion: info.ReleaseVersion,
+		APIVersion:     info.APIVersion,
+	}
+}
+
+// HasCapability returns true if the server supports the capability
+func (info *IRODSServerInfo) HasCapability(capability IRODSServerCapability) bool {
+	return info.Capabilities[capability]
+}
+
+// IsProvider returns true if the server is a catalog service provider
+func (info *IRODSServerInfo) IsProvider() bool {
+	return info.Type == IRODSServerTypeProvider
+}
+
+// ToString stringifies the object
+func (info *IRODSServerInfo) ToString() string {
+	return fmt.Sprintf("<IRODSServerInfo %s %s %s %s %s>", info.Type, info.Zone, info.ReleaseVersion, info.APIVersion, info.BootTime)
+}
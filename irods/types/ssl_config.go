@@ -1,12 +1,87 @@
 package types
 
 import (
+	"crypto/tls"
 	"crypto/x509"
+	"fmt"
+	"strings"
 
 	"github.com/hashicorp/go-rootcerts"
 	"golang.org/x/xerrors"
 )
 
+// SSLVerifyServer defines how the server certificate is verified
+type SSLVerifyServer string
+
+const (
+	// SSLVerifyServerNone does not verify the server certificate
+	SSLVerifyServerNone SSLVerifyServer = "none"
+	// SSLVerifyServerCert verifies the server certificate chain, but not the hostname
+	SSLVerifyServerCert SSLVerifyServer = "cert"
+	// SSLVerifyServerHostname verifies the server certificate chain and the hostname
+	SSLVerifyServerHostname SSLVerifyServer = "hostname"
+)
+
+// GetSSLVerifyServer returns SSLVerifyServer value from string
+func GetSSLVerifyServer(verifyServer string) (SSLVerifyServer, error) {
+	sslVerifyServer := SSLVerifyServerHostname
+	var err error = nil
+	switch strings.TrimSpace(strings.ToLower(verifyServer)) {
+	case string(SSLVerifyServerNone):
+		sslVerifyServer = SSLVerifyServerNone
+	case string(SSLVerifyServerCert):
+		sslVerifyServer = SSLVerifyServerCert
+	case string(SSLVerifyServerHostname), "":
+		sslVerifyServer = SSLVerifyServerHostname
+	default:
+		err = fmt.Errorf("cannot parse string %s", verifyServer)
+	}
+
+	return sslVerifyServer, err
+}
+
+// GetTLSVersion returns TLS version value from string, e.g., "1.2" or "TLS1.3"
+func GetTLSVersion(version string) (uint16, error) {
+	normalized := strings.TrimSpace(strings.ToUpper(version))
+	normalized = strings.TrimPrefix(normalized, "TLS")
+	normalized = strings.TrimPrefix(normalized, "V")
+	normalized = strings.TrimSpace(normalized)
+
+	switch normalized {
+	case "":
+		return 0, nil
+	case "1.0", "1_0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "1_1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "1_2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "1_3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, xerrors.Errorf("cannot parse tls version %s", version)
+	}
+}
+
+// GetTLSCipherSuite returns TLS cipher suite id from its name, e.g., "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
+func GetTLSCipherSuite(name string) (uint16, error) {
+	normalized := strings.TrimSpace(strings.ToUpper(name))
+
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == normalized {
+			return suite.ID, nil
+		}
+	}
+
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == normalized {
+			return suite.ID, nil
+		}
+	}
+
+	return 0, xerrors.Errorf("unknown tls cipher suite %s", name)
+}
+
 // IRODSSSLConfig contains irods ssl configuration
 type IRODSSSLConfig struct {
 	CACertificateFile   string
@@ -15,6 +90,24 @@ type IRODSSSLConfig struct {
 	EncryptionAlgorithm string
 	SaltSize            int
 	HashRounds          int
+
+	// client certificate chain and its private key for client certificate authentication
+	// the key can be in the certificate file if CertificateKeyFile is empty
+	CertificateFile    string
+	CertificateKeyFile string
+	// VerifyServer sets how the server certificate is verified
+	// empty does not verify the server certificate for backward compatibility
+	VerifyServer SSLVerifyServer
+	// ServerName overrides the hostname used for verification, the account's host is used if empty
+	ServerName string
+	// MinVersion and MaxVersion restrict TLS versions, e.g., tls.VersionTLS12, 0 uses Go defaults
+	MinVersion uint16
+	MaxVersion uint16
+	// CipherSuites restricts cipher suites for TLS 1.2 and below, empty uses Go defaults
+	CipherSuites []uint16
+	// TLSConfig is used as it is if set, all other TLS settings above are ignored
+	// ServerName is set to the host if empty
+	TLSConfig *tls.Config
 }
 
 // CreateIRODSSSLConfig creates IRODSSSLConfig
@@ -30,6 +123,12 @@ func CreateIRODSSSLConfig(caCertFile string, caCertPath string, keySize int, alg
 	}, nil
 }
 
+// SetClientCertificate sets client certificate chain file and its private key file
+func (config *IRODSSSLConfig) SetClientCertificate(certFile string, keyFile string) {
+	config.CertificateFile = certFile
+	config.CertificateKeyFile = keyFile
+}
+
 // LoadCACert loads CA Cert
 func (config *IRODSSSLConfig) LoadCACert() (*x509.CertPool, error) {
 
@@ -45,3 +144,108 @@ func (config *IRODSSSLConfig) LoadCACert() (*x509.CertPool, error) {
 
 	return certPool, nil
 }
+
+// LoadClientCert loads client certificate, returns nil if not configured
+func (config *IRODSSSLConfig) LoadClientCert() (*tls.Certificate, error) {
+	if len(config.CertificateFile) == 0 {
+		return nil, nil
+	}
+
+	keyFile := config.CertificateKeyFile
+	if len(keyFile) == 0 {
+		keyFile = config.CertificateFile
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.CertificateFile, keyFile)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load client certificate %s and key %s: %w", config.CertificateFile, keyFile, err)
+	}
+
+	return &cert, nil
+}
+
+// GetTLSConfig returns tls.Config to connect to the given host
+func (config *IRODSSSLConfig) GetTLSConfig(host string) (*tls.Config, error) {
+	serverName := host
+	if len(config.ServerName) > 0 {
+		serverName = config.ServerName
+	}
+
+	if config.TLSConfig != nil {
+		tlsConfig := config.TLSConfig.Clone()
+		if len(tlsConfig.ServerName) == 0 {
+			tlsConfig.ServerName = serverName
+		}
+		return tlsConfig, nil
+	}
+
+	caCertPool, err := config.LoadCACert()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:      caCertPool,
+		ServerName:   serverName,
+		MinVersion:   config.MinVersion,
+		MaxVersion:   config.MaxVersion,
+		CipherSuites: config.CipherSuites,
+	}
+
+	clientCert, err := config.LoadClientCert()
+	if err != nil {
+		return nil, err
+	}
+
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	switch config.VerifyServer {
+	case SSLVerifyServerHostname:
+		tlsConfig.InsecureSkipVerify = false
+	case SSLVerifyServerCert:
+		// go does not support skipping hostname verification only, verify the chain ourselves
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = getCertChainVerifier(caCertPool)
+	case SSLVerifyServerNone, "":
+		tlsConfig.InsecureSkipVerify = true
+	default:
+		return nil, xerrors.Errorf("unknown ssl verify server mode %s", config.VerifyServer)
+	}
+
+	return tlsConfig, nil
+}
+
+// getCertChainVerifier returns a function that verifies the certificate chain without hostname
+func getCertChainVerifier(caCertPool *x509.CertPool) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return xerrors.Errorf("server did not present a certificate")
+		}
+
+		certs := []*x509.Certificate{}
+		for _, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return xerrors.Errorf("failed to parse server certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         caCertPool,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			return xerrors.Errorf("failed to verify server certificate: %w", err)
+		}
+
+		return nil
+	}
+}
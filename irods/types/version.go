@@ -48,6 +48,16 @@ func (ver *IRODSVersion) GetReleaseVersion() (int, int, int) {
 	return major, minor, patch
 }
 
+// HasCapability returns true if the server of this version supports the capability
+func (ver *IRODSVersion) HasCapability(capability IRODSServerCapability) bool {
+	minVersion, ok := serverCapabilityVersions[capability]
+	if !ok {
+		return false
+	}
+
+	return ver.HasHigherVersionThan(minVersion[0], minVersion[1], minVersion[2])
+}
+
 // HasHigherVersionThan returns if given version is higher or equal than current version
 func (ver *IRODSVersion) HasHigherVersionThan(major int, minor int, patch int) bool {
 	smajor, sminor, spatch := ver.GetReleaseVersion()
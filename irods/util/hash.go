@@ -50,6 +50,24 @@ func HashLocalFile(sourcePath string, hashAlg string) ([]byte, error) {
 	}
 }
 
+// HashReader returns a hash of data read from the reader until EOF
+func HashReader(reader io.Reader, hashAlg string) ([]byte, error) {
+	switch strings.ToLower(hashAlg) {
+	case strings.ToLower(string(types.ChecksumAlgorithmMD5)):
+		return GetHashReader(reader, md5.New())
+	case strings.ToLower(string(types.ChecksumAlgorithmADLER32)):
+		return GetHashReader(reader, adler32.New())
+	case strings.ToLower(string(types.ChecksumAlgorithmSHA1)):
+		return GetHashReader(reader, sha1.New())
+	case strings.ToLower(string(types.ChecksumAlgorithmSHA256)):
+		return GetHashReader(reader, sha256.New())
+	case strings.ToLower(string(types.ChecksumAlgorithmSHA512)):
+		return GetHashReader(reader, sha512.New())
+	default:
+		return nil, xerrors.Errorf("unknown hash algorithm %s", hashAlg)
+	}
+}
+
 func GetHashStrings(strs []string, hashAlg hash.Hash) ([]byte, error) {
 	for _, str := range strs {
 		_, err := hashAlg.Write([]byte(str))
@@ -78,3 +96,13 @@ func GetHashLocalFile(sourcePath string, hashAlg hash.Hash) ([]byte, error) {
 	sumBytes := hashAlg.Sum(nil)
 	return sumBytes, nil
 }
+
+func GetHashReader(reader io.Reader, hashAlg hash.Hash) ([]byte, error) {
+	_, err := io.Copy(hashAlg, reader)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to write: %w", err)
+	}
+
+	sumBytes := hashAlg.Sum(nil)
+	return sumBytes, nil
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
@@ -21,6 +22,15 @@ func GetIRODSDateTime(timestring string) (time.Time, error) {
 	return time.Unix(i64, 0), nil
 }
 
+// GetIRODSDateTimeString returns IRODS time string (seconds since epoch) from time struct
+func GetIRODSDateTimeString(t time.Time) string {
+	if t.IsZero() {
+		return fmt.Sprintf("%011d", 0)
+	}
+
+	return fmt.Sprintf("%011d", t.Unix())
+}
+
 // GetIRODSDateTimeStringForTicket returns IRODS time string from time struct
 func GetIRODSDateTimeStringForTicket(t time.Time) string {
 	if t.IsZero() {
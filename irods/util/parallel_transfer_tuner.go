@@ -0,0 +1,358 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+)
+
+const (
+	// parallelTransferTuneMargin is a ratio of throughput change regarded as a real change, not noise
+	parallelTransferTuneMargin float64 = 0.1
+)
+
+// ParallelTransferTuner chooses the number of tasks and block size of parallel transfers
+// in adaptive mode, it scales them within bounds by throughput of early blocks reported by probes.
+// block size follows measured per-task throughput, the number of tasks is searched by comparing
+// aggregate throughput of transfers using different number of tasks.
+// it is safe for concurrent use
+type ParallelTransferTuner struct {
+	config    *ParallelTransferConfig
+	taskLimit int // bound of the number of tasks, e.g., size of a connection pool, 0 means no bound
+
+	numTasks       int
+	blockSize      int64
+	direction      int // +1 to try more tasks, -1 to try less tasks
+	lastNumTasks   int
+	lastThroughput float64 // bytes per second of all tasks
+	rejections     int     // consecutive tries not better than lastNumTasks
+	settled        bool    // lastNumTasks is better than both directions
+
+	mutex sync.Mutex
+}
+
+// NewParallelTransferTuner creates a new ParallelTransferTuner, nil config uses fixed default limits
+func NewParallelTransferTuner(config *ParallelTransferConfig) *ParallelTransferTuner {
+	return NewParallelTransferTunerWithTaskLimit(config, 0)
+}
+
+// NewParallelTransferTunerWithTaskLimit creates a new ParallelTransferTuner suggesting at most taskLimit tasks in adaptive mode
+// taskLimit is the number of tasks transfers can actually use, e.g., size of a connection pool, 0 means no bound
+func NewParallelTransferTunerWithTaskLimit(config *ParallelTransferConfig, taskLimit int) *ParallelTransferTuner {
+	if config == nil {
+		config = NewParallelTransferConfigWithDefault()
+	}
+
+	if taskLimit < 0 {
+		taskLimit = 0
+	}
+
+	tuner := &ParallelTransferTuner{
+		config:         config,
+		taskLimit:      taskLimit,
+		numTasks:       config.TaskMaxNum,
+		blockSize:      config.BlockSize,
+		direction:      1,
+		lastNumTasks:   0,
+		lastThroughput: 0,
+		rejections:     0,
+		settled:        false,
+		mutex:          sync.Mutex{},
+	}
+
+	if tuner.numTasks <= 0 {
+		tuner.numTasks = TransferTaskMaxNum
+	}
+
+	if tuner.blockSize <= 0 {
+		tuner.blockSize = int64(common.ReadWriteBufferSize)
+	}
+
+	if config.Adaptive {
+		tuner.numTasks = tuner.clampNumTasks(tuner.numTasks)
+		tuner.blockSize = tuner.clampBlockSize(tuner.blockSize)
+	}
+
+	return tuner
+}
+
+// GetConfig returns the config
+func (tuner *ParallelTransferTuner) GetConfig() *ParallelTransferConfig {
+	return tuner.config
+}
+
+// GetNumTasks returns the number of transfer tasks to be used for the data length
+func (tuner *ParallelTransferTuner) GetNumTasks(dataObjectLength int64) int {
+	if tuner == nil {
+		return GetNumTasksForParallelTransfer(dataObjectLength)
+	}
+
+	tuner.mutex.Lock()
+	defer tuner.mutex.Unlock()
+
+	return tuner.config.GetNumTasks(dataObjectLength, tuner.numTasks)
+}
+
+// GetBlockSize returns the block size to be used, it can change while transfers are in progress
+func (tuner *ParallelTransferTuner) GetBlockSize() int {
+	if tuner == nil {
+		return int(GetBlockSizeForParallelTransfer(0))
+	}
+
+	tuner.mutex.Lock()
+	defer tuner.mutex.Unlock()
+
+	return int(tuner.blockSize)
+}
+
+// NewProbe creates a probe to measure a transfer using numTasks tasks
+func (tuner *ParallelTransferTuner) NewProbe(numTasks int) *ParallelTransferProbe {
+	if numTasks <= 0 {
+		numTasks = 1
+	}
+
+	return &ParallelTransferProbe{
+		tuner:   tuner,
+		samples: make([]parallelTransferSample, numTasks),
+		applied: false,
+		mutex:   sync.Mutex{},
+	}
+}
+
+// tune scales block size and the number of tasks from throughput measured by a probe
+// numTasks is the number of tasks of the transfer, measuredTasks is the number of tasks having samples
+func (tuner *ParallelTransferTuner) tune(numTasks int, measuredTasks int, throughput float64) {
+	if !tuner.config.Adaptive || measuredTasks <= 0 || throughput <= 0 {
+		return
+	}
+
+	tuner.mutex.Lock()
+	defer tuner.mutex.Unlock()
+
+	// block size, to transfer a block in target duration
+	blockDuration := tuner.config.AdaptiveBlockDuration
+	if blockDuration <= 0 {
+		blockDuration = TransferAdaptiveBlockDurationDefault
+	}
+
+	taskThroughput := throughput / float64(measuredTasks)
+	tuner.blockSize = tuner.clampBlockSize(roundDownToPowerOfTwo(int64(taskThroughput * blockDuration.Seconds())))
+
+	// number of tasks, only when all tasks are measured
+	// the transfer may use less tasks than suggested, e.g., for a small file, so compare the number of tasks actually used
+	if measuredTasks != numTasks {
+		return
+	}
+
+	if tuner.lastNumTasks == 0 {
+		// first measurement
+		tuner.lastNumTasks = numTasks
+		tuner.lastThroughput = throughput
+		tuner.numTasks = tuner.nextNumTasks(numTasks)
+		return
+	}
+
+	if numTasks == tuner.lastNumTasks {
+		// re-measurement
+		if tuner.settled {
+			changed := throughput > tuner.lastThroughput*(1+parallelTransferTuneMargin) || throughput < tuner.lastThroughput*(1-parallelTransferTuneMargin)
+			tuner.lastThroughput = throughput
+			if !changed {
+				return
+			}
+
+			// network condition changed, search again
+			tuner.settled = false
+			tuner.rejections = 0
+		}
+
+		tuner.lastThroughput = throughput
+		tuner.numTasks = tuner.nextNumTasks(numTasks)
+		return
+	}
+
+	accept := false
+	tried := 1
+	if numTasks > tuner.lastNumTasks {
+		// more tasks must give more throughput
+		accept = throughput > tuner.lastThroughput*(1+parallelTransferTuneMargin)
+	} else {
+		// less tasks is better unless it loses throughput
+		tried = -1
+		accept = throughput >= tuner.lastThroughput*(1-parallelTransferTuneMargin)
+	}
+
+	if accept {
+		tuner.direction = tried
+		tuner.lastNumTasks = numTasks
+		tuner.lastThroughput = throughput
+		tuner.rejections = 0
+		tuner.settled = false
+		tuner.numTasks = tuner.nextNumTasks(numTasks)
+		return
+	}
+
+	// go back and try the other direction next time, stay if both directions are not better
+	tuner.rejections++
+	tuner.settled = tuner.rejections >= 2
+	tuner.direction = -tried
+	tuner.numTasks = tuner.clampNumTasks(tuner.lastNumTasks)
+}
+
+// nextNumTasks returns the number of tasks to try in the current direction
+func (tuner *ParallelTransferTuner) nextNumTasks(numTasks int) int {
+	next := numTasks * 2
+	if tuner.direction < 0 {
+		next = numTasks / 2
+	}
+
+	next = tuner.clampNumTasks(next)
+	if next == numTasks {
+		// reached a bound
+		tuner.direction = -tuner.direction
+	}
+	return next
+}
+
+func (tuner *ParallelTransferTuner) clampNumTasks(numTasks int) int {
+	maxNum := tuner.config.AdaptiveTaskMaxNum
+	if maxNum <= 0 {
+		maxNum = TransferAdaptiveTaskMaxNumDefault
+	}
+
+	if tuner.taskLimit > 0 && maxNum > tuner.taskLimit {
+		maxNum = tuner.taskLimit
+	}
+
+	if numTasks > maxNum {
+		return maxNum
+	} else if numTasks < 1 {
+		return 1
+	}
+	return numTasks
+}
+
+func (tuner *ParallelTransferTuner) clampBlockSize(blockSize int64) int64 {
+	minSize := tuner.config.AdaptiveBlockSizeMin
+	if minSize <= 0 {
+		minSize = TransferAdaptiveBlockSizeMinDefault
+	}
+
+	maxSize := tuner.config.AdaptiveBlockSizeMax
+	if maxSize < minSize {
+		maxSize = minSize
+	}
+
+	if blockSize > maxSize {
+		return maxSize
+	} else if blockSize < minSize {
+		return minSize
+	}
+	return blockSize
+}
+
+func roundDownToPowerOfTwo(value int64) int64 {
+	if value <= 1 {
+		return 1
+	}
+
+	power := int64(1)
+	for power <= value/2 {
+		power *= 2
+	}
+	return power
+}
+
+// parallelTransferSample is throughput samples of a task
+type parallelTransferSample struct {
+	blocks  int
+	size    int64
+	elapsed time.Duration
+}
+
+// ParallelTransferProbe measures throughput of early blocks of a parallel transfer and reports to the tuner
+// it is safe for concurrent use by tasks of the transfer
+type ParallelTransferProbe struct {
+	tuner   *ParallelTransferTuner
+	samples []parallelTransferSample
+	applied bool
+	mutex   sync.Mutex
+}
+
+// GetBlockSize returns the block size to be used for the next block
+func (probe *ParallelTransferProbe) GetBlockSize() int {
+	if probe == nil {
+		return common.ReadWriteBufferSize
+	}
+
+	return probe.tuner.GetBlockSize()
+}
+
+// Record records a block of size bytes transferred by a task in elapsed time
+// only early blocks of each task are measured, the tuner is updated when all tasks have enough samples
+func (probe *ParallelTransferProbe) Record(taskID int, size int64, elapsed time.Duration) {
+	if probe == nil || probe.tuner == nil || !probe.tuner.config.Adaptive {
+		return
+	}
+
+	if taskID < 0 || taskID >= len(probe.samples) || size <= 0 || elapsed <= 0 {
+		return
+	}
+
+	sampleBlocks := probe.tuner.config.AdaptiveSampleBlocks
+	if sampleBlocks <= 0 {
+		sampleBlocks = TransferAdaptiveSampleBlocksDefault
+	}
+
+	probe.mutex.Lock()
+	defer probe.mutex.Unlock()
+
+	if probe.applied || probe.samples[taskID].blocks >= sampleBlocks {
+		return
+	}
+
+	probe.samples[taskID].blocks++
+	probe.samples[taskID].size += size
+	probe.samples[taskID].elapsed += elapsed
+
+	for _, sample := range probe.samples {
+		if sample.blocks < sampleBlocks {
+			return
+		}
+	}
+
+	probe.applyLocked()
+}
+
+// Done reports samples measured so far to the tuner, if not reported yet
+// it must be called when the transfer is finished
+func (probe *ParallelTransferProbe) Done() {
+	if probe == nil || probe.tuner == nil || !probe.tuner.config.Adaptive {
+		return
+	}
+
+	probe.mutex.Lock()
+	defer probe.mutex.Unlock()
+
+	if probe.applied {
+		return
+	}
+
+	probe.applyLocked()
+}
+
+func (probe *ParallelTransferProbe) applyLocked() {
+	probe.applied = true
+
+	measuredTasks := 0
+	throughput := float64(0)
+	for _, sample := range probe.samples {
+		if sample.blocks > 0 && sample.elapsed > 0 {
+			measuredTasks++
+			throughput += float64(sample.size) / sample.elapsed.Seconds()
+		}
+	}
+
+	probe.tuner.tune(len(probe.samples), measuredTasks, throughput)
+}
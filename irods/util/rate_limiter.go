@@ -0,0 +1,102 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limits bytes transferred per second with a token bucket
+// it is safe for concurrent use, the limit can be changed at runtime
+type RateLimiter struct {
+	limit  int64   // bytes per second, 0 means no limit
+	tokens float64 // can be negative while waiters pay back
+	last   time.Time
+	mutex  sync.Mutex
+}
+
+// NewRateLimiter creates a new RateLimiter, limit is bytes per second, 0 means no limit
+func NewRateLimiter(limit int64) *RateLimiter {
+	if limit < 0 {
+		limit = 0
+	}
+
+	return &RateLimiter{
+		limit:  limit,
+		tokens: float64(limit),
+		last:   time.Now(),
+		mutex:  sync.Mutex{},
+	}
+}
+
+// GetLimit returns the limit in bytes per second, 0 means no limit
+func (limiter *RateLimiter) GetLimit() int64 {
+	if limiter == nil {
+		return 0
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	return limiter.limit
+}
+
+// SetLimit changes the limit in bytes per second, 0 removes the limit
+// transfers in progress follow the new limit from the next block
+func (limiter *RateLimiter) SetLimit(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	limiter.refill(now)
+
+	limiter.limit = limit
+	if limit == 0 || limiter.tokens > float64(limit) {
+		limiter.tokens = float64(limit)
+	}
+}
+
+// Wait blocks until size bytes can be transferred under the limit
+func (limiter *RateLimiter) Wait(size int) {
+	if limiter == nil || size <= 0 {
+		return
+	}
+
+	limiter.mutex.Lock()
+	if limiter.limit <= 0 {
+		limiter.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	limiter.refill(now)
+	limiter.tokens -= float64(size)
+
+	delay := time.Duration(0)
+	if limiter.tokens < 0 {
+		delay = time.Duration(-limiter.tokens / float64(limiter.limit) * float64(time.Second))
+	}
+	limiter.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// refill adds tokens for time elapsed, up to one second of transfer
+func (limiter *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(limiter.last).Seconds()
+	limiter.last = now
+
+	if limiter.limit <= 0 || elapsed <= 0 {
+		return
+	}
+
+	limiter.tokens += elapsed * float64(limiter.limit)
+	if limiter.tokens > float64(limiter.limit) {
+		limiter.tokens = float64(limiter.limit)
+	}
+}
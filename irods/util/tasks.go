@@ -1,36 +1,114 @@
 package util
 
+import (
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+)
+
 const (
 	// TransferTaskMinLength is a minimum data length of a task for parallel data transfer
 	TransferTaskMinLength int64 = 32 * 1024 * 1024 // 32MB
 	// TransferTaskMaxNum is a maximum number of tasks for parallel data transfer
 	TransferTaskMaxNum int = 4
-	// TransferBlockSize is a block size of a task
-	TransferBlockSize int64 = 1024 * 1024 // 1MB
+	// TransferBlockSize is a default block size of a task
+	TransferBlockSize int64 = int64(common.ReadWriteBufferSize)
+
+	// TransferAdaptiveTaskMaxNumDefault is a default upper bound of tasks in adaptive mode
+	TransferAdaptiveTaskMaxNumDefault int = 16
+	// TransferAdaptiveBlockSizeMinDefault is a default lower bound of block size in adaptive mode
+	TransferAdaptiveBlockSizeMinDefault int64 = 64 * 1024 // 64KB
+	// TransferAdaptiveBlockSizeMaxDefault is a default upper bound of block size in adaptive mode
+	TransferAdaptiveBlockSizeMaxDefault int64 = 16 * 1024 * 1024 // 16MB
+	// TransferAdaptiveSampleBlocksDefault is a default number of early blocks of a task measured in adaptive mode
+	TransferAdaptiveSampleBlocksDefault int = 4
+	// TransferAdaptiveBlockDurationDefault is a default time to transfer a block that adaptive mode aims for
+	TransferAdaptiveBlockDurationDefault time.Duration = 250 * time.Millisecond
 )
 
-// GetNumTasksForParallelTransfer returns the number transfer tasks to be used
-func GetNumTasksForParallelTransfer(dataObjectLength int64) int {
-	if dataObjectLength <= TransferTaskMinLength {
+// ParallelTransferConfig is a configuration for parallel data transfer
+type ParallelTransferConfig struct {
+	// TaskMinLength is a minimum data length of a task, data is not split into smaller tasks
+	TaskMinLength int64
+	// TaskMaxNum is a maximum number of tasks, in adaptive mode, it is the number of tasks to start with
+	TaskMaxNum int
+	// BlockSize is a size of a block read or written at once by a task, in adaptive mode, it is the block size to start with
+	BlockSize int64
+
+	// Adaptive enables scaling of the number of tasks and block size by throughput measured from early blocks
+	Adaptive bool
+	// AdaptiveTaskMaxNum is an upper bound of tasks in adaptive mode
+	AdaptiveTaskMaxNum int
+	// AdaptiveBlockSizeMin is a lower bound of block size in adaptive mode
+	AdaptiveBlockSizeMin int64
+	// AdaptiveBlockSizeMax is an upper bound of block size in adaptive mode
+	AdaptiveBlockSizeMax int64
+	// AdaptiveSampleBlocks is the number of early blocks of each task measured for throughput
+	AdaptiveSampleBlocks int
+	// AdaptiveBlockDuration is a time to transfer a block that block size is scaled for
+	AdaptiveBlockDuration time.Duration
+}
+
+// NewParallelTransferConfigWithDefault creates a ParallelTransferConfig with fixed default limits
+func NewParallelTransferConfigWithDefault() *ParallelTransferConfig {
+	return &ParallelTransferConfig{
+		TaskMinLength: TransferTaskMinLength,
+		TaskMaxNum:    TransferTaskMaxNum,
+		BlockSize:     TransferBlockSize,
+
+		Adaptive:              false,
+		AdaptiveTaskMaxNum:    TransferAdaptiveTaskMaxNumDefault,
+		AdaptiveBlockSizeMin:  TransferAdaptiveBlockSizeMinDefault,
+		AdaptiveBlockSizeMax:  TransferAdaptiveBlockSizeMaxDefault,
+		AdaptiveSampleBlocks:  TransferAdaptiveSampleBlocksDefault,
+		AdaptiveBlockDuration: TransferAdaptiveBlockDurationDefault,
+	}
+}
+
+// NewParallelTransferConfigAdaptive creates a ParallelTransferConfig with adaptive mode enabled
+func NewParallelTransferConfigAdaptive() *ParallelTransferConfig {
+	config := NewParallelTransferConfigWithDefault()
+	config.Adaptive = true
+	return config
+}
+
+// GetNumTasks returns the number of transfer tasks for the data length with the given max number of tasks
+func (config *ParallelTransferConfig) GetNumTasks(dataObjectLength int64, taskMaxNum int) int {
+	taskMinLength := config.TaskMinLength
+	if taskMinLength <= 0 {
+		taskMinLength = TransferTaskMinLength
+	}
+
+	if taskMaxNum <= 0 {
+		taskMaxNum = 1
+	}
+
+	if dataObjectLength <= taskMinLength {
 		return 1
 	}
 
-	numTasks := int(dataObjectLength / TransferTaskMinLength)
-	if dataObjectLength%TransferTaskMinLength > 0 {
+	numTasks := int(dataObjectLength / taskMinLength)
+	if dataObjectLength%taskMinLength > 0 {
 		numTasks++
 	}
 
 	if numTasks <= 1 {
 		return 1
-	} else if numTasks > TransferTaskMaxNum {
+	} else if numTasks > taskMaxNum {
 		// too many tasks
-		return TransferTaskMaxNum
+		return taskMaxNum
 	}
 
 	return numTasks
 }
 
-// GetBlockSizeForParallelTransfer returns the block size
+// GetNumTasksForParallelTransfer returns the number transfer tasks to be used with the default config
+func GetNumTasksForParallelTransfer(dataObjectLength int64) int {
+	config := NewParallelTransferConfigWithDefault()
+	return config.GetNumTasks(dataObjectLength, config.TaskMaxNum)
+}
+
+// GetBlockSizeForParallelTransfer returns the block size to be used with the default config
 func GetBlockSizeForParallelTransfer(dataObjectLength int64) int64 {
-	return TransferBlockSize
+	return NewParallelTransferConfigWithDefault().BlockSize
 }
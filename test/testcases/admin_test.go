@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	irods_fs "github.com/phdavis1027/go-irodsclient/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/auth"
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/fs"
 	"github.com/phdavis1027/go-irodsclient/irods/types"
@@ -19,8 +21,11 @@ func TestAdmin(t *testing.T) {
 	t.Run("test EncoderRing", testEncoderRing)
 	t.Run("test Scramble", testScramble)
 	t.Run("test ClientSignature", testClientSignature)
+	t.Run("test GenerateTempPassword", testGenerateTempPassword)
 
 	t.Run("test CreateAndRemoveUser", testCreateAndRemoveUser)
+	t.Run("test TempPasswordAccount", testTempPasswordAccount)
+	t.Run("test ProxyFileSystemFactory", testProxyFileSystemFactory)
 }
 
 func testEncoderRing(t *testing.T) {
@@ -37,6 +42,15 @@ func testScramble(t *testing.T) {
 	assert.Equal(t, ";E3O&GDl4!&_$3GBd+B\"", scrPass2)
 }
 
+func testGenerateTempPassword(t *testing.T) {
+	// md5 of challenge and password in a zero-filled 100 bytes buffer
+	tempPass1 := auth.GenerateTempPassword("e7bbcd5a6e0ffa5a3e2b9e37c5f2d1b0", "rods")
+	assert.Equal(t, "a5367bfafb470ad2b47611a151ad67af", tempPass1)
+
+	tempPass2 := auth.GenerateTempPassword("1bc29b36f623ba82aaf6724fd3b16718", "testpassword_!@#")
+	assert.Equal(t, "40a857052e1ecca0ec7b0fbdd7cd1257", tempPass2)
+}
+
 func testClientSignature(t *testing.T) {
 	account := GetTestAccount()
 
@@ -102,3 +116,136 @@ func testCreateAndRemoveUser(t *testing.T) {
 	assert.Error(t, err)
 	userConn.Disconnect()
 }
+
+func testTempPasswordAccount(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	conn := connection.NewIRODSConnection(account, 300*time.Second, "go-irodsclient-test")
+	err := conn.Connect()
+	failError(t, err)
+	defer conn.Disconnect()
+
+	testUsername := "test_temp_password_user"
+
+	err = fs.CreateUser(conn, testUsername, account.ClientZone, "rodsuser")
+	failError(t, err)
+	defer fs.RemoveUser(conn, testUsername, account.ClientZone)
+
+	filesystem, err := irods_fs.NewFileSystem(account, irods_fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	// current user
+	tempAccount, err := filesystem.GetTempPasswordAccount("")
+	failError(t, err)
+	assert.Equal(t, account.ClientUser, tempAccount.ClientUser)
+	assert.NotEqual(t, account.Password, tempAccount.Password)
+
+	tempConn := connection.NewIRODSConnection(tempAccount, 300*time.Second, "go-irodsclient-test")
+	err = tempConn.Connect()
+	failError(t, err)
+	tempConn.Disconnect()
+
+	// other user
+	tempAccount, err = filesystem.GetTempPasswordAccount(testUsername)
+	failError(t, err)
+	assert.Equal(t, testUsername, tempAccount.ClientUser)
+	assert.Equal(t, testUsername, tempAccount.ProxyUser)
+
+	tempConn = connection.NewIRODSConnection(tempAccount, 300*time.Second, "go-irodsclient-test")
+	err = tempConn.Connect()
+	failError(t, err)
+	tempConn.Disconnect()
+}
+
+func testProxyFileSystemFactory(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	conn := connection.NewIRODSConnection(account, 300*time.Second, "go-irodsclient-test")
+	err := conn.Connect()
+	failError(t, err)
+	defer conn.Disconnect()
+
+	testUsernames := []string{"test_proxy_user1", "test_proxy_user2"}
+	for _, testUsername := range testUsernames {
+		err = fs.CreateUser(conn, testUsername, account.ClientZone, "rodsuser")
+		failError(t, err)
+	}
+
+	defer func() {
+		for _, testUsername := range testUsernames {
+			fs.RemoveUser(conn, testUsername, account.ClientZone)
+		}
+	}()
+
+	fsConfig := irods_fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+	fsConfig.ConnectionMax = 1
+
+	// allow only one file system at a time
+	factory, err := irods_fs.NewProxyFileSystemFactory(account, fsConfig, fsConfig.ConnectionMax+irods_fs.FileSystemConnectionMetaDefault)
+	failError(t, err)
+	defer factory.Release()
+
+	for _, testUsername := range testUsernames {
+		filesystem, err := factory.AcquireFileSystem(testUsername)
+		failError(t, err)
+
+		assert.Equal(t, testUsername, filesystem.GetAccount().ClientUser)
+		assert.Equal(t, account.ProxyUser, filesystem.GetAccount().ProxyUser)
+
+		homePath := "/" + account.ClientZone + "/home/" + testUsername
+		entry, err := filesystem.Stat(homePath)
+		failError(t, err)
+		assert.Equal(t, homePath, entry.Path)
+
+		// cached
+		filesystem2, err := factory.AcquireFileSystem(testUsername)
+		failError(t, err)
+		assert.Equal(t, filesystem.GetID(), filesystem2.GetID())
+
+		// the previous user's file system is evicted
+		assert.Equal(t, 1, factory.FileSystems())
+
+		factory.ReturnFileSystem(filesystem2)
+		factory.ReturnFileSystem(filesystem)
+	}
+
+	// a file system held by a caller is not evicted
+	filesystem, err := factory.AcquireFileSystem(testUsernames[1])
+	failError(t, err)
+
+	_, err = factory.AcquireFileSystem(testUsernames[0])
+	assert.Error(t, err)
+	assert.True(t, types.IsConnectionPoolFullError(err))
+
+	// released when returned
+	factory.ReleaseFileSystem(testUsernames[1], "")
+	assert.Equal(t, 0, factory.FileSystems())
+
+	_, err = filesystem.Stat("/" + account.ClientZone + "/home/" + testUsernames[1])
+	failError(t, err)
+
+	factory.ReturnFileSystem(filesystem)
+	assert.Equal(t, 0, filesystem.ConnectionTotal())
+
+	// a cached file system acquired again becomes idle when returned
+	filesystem, err = factory.AcquireFileSystem(testUsernames[0])
+	failError(t, err)
+	factory.ReturnFileSystem(filesystem)
+
+	filesystem2, err := factory.AcquireFileSystem(testUsernames[0])
+	failError(t, err)
+	assert.Equal(t, filesystem.GetID(), filesystem2.GetID())
+	factory.ReturnFileSystem(filesystem2)
+
+	factory.SetIdleTimeout(1 * time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	factory.ReleaseIdleFileSystems()
+	assert.Equal(t, 0, factory.FileSystems())
+	assert.Equal(t, 0, filesystem.ConnectionTotal())
+}
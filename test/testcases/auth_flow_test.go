@@ -0,0 +1,409 @@
+package testcases
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/auth"
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/message"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	authFlowTestPassword  string = "test_password"
+	authFlowTestChallenge string = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestAuthFlow(t *testing.T) {
+	t.Run("test AuthenticationMessages", testAuthenticationMessages)
+	t.Run("test NativeAuthFlow", testNativeAuthFlow)
+	t.Run("test CustomAuthFlow", testCustomAuthFlow)
+}
+
+// authFlowTestHandler handles a request to the authentication API, returns the response and the result code
+type authFlowTestHandler func(request map[string]interface{}) (map[string]interface{}, int32)
+
+// startTestAuthFlowServer runs a fake iRODS 4.3 server that starts up connections and answers the authentication API
+func startTestAuthFlowServer(t *testing.T, handler authFlowTestHandler) net.Listener {
+	return startTestListener(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		header, _, err := readTestMessage(conn)
+		if err != nil || header.Type != message.RODS_MESSAGE_CONNECT_TYPE {
+			return
+		}
+
+		version := message.IRODSMessageVersion{
+			Status:         0,
+			ReleaseVersion: "rods4.3.0",
+			APIVersion:     "d",
+			ReconnectPort:  0,
+			ReconnectAddr:  "",
+			Cookie:         400,
+		}
+
+		versionMessage, err := version.GetMessage()
+		if err != nil {
+			return
+		}
+
+		err = writeTestMessage(conn, versionMessage.Body)
+		if err != nil {
+			return
+		}
+
+		for {
+			header, body, err := readTestMessage(conn)
+			if err != nil || header.Type == message.RODS_MESSAGE_DISCONNECT_TYPE {
+				return
+			}
+
+			reply := &message.IRODSMessageBody{
+				Type:    message.RODS_MESSAGE_API_REPLY_TYPE,
+				Message: nil,
+				Error:   nil,
+				Bs:      nil,
+				IntInfo: int32(common.SYS_API_INPUT_ERR),
+			}
+
+			if header.IntInfo == int32(common.AUTHENTICATION_APN) {
+				request := message.IRODSMessageAuthenticationRequest{}
+				err = request.FromBytes(body[:header.MessageLen])
+				if err != nil {
+					return
+				}
+
+				response, result := handler(request.Body)
+				reply.IntInfo = result
+				if response != nil {
+					responseBytes, err := message.NewIRODSMessageAuthenticationRequest(response).GetBytes()
+					if err != nil {
+						return
+					}
+					reply.Message = responseBytes
+				}
+			}
+
+			err = writeTestMessage(conn, reply)
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+func readTestMessage(conn net.Conn) (*message.IRODSMessageHeader, []byte, error) {
+	headerLenBuffer := make([]byte, 4)
+	_, err := io.ReadFull(conn, headerLenBuffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headerBuffer := make([]byte, binary.BigEndian.Uint32(headerLenBuffer))
+	_, err = io.ReadFull(conn, headerBuffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := message.IRODSMessageHeader{}
+	err = header.FromBytes(headerBuffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := make([]byte, header.MessageLen+header.ErrorLen+header.BsLen)
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &header, body, nil
+}
+
+func writeTestMessage(conn net.Conn, body *message.IRODSMessageBody) error {
+	header, err := body.BuildHeader()
+	if err != nil {
+		return err
+	}
+
+	headerBytes, err := header.GetBytes()
+	if err != nil {
+		return err
+	}
+
+	bodyBytes, err := body.GetBytes()
+	if err != nil {
+		return err
+	}
+
+	headerLenBuffer := make([]byte, 4)
+	binary.BigEndian.PutUint32(headerLenBuffer, uint32(len(headerBytes)))
+
+	_, err = conn.Write(append(append(headerLenBuffer, headerBytes...), bodyBytes...))
+	return err
+}
+
+// handleTestNativeAuthFlow answers requests of native authentication of the auth framework
+func handleTestNativeAuthFlow(request map[string]interface{}) (map[string]interface{}, int32) {
+	switch request[connection.AuthFlowNextOperationKey] {
+	case connection.AuthFlowAgentAuthRequest:
+		response := map[string]interface{}{}
+		for k, v := range request {
+			response[k] = v
+		}
+		response[connection.AuthFlowRequestResultKey] = authFlowTestChallenge
+		return response, 0
+	case connection.AuthFlowAgentAuthResponse:
+		digest := auth.GenerateAuthResponse([]byte(authFlowTestChallenge), authFlowTestPassword)
+		if request[connection.AuthFlowDigestKey] != digest {
+			return nil, int32(common.CAT_INVALID_AUTHENTICATION)
+		}
+		return map[string]interface{}{}, 0
+	default:
+		return nil, int32(common.SYS_API_INPUT_ERR)
+	}
+}
+
+func newAuthFlowTestAccount(t *testing.T, listener net.Listener, password string) *types.IRODSAccount {
+	host, portString, err := net.SplitHostPort(listener.Addr().String())
+	failError(t, err)
+
+	port, err := strconv.Atoi(portString)
+	failError(t, err)
+
+	account, err := types.CreateIRODSAccount(host, port, "testuser", "tempZone", types.AuthSchemeNative, password, "")
+	failError(t, err)
+
+	account.ClientServerNegotiation = false
+	return account
+}
+
+func testAuthenticationMessages(t *testing.T) {
+	body := map[string]interface{}{
+		connection.AuthFlowSchemeKey:        connection.AuthFlowNativeSchemeName,
+		connection.AuthFlowNextOperationKey: connection.AuthFlowAgentAuthRequest,
+		connection.AuthFlowUserNameKey:      "testuser",
+	}
+
+	requestBytes, err := message.NewIRODSMessageAuthenticationRequest(body).GetBytes()
+	failError(t, err)
+
+	request := message.IRODSMessageAuthenticationRequest{}
+	err = request.FromBytes(requestBytes)
+	failError(t, err)
+	assert.Equal(t, body, request.Body)
+
+	// the server sends the same format
+	response := message.IRODSMessageAuthenticationResponse{}
+	err = response.FromBytes(requestBytes)
+	failError(t, err)
+	assert.Equal(t, body, response.Body)
+
+	// with trailing nulls
+	jsonBody, err := json.Marshal(body)
+	failError(t, err)
+
+	paddedJSONBody := append(jsonBody, '\x00', '\x00')
+	paddedBytes, err := xml.Marshal(message.IRODSMessageBinBytesBuf{
+		Length: len(paddedJSONBody),
+		Data:   base64.StdEncoding.EncodeToString(paddedJSONBody),
+	})
+	failError(t, err)
+
+	paddedResponse := message.IRODSMessageAuthenticationResponse{}
+	err = paddedResponse.FromBytes(paddedBytes)
+	failError(t, err)
+	assert.Equal(t, body, paddedResponse.Body)
+
+	requestMessage, err := message.NewIRODSMessageAuthenticationRequest(body).GetMessage()
+	failError(t, err)
+	assert.Equal(t, int32(common.AUTHENTICATION_APN), requestMessage.Body.IntInfo)
+
+	err = response.FromMessage(&message.IRODSMessage{
+		Header: nil,
+		Body: &message.IRODSMessageBody{
+			Type:    message.RODS_MESSAGE_API_REPLY_TYPE,
+			Message: nil,
+			Error:   nil,
+			Bs:      nil,
+			IntInfo: int32(common.CAT_INVALID_AUTHENTICATION),
+		},
+	})
+	failError(t, err)
+	assert.Error(t, response.CheckError())
+
+	// auth plugin messages
+	pluginRequestBytes, err := message.NewIRODSMessageAuthPluginRequest("pam", "a_user=testuser;a_pw=pa\\;ss").GetBytes()
+	failError(t, err)
+
+	pluginRequest := message.IRODSMessageAuthPluginRequest{}
+	err = pluginRequest.FromBytes(pluginRequestBytes)
+	failError(t, err)
+	assert.Equal(t, "pam", pluginRequest.AuthScheme)
+	assert.Equal(t, "a_user=testuser;a_pw=pa\\;ss", pluginRequest.Context)
+}
+
+func testNativeAuthFlow(t *testing.T) {
+	listener := startTestAuthFlowServer(t, handleTestNativeAuthFlow)
+	defer listener.Close()
+
+	// the server supports the auth framework, so native authentication runs the flow
+	account := newAuthFlowTestAccount(t, listener, authFlowTestPassword)
+
+	conn := connection.NewIRODSConnection(account, 10*time.Second, "go-irodsclient-test")
+	err := conn.Connect()
+	failError(t, err)
+	assert.True(t, conn.SupportAuthFlow())
+	assert.NotEmpty(t, conn.GetClientSignature())
+	conn.Disconnect()
+
+	// wrong password
+	account = newAuthFlowTestAccount(t, listener, "wrong_password")
+
+	conn = connection.NewIRODSConnection(account, 10*time.Second, "go-irodsclient-test")
+	err = conn.Connect()
+	assert.Error(t, err)
+	assert.True(t, types.IsAuthError(err))
+}
+
+func testCustomAuthFlow(t *testing.T) {
+	// the custom scheme answers with a token, then expects it back
+	listener := startTestAuthFlowServer(t, func(request map[string]interface{}) (map[string]interface{}, int32) {
+		if request[connection.AuthFlowSchemeKey] != "custom" {
+			return handleTestNativeAuthFlow(request)
+		}
+
+		switch request[connection.AuthFlowNextOperationKey] {
+		case "custom_server_token":
+			return map[string]interface{}{
+				connection.AuthFlowRequestResultKey: "token-1",
+			}, 0
+		case "custom_server_verify":
+			if request["token"] != "token-1" {
+				return nil, int32(common.CAT_INVALID_AUTHENTICATION)
+			}
+			return map[string]interface{}{
+				connection.AuthFlowRequestResultKey: "verified",
+			}, 0
+		default:
+			return nil, int32(common.SYS_API_INPUT_ERR)
+		}
+	})
+	defer listener.Close()
+
+	account := newAuthFlowTestAccount(t, listener, authFlowTestPassword)
+
+	conn := connection.NewIRODSConnection(account, 10*time.Second, "go-irodsclient-test")
+	err := conn.Connect()
+	failError(t, err)
+	defer conn.Disconnect()
+
+	steps := []string{}
+	result := ""
+
+	flow := &connection.AuthFlow{
+		Scheme: "custom",
+		Operations: map[string]connection.AuthFlowOperation{
+			connection.AuthFlowClientStart: func(conn *connection.IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				steps = append(steps, connection.AuthFlowClientStart)
+				assert.Equal(t, "custom", state[connection.AuthFlowSchemeKey])
+				assert.Equal(t, "initial", state["initial"])
+
+				state[connection.AuthFlowNextOperationKey] = "custom_token"
+				return state, nil
+			},
+			"custom_token": func(conn *connection.IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				steps = append(steps, "custom_token")
+				response, err := conn.RequestAuthentication(map[string]interface{}{
+					connection.AuthFlowSchemeKey:        "custom",
+					connection.AuthFlowNextOperationKey: "custom_server_token",
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				state["token"] = response[connection.AuthFlowRequestResultKey]
+				state[connection.AuthFlowNextOperationKey] = "custom_verify"
+				return state, nil
+			},
+			"custom_verify": func(conn *connection.IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				steps = append(steps, "custom_verify")
+				response, err := conn.RequestAuthentication(map[string]interface{}{
+					connection.AuthFlowSchemeKey:        "custom",
+					connection.AuthFlowNextOperationKey: "custom_server_verify",
+					"token":                             state["token"],
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				result, _ = response[connection.AuthFlowRequestResultKey].(string)
+				state[connection.AuthFlowNextOperationKey] = connection.AuthFlowComplete
+				return state, nil
+			},
+		},
+	}
+
+	conn.Lock()
+	err = flow.Run(conn, map[string]interface{}{
+		"initial": "initial",
+	})
+	conn.Unlock()
+	failError(t, err)
+
+	assert.Equal(t, []string{connection.AuthFlowClientStart, "custom_token", "custom_verify"}, steps)
+	assert.Equal(t, "verified", result)
+
+	// an operation not in the flow fails
+	brokenFlow := &connection.AuthFlow{
+		Scheme: "custom",
+		Operations: map[string]connection.AuthFlowOperation{
+			connection.AuthFlowClientStart: func(conn *connection.IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				state[connection.AuthFlowNextOperationKey] = "missing"
+				return state, nil
+			},
+		},
+	}
+
+	conn.Lock()
+	err = brokenFlow.Run(conn, map[string]interface{}{})
+	conn.Unlock()
+	assert.Error(t, err)
+	assert.True(t, types.IsAuthError(err))
+
+	// errors from the server stop the flow
+	rejectedFlow := &connection.AuthFlow{
+		Scheme: "custom",
+		Operations: map[string]connection.AuthFlowOperation{
+			connection.AuthFlowClientStart: func(conn *connection.IRODSConnection, state map[string]interface{}) (map[string]interface{}, error) {
+				_, err := conn.RequestAuthentication(map[string]interface{}{
+					connection.AuthFlowSchemeKey:        "custom",
+					connection.AuthFlowNextOperationKey: "custom_server_verify",
+					"token":                             "wrong",
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				state[connection.AuthFlowNextOperationKey] = connection.AuthFlowComplete
+				return state, nil
+			},
+		},
+	}
+
+	conn.Lock()
+	err = rejectedFlow.Run(conn, map[string]interface{}{})
+	conn.Unlock()
+	assert.Error(t, err)
+	assert.True(t, types.IsAuthError(err))
+}
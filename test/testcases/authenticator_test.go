@@ -0,0 +1,61 @@
+package testcases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticator(t *testing.T) {
+	t.Run("test BuiltinAuthenticators", testBuiltinAuthenticators)
+	t.Run("test RegisterAuthenticator", testRegisterAuthenticator)
+	t.Run("test PAMAuthPluginContext", testPAMAuthPluginContext)
+}
+
+func testBuiltinAuthenticators(t *testing.T) {
+	assert.IsType(t, &connection.NativeAuthenticator{}, connection.GetAuthenticator(types.AuthSchemeNative))
+	assert.IsType(t, &connection.GSIAuthenticator{}, connection.GetAuthenticator(types.AuthSchemeGSI))
+	assert.IsType(t, &connection.PAMAuthenticator{}, connection.GetAuthenticator(types.AuthSchemePAM))
+	assert.IsType(t, &connection.AuthPluginAuthenticator{}, connection.GetAuthenticator(types.AuthSchemePAMPlugin))
+
+	assert.Equal(t, types.AuthSchemePAMPlugin, types.GetAuthScheme("PAM_Plugin"))
+}
+
+func testRegisterAuthenticator(t *testing.T) {
+	authenticator := connection.AuthenticatorFunc(func(conn *connection.IRODSConnection) error {
+		return nil
+	})
+
+	// scheme names are case-insensitive
+	connection.RegisterAuthenticator(types.AuthScheme("PAM_Custom"), authenticator)
+	defer connection.UnregisterAuthenticator(types.AuthScheme("pam_custom"))
+
+	scheme := types.GetAuthScheme(" pam_custom ")
+	assert.Equal(t, types.AuthScheme("pam_custom"), scheme)
+	assert.NotNil(t, connection.GetAuthenticator(scheme))
+	assert.NotNil(t, connection.GetAuthenticator(types.AuthScheme("PAM_Custom")))
+
+	connection.UnregisterAuthenticator(types.AuthScheme("PAM_CUSTOM"))
+	assert.Nil(t, connection.GetAuthenticator(scheme))
+
+	// the scheme name is no longer accepted
+	assert.Equal(t, types.AuthSchemeUnknown, types.GetAuthScheme("pam_custom"))
+}
+
+func testPAMAuthPluginContext(t *testing.T) {
+	account, err := types.CreateIRODSAccount("localhost", 1247, "pamuser", "tempZone", types.AuthSchemePAMPlugin, `pa;ss=wo\rd`, "")
+	failError(t, err)
+	account.PamTTL = 2
+
+	conn := connection.NewIRODSConnection(account, 300*time.Second, "go-irodsclient-test")
+
+	authenticator := connection.NewPAMAuthPluginAuthenticator()
+	context, err := authenticator.BuildContext(conn)
+	failError(t, err)
+
+	// delimiters in the password are escaped
+	assert.Equal(t, `a_user=pamuser;a_pw=pa\;ss\=wo\\rd;a_ttl=2`, context)
+}
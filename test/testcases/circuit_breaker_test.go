@@ -0,0 +1,133 @@
+package testcases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("test CircuitBreakerTransitions", testCircuitBreakerTransitions)
+	t.Run("test CircuitBreakerPermanentFailure", testCircuitBreakerPermanentFailure)
+	t.Run("test CircuitBreakerReleaseProbe", testCircuitBreakerReleaseProbe)
+	t.Run("test CircuitBreakerSessionCallback", testCircuitBreakerSessionCallback)
+}
+
+func testCircuitBreakerTransitions(t *testing.T) {
+	config := session.NewCircuitBreakerConfig(2, 1, 50*time.Millisecond, false)
+	breaker := session.NewCircuitBreaker(config)
+
+	events := []*session.CircuitBreakerEvent{}
+	breaker.AddCallback(func(event *session.CircuitBreakerEvent) {
+		events = append(events, event)
+	})
+
+	assert.NoError(t, breaker.Allow())
+
+	// below threshold
+	breaker.RecordFailure(types.NewConnectionError())
+	assert.Equal(t, session.CircuitStateClosed, breaker.GetState())
+	assert.NoError(t, breaker.Allow())
+
+	// open
+	breaker.RecordFailure(types.NewConnectionError())
+	assert.Equal(t, session.CircuitStateOpen, breaker.GetState())
+	assert.Error(t, breaker.Allow())
+
+	// half-open after cool-down, only one probe
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, session.CircuitStateHalfOpen, breaker.GetState())
+	assert.Error(t, breaker.Allow())
+
+	// probe failed
+	breaker.RecordFailure(types.NewConnectionError())
+	assert.Equal(t, session.CircuitStateOpen, breaker.GetState())
+
+	// probe succeeded
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, breaker.Allow())
+	breaker.RecordSuccess()
+	assert.Equal(t, session.CircuitStateClosed, breaker.GetState())
+	assert.NoError(t, breaker.Allow())
+
+	_, lastErr := breaker.GetLastError()
+	assert.NoError(t, lastErr)
+
+	assert.Len(t, events, 5)
+	assert.Equal(t, session.CircuitStateClosed, events[0].From)
+	assert.Equal(t, session.CircuitStateOpen, events[0].To)
+	assert.Equal(t, session.CircuitStateClosed, events[4].To)
+}
+
+func testCircuitBreakerPermanentFailure(t *testing.T) {
+	config := session.NewCircuitBreakerConfig(5, 1, time.Minute, false)
+	breaker := session.NewCircuitBreaker(config)
+
+	// permanent failures open the circuit immediately
+	breaker.RecordFailure(types.NewAuthError(&types.IRODSAccount{}))
+	assert.Equal(t, session.CircuitStateOpen, breaker.GetState())
+
+	breaker.Reset()
+	assert.Equal(t, session.CircuitStateClosed, breaker.GetState())
+	assert.NoError(t, breaker.Allow())
+}
+
+func testCircuitBreakerReleaseProbe(t *testing.T) {
+	config := session.NewCircuitBreakerConfig(1, 1, 50*time.Millisecond, false)
+	breaker := session.NewCircuitBreaker(config)
+
+	breaker.RecordFailure(types.NewConnectionError())
+	time.Sleep(100 * time.Millisecond)
+
+	// probe gave up without reaching the server
+	assert.NoError(t, breaker.Allow())
+	assert.Error(t, breaker.Allow())
+	breaker.ReleaseProbe()
+
+	// another request can probe
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, session.CircuitStateHalfOpen, breaker.GetState())
+	breaker.RecordSuccess()
+	assert.Equal(t, session.CircuitStateClosed, breaker.GetState())
+}
+
+func testCircuitBreakerSessionCallback(t *testing.T) {
+	// nothing listens on the port
+	account, err := types.CreateIRODSAccount("127.0.0.1", 1, "rods", "tempZone", types.AuthSchemeNative, "rods", "")
+	failError(t, err)
+
+	account.ClientServerNegotiation = false
+
+	sessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	sessionConfig.CircuitBreaker = session.NewCircuitBreakerConfig(1, 1, time.Minute, false)
+
+	sess, err := session.NewIRODSSession(account, sessionConfig)
+	failError(t, err)
+	defer sess.Release()
+
+	connectionTotal := -1
+	sess.AddCircuitBreakerCallback(func(event *session.CircuitBreakerEvent) {
+		// callbacks run after the session lock is released
+		connectionTotal = sess.ConnectionTotal()
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, acquireErr := sess.AcquireConnection()
+		done <- acquireErr
+	}()
+
+	select {
+	case acquireErr := <-done:
+		assert.Error(t, acquireErr)
+	case <-time.After(10 * time.Second):
+		assert.FailNow(t, "callback deadlocked on the session")
+	}
+
+	assert.Equal(t, session.CircuitStateOpen, sess.GetCircuitState())
+	assert.Equal(t, 0, connectionTotal)
+}
@@ -17,6 +17,7 @@ func TestIRODSConnection(t *testing.T) {
 	t.Run("test IRODS Connection", testIRODSConnection)
 	t.Run("test IRODS Invalid Username", testIRODSInvalidUsername)
 	t.Run("test IRODS Connection with Negotiation", testIRODSConnectionWithNegotiation)
+	t.Run("test IRODS Connection Heartbeat", testIRODSConnectionHeartbeat)
 }
 
 func testIRODSConnection(t *testing.T) {
@@ -65,3 +66,29 @@ func testIRODSConnectionWithNegotiation(t *testing.T) {
 	verMajor, _, _ := ver.GetReleaseVersion()
 	assert.GreaterOrEqual(t, 4, verMajor)
 }
+
+func testIRODSConnectionHeartbeat(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+	account.CSNegotiationPolicy = types.CSNegotiationDontCare
+
+	conn := connection.NewIRODSConnection(account, 300*time.Second, "go-irodsclient-test")
+	conn.SetTCPKeepAlivePeriod(30 * time.Second)
+	conn.SetHeartbeatInterval(1 * time.Second)
+	err := conn.Connect()
+	failError(t, err)
+	defer conn.Disconnect()
+
+	lastAccess := conn.GetLastSuccessfulAccess()
+
+	time.Sleep(3500 * time.Millisecond)
+
+	metrics := conn.GetMetrics()
+	assert.GreaterOrEqual(t, metrics.GetCounterForHeartbeats(), uint64(1))
+	assert.Equal(t, uint64(0), metrics.GetCounterForHeartbeatFailures())
+	assert.True(t, conn.IsConnected())
+
+	// heartbeats do not count as activities
+	assert.Equal(t, lastAccess, conn.GetLastSuccessfulAccess())
+}
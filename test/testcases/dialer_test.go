@@ -0,0 +1,148 @@
+package testcases
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialer(t *testing.T) {
+	t.Run("test SOCKS5Dialer", testSOCKS5Dialer)
+	t.Run("test HTTPConnectDialer", testHTTPConnectDialer)
+}
+
+// startTestListener runs handler for each accepted connection
+func startTestListener(t *testing.T, handler func(conn net.Conn)) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	failError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go handler(conn)
+		}
+	}()
+
+	return listener
+}
+
+func startTestEchoServer(t *testing.T) net.Listener {
+	return startTestListener(t, func(conn net.Conn) {
+		defer conn.Close()
+		io.Copy(conn, conn)
+	})
+}
+
+func relayTestConnection(client net.Conn, address string) {
+	server, err := net.Dial("tcp", address)
+	if err != nil {
+		return
+	}
+	defer server.Close()
+
+	go io.Copy(server, client)
+	io.Copy(client, server)
+}
+
+func testDialerEcho(t *testing.T, dialer connection.Dialer, address string) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	failError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	failError(t, err)
+
+	buffer := make([]byte, 5)
+	_, err = io.ReadFull(conn, buffer)
+	failError(t, err)
+	assert.Equal(t, "hello", string(buffer))
+}
+
+func testSOCKS5Dialer(t *testing.T) {
+	echo := startTestEchoServer(t)
+	defer echo.Close()
+
+	proxy := startTestListener(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		// greeting, accept username/password
+		header := make([]byte, 2)
+		io.ReadFull(conn, header)
+		io.ReadFull(conn, make([]byte, header[1]))
+		conn.Write([]byte{0x05, 0x02})
+
+		// authentication
+		io.ReadFull(conn, header)
+		username := make([]byte, header[1])
+		io.ReadFull(conn, username)
+		io.ReadFull(conn, header[:1])
+		password := make([]byte, header[0])
+		io.ReadFull(conn, password)
+		if string(username) != "user" || string(password) != "pass" {
+			conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		conn.Write([]byte{0x01, 0x00})
+
+		// connect request with IPv4 address
+		request := make([]byte, 10)
+		io.ReadFull(conn, request)
+		ip := net.IP(request[4:8])
+		port := binary.BigEndian.Uint16(request[8:10])
+		conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+		relayTestConnection(conn, net.JoinHostPort(ip.String(), strconv.Itoa(int(port))))
+	})
+	defer proxy.Close()
+
+	dialer := connection.NewSOCKS5Dialer(proxy.Addr().String(), "user", "pass")
+	testDialerEcho(t, dialer, echo.Addr().String())
+
+	// wrong password
+	badDialer := connection.NewSOCKS5Dialer(proxy.Addr().String(), "user", "wrong")
+	_, err := badDialer.DialContext(context.Background(), "tcp", echo.Addr().String())
+	assert.Error(t, err)
+}
+
+func testHTTPConnectDialer(t *testing.T) {
+	echo := startTestEchoServer(t)
+	defer echo.Close()
+
+	proxy := startTestListener(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		request, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+
+		if request.Method != http.MethodConnect {
+			fmt.Fprintf(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			return
+		}
+
+		fmt.Fprintf(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		relayTestConnection(conn, request.Host)
+	})
+	defer proxy.Close()
+
+	dialer := connection.NewHTTPConnectDialer(proxy.Addr().String(), "", "")
+	testDialerEcho(t, dialer, echo.Addr().String())
+}
@@ -0,0 +1,245 @@
+package testcases
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	"github.com/phdavis1027/go-irodsclient/irods/metrics"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testEndpoints = []session.Endpoint{
+		{Host: "ies1", Port: 1247},
+		{Host: "ies2", Port: 1247},
+		{Host: "ies3", Port: 1247},
+	}
+)
+
+func TestEndpointSelector(t *testing.T) {
+	t.Run("test EndpointSelectorRoundRobin", testEndpointSelectorRoundRobin)
+	t.Run("test EndpointSelectorLeastConnections", testEndpointSelectorLeastConnections)
+	t.Run("test EndpointSelectorFailover", testEndpointSelectorFailover)
+	t.Run("test EndpointSelectorServerVersion", testEndpointSelectorServerVersion)
+	t.Run("test ConnectionPoolDialOutsideLock", testConnectionPoolDialOutsideLock)
+	t.Run("test UnmanagedConnectionAccountHost", testUnmanagedConnectionAccountHost)
+}
+
+func testEndpointSelectorRoundRobin(t *testing.T) {
+	selector := session.NewEndpointSelector(testEndpoints, session.LoadBalancingRoundRobin, 1, time.Minute)
+
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+	assert.Equal(t, "ies2", selector.Candidates()[0].Host)
+	assert.Equal(t, "ies3", selector.Candidates()[0].Host)
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+
+	// a failed endpoint is skipped, but still tried last
+	selector.RecordFailure(testEndpoints[1], types.NewConnectionError())
+
+	candidates := selector.Candidates()
+	assert.Equal(t, 3, len(candidates))
+	assert.Equal(t, "ies3", candidates[0].Host)
+	assert.Equal(t, "ies1", candidates[1].Host)
+	assert.Equal(t, "ies2", candidates[2].Host)
+
+	status := selector.GetStatus()
+	assert.True(t, status[0].Healthy)
+	assert.False(t, status[1].Healthy)
+	assert.Equal(t, 1, status[1].ConsecutiveFailures)
+	assert.Error(t, status[1].LastError)
+
+	selector.RecordSuccess(testEndpoints[1])
+	assert.True(t, selector.GetStatus()[1].Healthy)
+}
+
+func testEndpointSelectorLeastConnections(t *testing.T) {
+	selector := session.NewEndpointSelector(testEndpoints, session.LoadBalancingLeastConnections, 1, time.Minute)
+
+	selector.AddConnection(testEndpoints[0])
+	selector.AddConnection(testEndpoints[0])
+	selector.AddConnection(testEndpoints[1])
+
+	assert.Equal(t, "ies3", selector.Candidates()[0].Host)
+
+	selector.AddConnection(testEndpoints[2])
+	selector.AddConnection(testEndpoints[2])
+	assert.Equal(t, "ies2", selector.Candidates()[0].Host)
+
+	selector.RemoveConnection(testEndpoints[0])
+	selector.RemoveConnection(testEndpoints[0])
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+	assert.Equal(t, 0, selector.GetStatus()[0].Connections)
+}
+
+func testEndpointSelectorFailover(t *testing.T) {
+	selector := session.NewEndpointSelector(testEndpoints, session.LoadBalancingFailover, 2, 50*time.Millisecond)
+
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+
+	// below threshold
+	selector.RecordFailure(testEndpoints[0], types.NewConnectionError())
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+
+	selector.RecordFailure(testEndpoints[0], types.NewConnectionError())
+	assert.Equal(t, "ies2", selector.Candidates()[0].Host)
+
+	// primary is tried again after retry interval
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "ies1", selector.Candidates()[0].Host)
+}
+
+// recordingDialer records dialed addresses and fails them, blocking until released if release is set
+type recordingDialer struct {
+	addresses []string
+	release   chan bool
+	mutex     sync.Mutex
+}
+
+func (dialer *recordingDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	dialer.mutex.Lock()
+	dialer.addresses = append(dialer.addresses, address)
+	dialer.mutex.Unlock()
+
+	if dialer.release != nil {
+		<-dialer.release
+	}
+
+	return nil, types.NewConnectionError()
+}
+
+func (dialer *recordingDialer) getAddresses() []string {
+	dialer.mutex.Lock()
+	defer dialer.mutex.Unlock()
+
+	return append([]string{}, dialer.addresses...)
+}
+
+func newTestEndpointPool(t *testing.T, dialer connection.Dialer) *session.ConnectionPool {
+	account, err := types.CreateIRODSAccount("ies1", 1247, "rods", "tempZone", types.AuthSchemeNative, "rods", "")
+	failError(t, err)
+
+	poolConfig := session.ConnectionPoolConfig{
+		Account:          account,
+		ApplicationName:  "go-irodsclient-test",
+		InitialCap:       0,
+		MaxIdle:          2,
+		MaxCap:           2,
+		Lifespan:         time.Hour,
+		IdleTimeout:      time.Minute,
+		OperationTimeout: time.Minute,
+		EndpointSelector: session.NewEndpointSelector(testEndpoints[:2], session.LoadBalancingFailover, 1, time.Minute),
+		Dialer:           dialer,
+	}
+
+	pool, err := session.NewConnectionPool(&poolConfig, &metrics.IRODSMetrics{})
+	failError(t, err)
+	return pool
+}
+
+func testEndpointSelectorServerVersion(t *testing.T) {
+	selector := session.NewEndpointSelector(testEndpoints, session.LoadBalancingRoundRobin, 1, time.Minute)
+
+	assert.Nil(t, selector.GetStatus()[0].ServerVersion)
+
+	// endpoints run different versions
+	oldVersion := &types.IRODSVersion{
+		ReleaseVersion: "rods4.2.11",
+		APIVersion:     "d",
+		ReconnectPort:  0,
+		ReconnectAddr:  "",
+		Cookie:         0,
+	}
+	newVersion := &types.IRODSVersion{
+		ReleaseVersion: "rods4.3.0",
+		APIVersion:     "d",
+		ReconnectPort:  0,
+		ReconnectAddr:  "",
+		Cookie:         0,
+	}
+
+	selector.SetServerVersion(testEndpoints[0], oldVersion)
+	selector.SetServerVersion(testEndpoints[1], newVersion)
+
+	status := selector.GetStatus()
+	assert.Same(t, oldVersion, status[0].ServerVersion)
+	assert.Same(t, newVersion, status[1].ServerVersion)
+	assert.Nil(t, status[2].ServerVersion)
+
+	// the same version reported by another connection keeps the cached one
+	selector.SetServerVersion(testEndpoints[0], &types.IRODSVersion{
+		ReleaseVersion: "rods4.2.11",
+		APIVersion:     "d",
+		ReconnectPort:  0,
+		ReconnectAddr:  "",
+		Cookie:         0,
+	})
+	assert.Same(t, oldVersion, selector.GetStatus()[0].ServerVersion)
+
+	// upgraded
+	selector.SetServerVersion(testEndpoints[0], newVersion)
+	assert.Same(t, newVersion, selector.GetStatus()[0].ServerVersion)
+}
+
+func testConnectionPoolDialOutsideLock(t *testing.T) {
+	dialer := &recordingDialer{
+		release: make(chan bool),
+	}
+
+	pool := newTestEndpointPool(t, dialer)
+	defer pool.Release()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, getErr := pool.Get()
+		done <- getErr
+	}()
+
+	// wait for the first dial
+	for len(dialer.getAddresses()) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the pool is not locked while dialing, the slot is reserved
+	counted := make(chan int, 1)
+	go func() {
+		counted <- pool.AvailableConnections()
+	}()
+
+	select {
+	case available := <-counted:
+		assert.Equal(t, 1, available)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "pool is locked while dialing")
+	}
+
+	close(dialer.release)
+
+	getErr := <-done
+	assert.Error(t, getErr)
+	assert.Equal(t, []string{"ies1:1247", "ies2:1247"}, dialer.getAddresses())
+	assert.Equal(t, 2, pool.AvailableConnections())
+}
+
+func testUnmanagedConnectionAccountHost(t *testing.T) {
+	dialer := &recordingDialer{}
+
+	pool := newTestEndpointPool(t, dialer)
+	defer pool.Release()
+
+	_, err := pool.NewUnmanagedConnection()
+	assert.Error(t, err)
+
+	// only the account's host is tried and endpoint health is untouched
+	assert.Equal(t, []string{"ies1:1247"}, dialer.getAddresses())
+	for _, status := range pool.GetEndpointStatus() {
+		assert.True(t, status.Healthy)
+		assert.Equal(t, 0, status.ConsecutiveFailures)
+	}
+}
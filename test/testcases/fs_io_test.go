@@ -1,15 +1,21 @@
 package testcases
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/phdavis1027/go-irodsclient/fs"
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
 	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -23,6 +29,15 @@ func TestFSIO(t *testing.T) {
 	makeHomeDir(t, fsIOTestID)
 
 	t.Run("test UpDownMBFiles", testUpDownMBFiles)
+	t.Run("test UpDownStream", testUpDownStream)
+	t.Run("test BufferedReadWrite", testBufferedReadWrite)
+	t.Run("test ConcurrentReadAt", testConcurrentReadAt)
+	t.Run("test UpDownDir", testUpDownDir)
+	t.Run("test TransferManager", testTransferManager)
+	t.Run("test BandwidthLimit", testBandwidthLimit)
+	t.Run("test UploadResumable", testUploadResumable)
+	t.Run("test AdaptiveParallelTransfer", testAdaptiveParallelTransfer)
+	t.Run("test CopyToFileSystem", testCopyToFileSystem)
 }
 
 func testUpDownMBFiles(t *testing.T) {
@@ -72,3 +87,794 @@ func testUpDownMBFiles(t *testing.T) {
 	err = os.Remove(localPath)
 	failError(t, err)
 }
+
+func testUpDownStream(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(50 * 1024 * 1024) // 50MB
+	data := makeRandomContentTestDataBuf(fileSize)
+	iRODSPath := fmt.Sprintf("%s/test_stream_file.bin", homedir)
+
+	// serial upload from a reader of unknown size
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(func() error {
+			_, writeErr := pipeWriter.Write(data)
+			return writeErr
+		}())
+	}()
+
+	err = filesystem.UploadFromReader(pipeReader, irods_fs.DataObjectLengthUnknown, iRODSPath, "", 0, false, nil)
+	failError(t, err)
+
+	// serial download to a writer
+	buffer := &bytes.Buffer{}
+	err = filesystem.DownloadFileToBuffer(iRODSPath, "", buffer, nil)
+	failError(t, err)
+	assert.Equal(t, data, buffer.Bytes())
+
+	// parallel upload from a reader at
+	err = filesystem.UploadFromReader(bytes.NewReader(data), fileSize, iRODSPath, "", 4, false, nil)
+	failError(t, err)
+
+	// parallel download to a writer at
+	localDownloadPath, err := filepath.Abs("./test_stream_file.bin")
+	failError(t, err)
+
+	f, err := os.Create(localDownloadPath)
+	failError(t, err)
+
+	err = filesystem.DownloadToWriter(iRODSPath, "", f, 4, nil)
+	f.Close()
+	failError(t, err)
+
+	downloadedData, err := os.ReadFile(localDownloadPath)
+	failError(t, err)
+	assert.Equal(t, data, downloadedData)
+
+	// remove
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+
+	err = os.Remove(localDownloadPath)
+	failError(t, err)
+}
+
+func testBufferedReadWrite(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	bufferConfig := fs.NewFileBufferConfigWithDefault()
+	bufferConfig.BlockSize = 64 * 1024 // 64KB
+
+	fileSize := int64(1024 * 1024) // 1MB
+	data := makeRandomContentTestDataBuf(fileSize)
+	iRODSPath := fmt.Sprintf("%s/test_buffered_file.bin", homedir)
+
+	// write in small chunks
+	handle, err := filesystem.CreateFileWithBuffer(iRODSPath, "", "w", bufferConfig)
+	failError(t, err)
+	assert.True(t, handle.IsBuffered())
+
+	chunkSize := int64(4 * 1024)
+	for offset := int64(0); offset < fileSize; offset += chunkSize {
+		writeLen, err := handle.Write(data[offset : offset+chunkSize])
+		failError(t, err)
+		assert.Equal(t, int(chunkSize), writeLen)
+	}
+
+	err = handle.Close()
+	failError(t, err)
+
+	entry, err := filesystem.Stat(iRODSPath)
+	failError(t, err)
+	assert.Equal(t, fileSize, entry.Size)
+
+	// read sequentially in small chunks, blocks are read ahead
+	handle, err = filesystem.OpenFileWithBuffer(iRODSPath, "", "r", bufferConfig)
+	failError(t, err)
+
+	readData, err := io.ReadAll(handle)
+	failError(t, err)
+	assert.Equal(t, data, readData)
+
+	// random access
+	readBuffer := make([]byte, chunkSize)
+	readOffset := fileSize / 3
+	newOffset, err := handle.Seek(readOffset, io.SeekStart)
+	failError(t, err)
+	assert.Equal(t, readOffset, newOffset)
+
+	readLen, err := io.ReadFull(handle, readBuffer)
+	failError(t, err)
+	assert.Equal(t, data[readOffset:readOffset+int64(readLen)], readBuffer)
+
+	// seek back and forth while reading sequentially, discarded blocks are requested again
+	for round := 0; round < 4; round++ {
+		_, err = handle.Seek(0, io.SeekStart)
+		failError(t, err)
+
+		for readOffset = 0; readOffset < fileSize/2; readOffset += chunkSize {
+			readLen, err = io.ReadFull(handle, readBuffer)
+			failError(t, err)
+			assert.Equal(t, data[readOffset:readOffset+int64(readLen)], readBuffer)
+		}
+	}
+
+	err = handle.Close()
+	failError(t, err)
+
+	// remove
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+}
+
+func testConcurrentReadAt(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(16 * 1024 * 1024) // 16MB
+	data := makeRandomContentTestDataBuf(fileSize)
+	iRODSPath := fmt.Sprintf("%s/test_readat_file.bin", homedir)
+
+	err = filesystem.UploadFromReader(bytes.NewReader(data), fileSize, iRODSPath, "", 1, false, nil)
+	failError(t, err)
+
+	readerAt, err := filesystem.OpenFileReaderAt(iRODSPath, "", 4)
+	failError(t, err)
+	assert.Equal(t, fileSize, readerAt.Size())
+
+	chunkSize := int64(1024 * 1024)
+	waitGroup := sync.WaitGroup{}
+	errs := make([]error, fileSize/chunkSize)
+	for i := int64(0); i < fileSize/chunkSize; i++ {
+		waitGroup.Add(1)
+
+		go func(chunkIndex int64) {
+			defer waitGroup.Done()
+
+			offset := chunkIndex * chunkSize
+			buffer := make([]byte, chunkSize)
+			_, readErr := readerAt.ReadAt(buffer, offset)
+			if readErr == nil && !bytes.Equal(data[offset:offset+chunkSize], buffer) {
+				readErr = fmt.Errorf("data mismatch at offset %d", offset)
+			}
+			errs[chunkIndex] = readErr
+		}(i)
+	}
+
+	waitGroup.Wait()
+
+	for _, readErr := range errs {
+		failError(t, readErr)
+	}
+
+	// read past EOF
+	buffer := make([]byte, chunkSize)
+	readLen, err := readerAt.ReadAt(buffer, fileSize-10)
+	assert.Equal(t, 10, readLen)
+	assert.ErrorIs(t, err, io.EOF)
+
+	err = readerAt.Close()
+	failError(t, err)
+
+	// remove
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+}
+
+func testUpDownDir(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	// make a local tree
+	localDir, err := os.MkdirTemp("", "go-irodsclient-test-dir-")
+	failError(t, err)
+	defer os.RemoveAll(localDir)
+
+	localFiles := map[string]int64{
+		"a.txt":          1024,
+		"b.bin":          2048,
+		"sub/c.txt":      4096,
+		"sub/deep/d.txt": 100,
+		"skip/e.txt":     10,
+	}
+
+	for relPath, size := range localFiles {
+		localPath := filepath.Join(localDir, filepath.FromSlash(relPath))
+		err = os.MkdirAll(filepath.Dir(localPath), 0755)
+		failError(t, err)
+
+		err = os.WriteFile(localPath, makeRandomContentTestDataBuf(size), 0644)
+		failError(t, err)
+	}
+
+	err = os.Symlink(filepath.Join(localDir, "a.txt"), filepath.Join(localDir, "link.txt"))
+	failError(t, err)
+
+	irodsDir := fmt.Sprintf("%s/test_dir_transfer", homedir)
+
+	transferConfig := fs.NewDirTransferConfigWithDefault()
+	transferConfig.Include = []string{"*.txt"}
+	transferConfig.Exclude = []string{"skip"}
+	transferConfig.Symlink = fs.SymlinkSkip
+
+	report, err := filesystem.UploadDir(localDir, irodsDir, transferConfig)
+	failError(t, err)
+	assert.Empty(t, report.Failed())
+	assert.Len(t, report.Transferred(), 3)
+	assert.Len(t, report.Skipped(), 1) // symbolic link
+
+	assert.True(t, filesystem.ExistsFile(irodsDir+"/a.txt"))
+	assert.True(t, filesystem.ExistsFile(irodsDir+"/sub/deep/d.txt"))
+	assert.False(t, filesystem.Exists(irodsDir+"/b.bin"))
+	assert.False(t, filesystem.Exists(irodsDir+"/skip"))
+
+	// nothing changed
+	transferConfig.Overwrite = fs.OverwriteIfDifferentSize
+	report, err = filesystem.UploadDir(localDir, irodsDir, transferConfig)
+	failError(t, err)
+	assert.Empty(t, report.Transferred())
+
+	// download
+	downloadDir, err := os.MkdirTemp("", "go-irodsclient-test-dir-")
+	failError(t, err)
+	defer os.RemoveAll(downloadDir)
+
+	report, err = filesystem.DownloadDir(irodsDir, downloadDir, fs.NewDirTransferConfigWithDefault())
+	failError(t, err)
+	assert.Empty(t, report.Failed())
+	assert.Len(t, report.Transferred(), 3)
+
+	for _, relPath := range []string{"a.txt", "sub/c.txt", "sub/deep/d.txt"} {
+		localData, err := os.ReadFile(filepath.Join(localDir, filepath.FromSlash(relPath)))
+		failError(t, err)
+
+		downloadedData, err := os.ReadFile(filepath.Join(downloadDir, filepath.FromSlash(relPath)))
+		failError(t, err)
+
+		assert.Equal(t, localData, downloadedData)
+	}
+
+	// never overwrite
+	downloadConfig := fs.NewDirTransferConfigWithDefault()
+	downloadConfig.Overwrite = fs.OverwriteNever
+	report, err = filesystem.DownloadDir(irodsDir, downloadDir, downloadConfig)
+	failError(t, err)
+	assert.Len(t, report.Skipped(), 3)
+
+	// a symbolic link at the destination is replaced, not written through
+	outsideDir, err := os.MkdirTemp("", "go-irodsclient-test-dir-")
+	failError(t, err)
+	defer os.RemoveAll(outsideDir)
+
+	outsidePath := filepath.Join(outsideDir, "outside.txt")
+	err = os.WriteFile(outsidePath, []byte("outside"), 0644)
+	failError(t, err)
+
+	linkPath := filepath.Join(downloadDir, "a.txt")
+	err = os.Remove(linkPath)
+	failError(t, err)
+	err = os.Symlink(outsidePath, linkPath)
+	failError(t, err)
+
+	report, err = filesystem.DownloadDir(irodsDir, downloadDir, fs.NewDirTransferConfigWithDefault())
+	failError(t, err)
+	assert.Empty(t, report.Failed())
+	for _, result := range report.Transferred() {
+		assert.NoError(t, result.Error)
+	}
+
+	outsideData, err := os.ReadFile(outsidePath)
+	failError(t, err)
+	assert.Equal(t, []byte("outside"), outsideData)
+
+	linkStat, err := os.Lstat(linkPath)
+	failError(t, err)
+	assert.True(t, linkStat.Mode().IsRegular())
+
+	// remove
+	err = filesystem.RemoveDir(irodsDir, true, true)
+	failError(t, err)
+}
+
+func testTransferManager(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(50 * 1024 * 1024) // 50MB
+	localPath, err := createLocalTestFile("test_file_", fileSize)
+	failError(t, err)
+	defer os.Remove(localPath)
+
+	iRODSPath := fmt.Sprintf("%s/%s", homedir, path.Base(localPath))
+	iRODSCopyPath := iRODSPath + ".copy"
+	localDownloadPath := localPath + ".download"
+
+	managerConfig := fs.NewTransferManagerConfigWithDefault()
+	managerConfig.ProgressInterval = 100 * time.Millisecond
+
+	manager := fs.NewTransferManager(filesystem, managerConfig)
+	defer manager.Release()
+
+	progressMutex := sync.Mutex{}
+	var lastProgress *fs.TransferProgress
+	manager.AddProgressCallback(func(progress *fs.TransferProgress) {
+		progressMutex.Lock()
+		defer progressMutex.Unlock()
+		lastProgress = progress
+	})
+
+	// upload
+	uploadJob, err := manager.AddUpload(localPath, iRODSPath, "", 0)
+	failError(t, err)
+
+	manager.Wait()
+	assert.Equal(t, fs.TransferJobStateCompleted, uploadJob.GetState())
+	assert.Equal(t, fileSize, uploadJob.GetTransferred())
+
+	// download, pause and resume
+	downloadJob, err := manager.AddDownload(iRODSPath, localDownloadPath, "", 2)
+	failError(t, err)
+	defer os.Remove(localDownloadPath)
+
+	// keep the job running long enough to pause it
+	downloadJob.SetBandwidthLimit(1024 * 1024) // 1MB/s
+
+	for downloadJob.GetState() == fs.TransferJobStateQueued {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = manager.Pause(downloadJob.GetID())
+	failError(t, err)
+
+	manager.Wait()
+	assert.Equal(t, fs.TransferJobStatePaused, downloadJob.GetState())
+	assert.Less(t, downloadJob.GetTransferred(), fileSize)
+
+	downloadJob.SetBandwidthLimit(0)
+
+	err = manager.Resume(downloadJob.GetID())
+	failError(t, err)
+
+	manager.Wait()
+	assert.Equal(t, fs.TransferJobStateCompleted, downloadJob.GetState())
+	assert.NoFileExists(t, irods_fs.GetDataObjectTransferStatusFilePath(localDownloadPath))
+
+	localData, err := os.ReadFile(localPath)
+	failError(t, err)
+
+	downloadedData, err := os.ReadFile(localDownloadPath)
+	failError(t, err)
+	assert.Equal(t, localData, downloadedData)
+
+	// canceling a download keeps the file existed before
+	overwriteJob, err := manager.AddDownload(iRODSPath, localDownloadPath, "", 2)
+	failError(t, err)
+
+	overwriteJob.SetBandwidthLimit(1024 * 1024) // 1MB/s
+
+	for overwriteJob.GetState() == fs.TransferJobStateQueued {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = manager.Cancel(overwriteJob.GetID())
+	failError(t, err)
+
+	manager.Wait()
+	assert.Equal(t, fs.TransferJobStateCanceled, overwriteJob.GetState())
+	assert.FileExists(t, localDownloadPath)
+	assert.NoFileExists(t, irods_fs.GetDataObjectTransferStatusFilePath(localDownloadPath))
+
+	err = manager.RemoveJob(overwriteJob.GetID())
+	failError(t, err)
+
+	// copy and cancel
+	copyJob, err := manager.AddCopy(iRODSPath, iRODSCopyPath)
+	failError(t, err)
+
+	manager.Wait()
+	assert.Equal(t, fs.TransferJobStateCompleted, copyJob.GetState())
+	assert.True(t, filesystem.ExistsFile(iRODSCopyPath))
+
+	err = manager.Cancel(copyJob.GetID())
+	assert.Error(t, err)
+
+	progress := manager.GetProgress()
+	assert.Equal(t, 3, progress.Jobs)
+	assert.Equal(t, 3, progress.Completed)
+
+	time.Sleep(200 * time.Millisecond)
+	progressMutex.Lock()
+	assert.NotNil(t, lastProgress)
+	progressMutex.Unlock()
+
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+
+	err = filesystem.RemoveFile(iRODSCopyPath, true)
+	failError(t, err)
+}
+
+func testBandwidthLimit(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+	fsConfig.UploadBandwidthLimit = 2 * 1024 * 1024 // 2MB/s
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(6 * 1024 * 1024) // 6MB
+	data := makeRandomContentTestDataBuf(fileSize)
+	iRODSPath := fmt.Sprintf("%s/test_bandwidth_limit.bin", homedir)
+
+	// upload is limited, the first second is a burst
+	start := time.Now()
+	err = filesystem.UploadFromReader(bytes.NewReader(data), fileSize, iRODSPath, "", 1, false, nil)
+	failError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+
+	uploadLimit, downloadLimit := filesystem.GetBandwidthLimit()
+	assert.Equal(t, int64(2*1024*1024), uploadLimit)
+	assert.Equal(t, int64(0), downloadLimit)
+
+	// limit a file handle
+	filesystem.SetBandwidthLimit(0, 0)
+
+	handle, err := filesystem.OpenFile(iRODSPath, "", "r")
+	failError(t, err)
+
+	readLimiter := util.NewRateLimiter(2 * 1024 * 1024)
+	handle.SetRateLimiters(nil, readLimiter)
+
+	buffer := make([]byte, fileSize)
+	start = time.Now()
+	readLen, err := io.ReadFull(handle, buffer)
+	failError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, data, buffer[:readLen])
+
+	err = handle.Close()
+	failError(t, err)
+
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+}
+
+func testUploadResumable(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	statusDir, err := os.MkdirTemp("", "go-irodsclient-test-status-")
+	failError(t, err)
+	defer os.RemoveAll(statusDir)
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+	fsConfig.UploadTransferStatusDir = statusDir
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(40 * 1024 * 1024) // 40MB
+	localPath, err := createLocalTestFile("test_file_", fileSize)
+	failError(t, err)
+	defer os.Remove(localPath)
+
+	localData, err := os.ReadFile(localPath)
+	failError(t, err)
+
+	localStat, err := os.Stat(localPath)
+	failError(t, err)
+
+	iRODSPath := fmt.Sprintf("%s/%s", homedir, path.Base(localPath))
+	statusFilePath := irods_fs.GetDataObjectUploadTransferStatusFilePath(statusDir, localPath, iRODSPath)
+
+	numTasks := 4
+
+	// interrupt the upload, the local file is cut in the middle while it is being uploaded
+	interruptOnce := sync.Once{}
+	interruptCallback := func(processed int64, total int64) {
+		if processed >= fileSize/4 {
+			interruptOnce.Do(func() {
+				truncErr := os.Truncate(localPath, fileSize/2)
+				assert.NoError(t, truncErr)
+			})
+		}
+	}
+
+	err = filesystem.UploadFileParallelResumable(localPath, iRODSPath, "", numTasks, false, false, interruptCallback)
+	assert.Error(t, err)
+	assert.FileExists(t, statusFilePath)
+
+	// partial data must not be taken as good
+	replicas, err := filesystem.ListReplicas(iRODSPath)
+	failError(t, err)
+	assert.NotEmpty(t, replicas)
+	for _, replica := range replicas {
+		assert.False(t, replica.IsGood())
+	}
+
+	// restore the local file and resume
+	err = os.WriteFile(localPath, localData, 0644)
+	failError(t, err)
+
+	// the status is discarded if the modification time differs
+	err = os.Chtimes(localPath, localStat.ModTime(), localStat.ModTime())
+	failError(t, err)
+
+	firstProcessed := int64(-1)
+	firstOnce := sync.Once{}
+	callback := func(processed int64, total int64) {
+		firstOnce.Do(func() {
+			firstProcessed = processed
+		})
+	}
+
+	err = filesystem.UploadFileParallelResumable(localPath, iRODSPath, "", numTasks, false, true, callback)
+	failError(t, err)
+	assert.Greater(t, firstProcessed, int64(0))
+	assert.Less(t, firstProcessed, fileSize)
+	assert.NoFileExists(t, statusFilePath)
+
+	entry, err := filesystem.Stat(iRODSPath)
+	failError(t, err)
+	assert.Equal(t, fileSize, entry.Size)
+
+	replicas, err = filesystem.ListReplicas(iRODSPath)
+	failError(t, err)
+	for _, replica := range replicas {
+		assert.True(t, replica.IsGood())
+	}
+
+	// compare
+	buffer := bytes.Buffer{}
+	err = filesystem.DownloadFileToBuffer(iRODSPath, "", &buffer, nil)
+	failError(t, err)
+	assert.Equal(t, localData, buffer.Bytes())
+
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+}
+
+func testAdaptiveParallelTransfer(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	parallelConfig := util.NewParallelTransferConfigAdaptive()
+	parallelConfig.TaskMinLength = 4 * 1024 * 1024 // 4MB
+	parallelConfig.AdaptiveTaskMaxNum = 8
+	parallelConfig.AdaptiveBlockSizeMin = 256 * 1024      // 256KB
+	parallelConfig.AdaptiveBlockSizeMax = 8 * 1024 * 1024 // 8MB
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+	fsConfig.ParallelTransfer = parallelConfig
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	tuner := filesystem.GetParallelTransferTuner()
+	assert.Equal(t, 1, tuner.GetNumTasks(1024))
+	assert.Equal(t, util.TransferTaskMaxNum, tuner.GetNumTasks(64*1024*1024))
+
+	// suggestions are bounded by connections transfers can use
+	limitedTuner := util.NewParallelTransferTunerWithTaskLimit(parallelConfig, 2)
+	assert.Equal(t, 2, limitedTuner.GetNumTasks(64*1024*1024))
+
+	for i := 0; i < 3; i++ {
+		numTasks := limitedTuner.GetNumTasks(64 * 1024 * 1024)
+		probe := limitedTuner.NewProbe(numTasks)
+		for taskID := 0; taskID < numTasks; taskID++ {
+			probe.Record(taskID, 1024*1024, 10*time.Millisecond)
+		}
+		probe.Done()
+
+		assert.LessOrEqual(t, limitedTuner.GetNumTasks(64*1024*1024), 2)
+	}
+
+	homedir := getHomeDir(fsIOTestID)
+
+	fileSize := int64(48 * 1024 * 1024) // 48MB
+	localPath, err := createLocalTestFile("test_file_", fileSize)
+	failError(t, err)
+	defer os.Remove(localPath)
+
+	localData, err := os.ReadFile(localPath)
+	failError(t, err)
+
+	iRODSPath := fmt.Sprintf("%s/%s", homedir, path.Base(localPath))
+	downloadPath := fmt.Sprintf("%s.download", localPath)
+	defer os.Remove(downloadPath)
+
+	for i := 0; i < 3; i++ {
+		err = filesystem.UploadFileParallel(localPath, iRODSPath, "", 0, false, nil)
+		failError(t, err)
+
+		err = filesystem.DownloadFileParallel(iRODSPath, "", downloadPath, 0, nil)
+		failError(t, err)
+
+		downloadData, err := os.ReadFile(downloadPath)
+		failError(t, err)
+		assert.Equal(t, localData, downloadData)
+
+		// tuned values stay within bounds
+		numTasks := tuner.GetNumTasks(fileSize)
+		assert.GreaterOrEqual(t, numTasks, 1)
+		assert.LessOrEqual(t, numTasks, parallelConfig.AdaptiveTaskMaxNum)
+		assert.LessOrEqual(t, numTasks, fsConfig.ConnectionMax)
+
+		blockSize := tuner.GetBlockSize()
+		assert.GreaterOrEqual(t, int64(blockSize), parallelConfig.AdaptiveBlockSizeMin)
+		assert.LessOrEqual(t, int64(blockSize), parallelConfig.AdaptiveBlockSizeMax)
+	}
+
+	err = filesystem.RemoveFile(iRODSPath, true)
+	failError(t, err)
+}
+
+func testCopyToFileSystem(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	// two file systems stand for independent deployments
+	srcFilesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer srcFilesystem.Release()
+
+	destFilesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer destFilesystem.Release()
+
+	homedir := getHomeDir(fsIOTestID)
+
+	srcDir := fmt.Sprintf("%s/test_cross_copy_src", homedir)
+	destDir := fmt.Sprintf("%s/test_cross_copy_dest", homedir)
+
+	err = srcFilesystem.MakeDir(srcDir+"/sub", true)
+	failError(t, err)
+
+	srcFiles := map[string]int64{
+		"a.bin":     40 * 1024 * 1024, // copied in parallel ranges
+		"sub/b.txt": 1024,
+		"sub/c.txt": 0,
+	}
+
+	for relPath, size := range srcFiles {
+		err = srcFilesystem.UploadFromReader(bytes.NewReader(makeRandomContentTestDataBuf(size)), size, srcDir+"/"+relPath, "", 0, false, nil)
+		failError(t, err)
+	}
+
+	err = srcFilesystem.AddMetadata(srcDir+"/a.bin", "origin", "site-a", "")
+	failError(t, err)
+
+	err = srcFilesystem.AddMetadata(srcDir+"/sub", "project", "migration", "")
+	failError(t, err)
+
+	modifyTime := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	err = srcFilesystem.SetModifyTime(srcDir+"/a.bin", modifyTime)
+	failError(t, err)
+
+	err = srcFilesystem.SetDirModifyTime(srcDir+"/sub", modifyTime)
+	failError(t, err)
+
+	// single file
+	copyConfig := fs.NewCrossCopyConfigWithDefault()
+	copyConfig.PreserveModifyTime = true
+	copyConfig.PreserveACLs = true
+
+	err = destFilesystem.MakeDir(destDir, true)
+	failError(t, err)
+
+	err = srcFilesystem.CopyFileToFileSystem(srcDir+"/a.bin", destFilesystem, destDir, copyConfig, nil)
+	failError(t, err)
+
+	destEntry, err := destFilesystem.Stat(destDir + "/a.bin")
+	failError(t, err)
+	assert.Equal(t, srcFiles["a.bin"], destEntry.Size)
+	assert.Equal(t, modifyTime.Unix(), destEntry.ModifyTime.Unix())
+
+	metas, err := destFilesystem.ListMetadata(destDir + "/a.bin")
+	failError(t, err)
+	assert.Len(t, metas, 1)
+	assert.Equal(t, "origin", metas[0].Name)
+	assert.Equal(t, "site-a", metas[0].Value)
+
+	// recursive, a.bin is the same already
+	copyConfig.Overwrite = fs.OverwriteIfDifferentChecksum
+
+	report, err := srcFilesystem.CopyDirToFileSystem(srcDir, destFilesystem, destDir, copyConfig)
+	failError(t, err)
+	assert.Empty(t, report.Failed())
+	assert.Len(t, report.Transferred(), 2)
+	assert.Len(t, report.Skipped(), 1)
+
+	// users are the same on both sides
+	for _, result := range report.Transferred() {
+		assert.Empty(t, result.Warnings)
+	}
+
+	metas, err = destFilesystem.ListMetadata(destDir + "/sub")
+	failError(t, err)
+	assert.Len(t, metas, 1)
+	assert.Equal(t, "project", metas[0].Name)
+
+	destEntry, err = destFilesystem.Stat(destDir + "/sub")
+	failError(t, err)
+	assert.Equal(t, modifyTime.Unix(), destEntry.ModifyTime.Unix())
+
+	for relPath := range srcFiles {
+		srcBuffer := bytes.Buffer{}
+		err = srcFilesystem.DownloadFileToBuffer(srcDir+"/"+relPath, "", &srcBuffer, nil)
+		failError(t, err)
+
+		destBuffer := bytes.Buffer{}
+		err = destFilesystem.DownloadFileToBuffer(destDir+"/"+relPath, "", &destBuffer, nil)
+		failError(t, err)
+
+		assert.Equal(t, srcBuffer.Bytes(), destBuffer.Bytes())
+	}
+
+	err = srcFilesystem.RemoveDir(srcDir, true, true)
+	failError(t, err)
+
+	err = destFilesystem.RemoveDir(destDir, true, true)
+	failError(t, err)
+}
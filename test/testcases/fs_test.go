@@ -5,11 +5,15 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	io_fs "io/fs"
+	"path"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/phdavis1027/go-irodsclient/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,6 +38,8 @@ func TestFS(t *testing.T) {
 	t.Run("test WriteRename", testWriteRename)
 	t.Run("test WriteRenameDir", testWriteRenameDir)
 	t.Run("test RemoveClose", testRemoveClose)
+	t.Run("test IOFS", testIOFS)
+	t.Run("test ServerVersion", testServerVersion)
 }
 
 func testPrepareSamplesForFS(t *testing.T) {
@@ -464,3 +470,67 @@ func testRemoveClose(t *testing.T) {
 	wg.Wait()
 	assert.False(t, filesystem.Exists(newDataObjectPath))
 }
+
+func testIOFS(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(fsTestID)
+	iofs := fs.NewIOFS(filesystem, homedir)
+
+	expected := []string{}
+	for _, testFilePath := range GetTestFiles() {
+		expected = append(expected, path.Base(testFilePath))
+	}
+
+	err = fstest.TestFS(iofs, expected...)
+	failError(t, err)
+
+	for _, testFilePath := range GetTestFiles() {
+		entry, err := filesystem.Stat(testFilePath)
+		failError(t, err)
+
+		data, err := io_fs.ReadFile(iofs, path.Base(testFilePath))
+		failError(t, err)
+		assert.Equal(t, entry.Size, int64(len(data)))
+
+		info, err := io_fs.Stat(iofs, path.Base(testFilePath))
+		failError(t, err)
+		assert.True(t, info.Mode().IsRegular())
+		assert.Equal(t, entry.ModifyTime, info.ModTime())
+	}
+
+	_, err = iofs.Open("does_not_exist")
+	assert.ErrorIs(t, err, io_fs.ErrNotExist)
+
+	_, err = iofs.Open("../escape")
+	assert.ErrorIs(t, err, io_fs.ErrInvalid)
+}
+
+func testServerVersion(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	fsConfig := fs.NewFileSystemConfigWithDefault("go-irodsclient-test")
+
+	filesystem, err := fs.NewFileSystem(account, fsConfig)
+	failError(t, err)
+	defer filesystem.Release()
+
+	version, err := filesystem.GetServerVersion()
+	failError(t, err)
+
+	// cached
+	version2, err := filesystem.GetServerVersion()
+	failError(t, err)
+	assert.Same(t, version, version2)
+	assert.Equal(t, version.HasCapability(types.IRODSServerCapabilityReplicaClose), filesystem.HasCapability(types.IRODSServerCapabilityReplicaClose))
+}
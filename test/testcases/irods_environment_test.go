@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/phdavis1027/go-irodsclient/icommands"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/phdavis1027/go-irodsclient/test/server"
 	"github.com/stretchr/testify/assert"
 )
@@ -14,6 +15,7 @@ func TestIRODSEnvironment(t *testing.T) {
 	t.Run("test SaveAndLoadEnv", testSaveAndLoadEnv)
 	t.Run("test SaveAndLoadEnvSession", testSaveAndLoadEnvSession)
 	t.Run("test ConfiguredAuthFilePath", testConfiguredAuthFilePath)
+	t.Run("test SSLVerifyServerEnv", testSSLVerifyServerEnv)
 }
 
 func testSaveAndLoadEnv(t *testing.T) {
@@ -127,3 +129,30 @@ func testSaveAndLoadEnvSession(t *testing.T) {
 	err = os.RemoveAll("~/.irods2")
 	failError(t, err)
 }
+
+func testSSLVerifyServerEnv(t *testing.T) {
+	// not set, the legacy default
+	env, err := icommands.CreateICommandsEnvironmentFromJSON([]byte(`{"irods_host": "irods.example.org"}`))
+	failError(t, err)
+
+	account, err := env.ToIRODSAccountWithValidation()
+	failError(t, err)
+	assert.Equal(t, types.SSLVerifyServer(""), account.SSLConfiguration.VerifyServer)
+
+	env, err = icommands.CreateICommandsEnvironmentFromJSON([]byte(`{"irods_host": "irods.example.org", "irods_ssl_verify_server": "none"}`))
+	failError(t, err)
+
+	account, err = env.ToIRODSAccountWithValidation()
+	failError(t, err)
+	assert.Equal(t, types.SSLVerifyServerNone, account.SSLConfiguration.VerifyServer)
+
+	// invalid value must not turn verification off
+	env, err = icommands.CreateICommandsEnvironmentFromJSON([]byte(`{"irods_host": "irods.example.org", "irods_ssl_verify_server": "maybe"}`))
+	failError(t, err)
+
+	_, err = env.ToIRODSAccountWithValidation()
+	assert.Error(t, err)
+
+	account = env.ToIRODSAccount()
+	assert.Equal(t, types.SSLVerifyServerHostname, account.SSLConfiguration.VerifyServer)
+}
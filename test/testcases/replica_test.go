@@ -0,0 +1,143 @@
+package testcases
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/phdavis1027/go-irodsclient/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	replicaTestID = xid.New().String()
+)
+
+func TestReplica(t *testing.T) {
+	setup()
+	defer shutdown()
+
+	makeHomeDir(t, replicaTestID)
+
+	t.Run("test ListReplicas", testListReplicas)
+	t.Run("test SetReplicaStatus", testSetReplicaStatus)
+	t.Run("test OpenCloseReplica", testOpenCloseReplica)
+}
+
+func testListReplicas(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(replicaTestID)
+	irodsPath := fmt.Sprintf("%s/list_replicas.txt", homedir)
+
+	data := makeRandomContentTestDataBuf(1024)
+	err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), irodsPath, "", 0, false, nil)
+	failError(t, err)
+
+	replicas, err := filesystem.ListReplicas(irodsPath)
+	failError(t, err)
+
+	assert.Len(t, replicas, 1)
+	assert.Equal(t, int64(0), replicas[0].Number)
+	assert.Equal(t, int64(len(data)), replicas[0].Size)
+	assert.True(t, replicas[0].IsGood())
+
+	err = filesystem.RemoveFile(irodsPath, true)
+	failError(t, err)
+}
+
+func testSetReplicaStatus(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(replicaTestID)
+	irodsPath := fmt.Sprintf("%s/replica_status.txt", homedir)
+
+	data := makeRandomContentTestDataBuf(1024)
+	err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), irodsPath, "", 0, false, nil)
+	failError(t, err)
+
+	// owner, without admin flag
+	err = filesystem.MarkReplicaStale(irodsPath, 0, false)
+	failError(t, err)
+
+	replicas, err := filesystem.ListReplicas(irodsPath)
+	failError(t, err)
+	assert.Len(t, replicas, 1)
+	assert.True(t, replicas[0].IsStale())
+
+	// with admin flag
+	err = filesystem.MarkReplicaGood(irodsPath, 0, true)
+	failError(t, err)
+
+	replicas, err = filesystem.ListReplicas(irodsPath)
+	failError(t, err)
+	assert.Len(t, replicas, 1)
+	assert.True(t, replicas[0].IsGood())
+
+	err = filesystem.RemoveFile(irodsPath, true)
+	failError(t, err)
+}
+
+func testOpenCloseReplica(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(replicaTestID)
+	irodsPath := fmt.Sprintf("%s/open_close_replica.txt", homedir)
+
+	data := makeRandomContentTestDataBuf(1024)
+	err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), irodsPath, "", 0, false, nil)
+	failError(t, err)
+
+	handle, err := filesystem.OpenFileReplica(irodsPath, 0, "", "r")
+	failError(t, err)
+
+	readBuffer := make([]byte, len(data))
+	readLen, err := handle.Read(readBuffer)
+	failError(t, err)
+	assert.Equal(t, data, readBuffer[:readLen])
+
+	metricsBefore := filesystem.GetMetrics()
+
+	err = handle.CloseReplica(false, false, false)
+	metricsAfter := filesystem.GetMetrics()
+
+	if filesystem.HasCapability(types.IRODSServerCapabilityReplicaClose) {
+		failError(t, err)
+
+		assert.Equal(t, metricsBefore.GetCounterForDataObjectClose()+1, metricsAfter.GetCounterForDataObjectClose())
+		assert.Equal(t, metricsBefore.GetCounterForOpenFileHandles()-1, metricsAfter.GetCounterForOpenFileHandles())
+	} else {
+		// server too old, the close is refused before sending anything
+		assert.Error(t, err)
+
+		assert.Equal(t, metricsBefore.GetCounterForDataObjectClose(), metricsAfter.GetCounterForDataObjectClose())
+		assert.Equal(t, metricsBefore.GetCounterForOpenFileHandles(), metricsAfter.GetCounterForOpenFileHandles())
+
+		// the handle is still open and can be closed normally
+		err = handle.Close()
+		failError(t, err)
+	}
+
+	err = filesystem.RemoveFile(irodsPath, true)
+	failError(t, err)
+}
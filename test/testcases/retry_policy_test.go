@@ -0,0 +1,87 @@
+package testcases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/irods/common"
+	"github.com/phdavis1027/go-irodsclient/irods/session"
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("test RetryableError", testRetryableError)
+	t.Run("test RetryBackoff", testRetryBackoff)
+	t.Run("test RetryExecute", testRetryExecute)
+}
+
+func testRetryableError(t *testing.T) {
+	assert.True(t, session.IsRetryableError(xerrors.Errorf("failed: %w", types.NewConnectionError())))
+	assert.True(t, session.IsRetryableError(types.NewIRODSError(common.SYS_HEADER_READ_LEN_ERR)))
+
+	// with errno
+	errcode := common.ErrorCode(int(common.SYS_HEADER_READ_LEN_ERR) - int(common.ECONNRESET))
+	assert.True(t, session.IsRetryableError(xerrors.Errorf("failed: %w", types.NewIRODSError(errcode))))
+
+	assert.False(t, session.IsRetryableError(nil))
+	assert.False(t, session.IsRetryableError(types.NewIRODSError(common.CAT_NO_ROWS_FOUND)))
+	assert.False(t, session.IsRetryableError(types.NewFileNotFoundError("/zone/home/test")))
+}
+
+func testRetryBackoff(t *testing.T) {
+	policy := session.NewRetryPolicy(5, 100*time.Millisecond, 300*time.Millisecond, 2.0, 0)
+
+	assert.Equal(t, 100*time.Millisecond, policy.GetBackoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.GetBackoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.GetBackoff(3))
+	assert.Equal(t, 300*time.Millisecond, policy.GetBackoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.GetBackoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
+
+func testRetryExecute(t *testing.T) {
+	policy := session.NewRetryPolicy(3, time.Millisecond, 10*time.Millisecond, 2.0, 0)
+
+	retries := 0
+	policy.AddHook(func(attempt int, err error, backoff time.Duration) {
+		retries++
+	})
+
+	// succeeds at the last attempt
+	attempts := 0
+	err := policy.Execute(func(attempt int) error {
+		attempts = attempt
+		if attempt < 3 {
+			return types.NewConnectionError()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, retries)
+
+	// not retryable
+	retries = 0
+	err = policy.Execute(func(attempt int) error {
+		attempts = attempt
+		return types.NewFileNotFoundError("/zone/home/test")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 0, retries)
+
+	// gives up
+	err = policy.Execute(func(attempt int) error {
+		attempts = attempt
+		return types.NewConnectionError()
+	})
+	assert.True(t, types.IsConnectionError(err))
+	assert.Equal(t, 3, attempts)
+}
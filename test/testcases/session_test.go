@@ -2,6 +2,7 @@ package testcases
 
 import (
 	"testing"
+	"time"
 
 	"github.com/phdavis1027/go-irodsclient/irods/connection"
 	"github.com/phdavis1027/go-irodsclient/irods/fs"
@@ -9,6 +10,7 @@ import (
 	"github.com/phdavis1027/go-irodsclient/irods/types"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
 )
 
 var (
@@ -24,6 +26,10 @@ func TestSession(t *testing.T) {
 	t.Run("test Session", testSession)
 	t.Run("test many Connections", testManyConnections)
 	t.Run("test Connection Metrics", testConnectionMetrics)
+	t.Run("test Connection Wait", testConnectionWait)
+	t.Run("test Connection Priority", testConnectionPriority)
+	t.Run("test Connection Priority Shared Pool", testConnectionPrioritySharedPool)
+	t.Run("test Connection Health Check", testConnectionHealthCheck)
 }
 
 func testSession(t *testing.T) {
@@ -136,3 +142,262 @@ func testConnectionMetrics(t *testing.T) {
 	assert.Equal(t, uint64(sessionConfig.ConnectionMaxIdle), metrics.GetConnectionsOpened())
 	assert.Equal(t, uint64(0), metrics.GetConnectionsOccupied())
 }
+
+func testConnectionWait(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+	account.CSNegotiationPolicy = types.CSNegotiationDontCare
+
+	sessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	sessionConfig.ConnectionWaitTimeout = 1 * time.Second
+
+	sess, err := session.NewIRODSSession(account, sessionConfig)
+	failError(t, err)
+	defer sess.Release()
+
+	metrics := sess.GetMetrics()
+
+	connections := []*connection.IRODSConnection{}
+	for i := 0; i < sessionConfig.ConnectionMax; i++ {
+		conn, err := sess.AcquireConnection()
+		failError(t, err)
+
+		connections = append(connections, conn)
+	}
+
+	// pool is full, must time out
+	_, err = sess.AcquireConnection()
+	assert.Error(t, err)
+	assert.True(t, types.IsConnectionPoolFullError(err))
+	assert.Equal(t, uint64(1), metrics.GetCounterForConnectionPoolWaitTimeouts())
+
+	// returning a connection wakes up the waiter
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		sess.ReturnConnection(connections[0])
+	}()
+
+	conn, err := sess.AcquireConnectionWithPriority(session.ConnectionPriorityIO)
+	failError(t, err)
+	connections[0] = conn
+
+	assert.Equal(t, uint64(2), metrics.GetCounterForConnectionPoolWaits())
+	assert.Equal(t, uint64(0), metrics.GetConnectionPoolWaiters())
+	assert.Equal(t, sessionConfig.ConnectionMax, sess.ConnectionTotal())
+
+	for _, conn := range connections {
+		err = sess.ReturnConnection(conn)
+		failError(t, err)
+	}
+}
+
+func testConnectionPriority(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+	account.CSNegotiationPolicy = types.CSNegotiationDontCare
+
+	// a session for bulk transfers waits in the io lane
+	sessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	sessionConfig.ConnectionWaitTimeout = 10 * time.Second
+	sessionConfig.ConnectionPriority = session.ConnectionPriorityIO
+
+	sess, err := session.NewIRODSSession(account, sessionConfig)
+	failError(t, err)
+	defer sess.Release()
+
+	connections := []*connection.IRODSConnection{}
+	for i := 0; i < sessionConfig.ConnectionMax; i++ {
+		conn, err := sess.AcquireConnection()
+		failError(t, err)
+
+		connections = append(connections, conn)
+	}
+
+	served := make(chan string, 2)
+	acquired := make(chan *connection.IRODSConnection, 2)
+
+	go func() {
+		conn, err := sess.AcquireConnection()
+		if err == nil {
+			served <- "io"
+			acquired <- conn
+		}
+	}()
+
+	for sess.WaitingRequests() < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	go func() {
+		conn, err := sess.AcquireConnectionWithPriority(session.ConnectionPriorityMetadata)
+		if err == nil {
+			served <- "metadata"
+			acquired <- conn
+		}
+	}()
+
+	for sess.WaitingRequests() < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the metadata request is served first although it came later
+	sess.ReturnConnection(connections[0])
+	assert.Equal(t, "metadata", <-served)
+	connections[0] = <-acquired
+
+	sess.ReturnConnection(connections[1])
+	assert.Equal(t, "io", <-served)
+	connections[1] = <-acquired
+
+	for _, conn := range connections {
+		err = sess.ReturnConnection(conn)
+		failError(t, err)
+	}
+}
+
+func testConnectionPrioritySharedPool(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+	account.CSNegotiationPolicy = types.CSNegotiationDontCare
+
+	// io requests can hold 2 of 3 connections
+	ioSessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	ioSessionConfig.ConnectionMax = 3
+	ioSessionConfig.ConnectionMaxPerPriority = map[session.ConnectionPriority]int{
+		session.ConnectionPriorityIO: 2,
+	}
+	ioSessionConfig.ConnectionWaitTimeout = 10 * time.Second
+	ioSessionConfig.ConnectionPriority = session.ConnectionPriorityIO
+
+	ioSess, err := session.NewIRODSSession(account, ioSessionConfig)
+	failError(t, err)
+	defer ioSess.Release()
+
+	metaSessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	metaSessionConfig.ConnectionWaitTimeout = 10 * time.Second
+	metaSessionConfig.ConnectionPriority = session.ConnectionPriorityMetadata
+
+	metaSess, err := session.NewIRODSSessionSharingConnectionPool(account, metaSessionConfig, ioSess)
+	failError(t, err)
+	defer metaSess.Release()
+
+	ioConnections := []*connection.IRODSConnection{}
+	for i := 0; i < 2; i++ {
+		conn, err := ioSess.AcquireConnection()
+		failError(t, err)
+
+		ioConnections = append(ioConnections, conn)
+	}
+
+	// the io lane is full
+	request := session.NewConnectionRequest(session.ConnectionPriorityIO, "")
+	request.Deadline = time.Now().Add(100 * time.Millisecond)
+	_, err = ioSess.AcquireConnectionWithRequest(request)
+	assert.Error(t, err)
+	assert.True(t, types.IsConnectionPoolFullError(err))
+
+	// the remaining connection is for metadata
+	metaConn, err := metaSess.AcquireConnection()
+	failError(t, err)
+
+	assert.Equal(t, 3, ioSess.ConnectionTotal())
+	assert.Equal(t, 3, metaSess.ConnectionTotal())
+
+	served := make(chan string, 2)
+	acquired := make(chan *connection.IRODSConnection, 2)
+
+	go func() {
+		conn, err := ioSess.AcquireConnection()
+		if err == nil {
+			served <- "io"
+			acquired <- conn
+		}
+	}()
+
+	for ioSess.WaitingRequests() < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	go func() {
+		conn, err := metaSess.AcquireConnection()
+		if err == nil {
+			served <- "metadata"
+			acquired <- conn
+		}
+	}()
+
+	// both sessions wait in the same pool
+	for metaSess.WaitingRequests() < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the metadata request takes the connection returned by the io session
+	ioSess.ReturnConnection(ioConnections[0])
+	assert.Equal(t, "metadata", <-served)
+	metaConn2 := <-acquired
+
+	metaSess.ReturnConnection(metaConn)
+	assert.Equal(t, "io", <-served)
+	ioConnections[0] = <-acquired
+
+	err = metaSess.ReturnConnection(metaConn2)
+	failError(t, err)
+
+	for _, conn := range ioConnections {
+		err = ioSess.ReturnConnection(conn)
+		failError(t, err)
+	}
+}
+
+func testConnectionHealthCheck(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+	account.CSNegotiationPolicy = types.CSNegotiationDontCare
+
+	sessionConfig := session.NewIRODSSessionConfigWithDefault("go-irodsclient-test")
+	sessionConfig.ConnectionValidateOnBorrow = true
+	sessionConfig.RetryPolicy = session.NewRetryPolicyWithDefault()
+
+	sess, err := session.NewIRODSSession(account, sessionConfig)
+	failError(t, err)
+	defer sess.Release()
+
+	metrics := sess.GetMetrics()
+
+	conn, err := sess.AcquireConnection()
+	failError(t, err)
+
+	err = sess.ReturnConnection(conn)
+	failError(t, err)
+
+	// reuse of the idle connection is validated
+	conn, err = sess.AcquireConnection()
+	failError(t, err)
+
+	assert.Equal(t, uint64(1), metrics.GetCounterForConnectionHealthChecks())
+	assert.Equal(t, uint64(0), metrics.GetCounterForConnectionHealthCheckFailures())
+
+	err = sess.ReturnConnection(conn)
+	failError(t, err)
+
+	// idempotent operations are retried once on connection errors
+	homedir := getHomeDir(fsSessionTestID)
+	attempts := 0
+	err = sess.ExecuteIdempotent(func(conn *connection.IRODSConnection) error {
+		attempts++
+		if attempts == 1 {
+			return xerrors.Errorf("simulated failure: %w", types.NewConnectionError())
+		}
+
+		_, err := fs.GetCollection(conn, homedir)
+		return err
+	})
+	failError(t, err)
+
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, uint64(1), metrics.GetCounterForOperationRetries())
+}
@@ -0,0 +1,92 @@
+package testcases
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/phdavis1027/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSLConfig(t *testing.T) {
+	t.Run("test SSLConfigParse", testSSLConfigParse)
+	t.Run("test SSLConfigVerifyModes", testSSLConfigVerifyModes)
+}
+
+func testSSLConfigParse(t *testing.T) {
+	verify, err := types.GetSSLVerifyServer("CERT")
+	failError(t, err)
+	assert.Equal(t, types.SSLVerifyServerCert, verify)
+
+	verify, err = types.GetSSLVerifyServer("")
+	failError(t, err)
+	assert.Equal(t, types.SSLVerifyServerHostname, verify)
+
+	_, err = types.GetSSLVerifyServer("maybe")
+	assert.Error(t, err)
+
+	version, err := types.GetTLSVersion("TLS1.2")
+	failError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), version)
+
+	version, err = types.GetTLSVersion("1.3")
+	failError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+
+	_, err = types.GetTLSVersion("2.0")
+	assert.Error(t, err)
+
+	suite, err := types.GetTLSCipherSuite("tls_ecdhe_rsa_with_aes_256_gcm_sha384")
+	failError(t, err)
+	assert.Equal(t, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, suite)
+}
+
+func testSSLConfigVerifyModes(t *testing.T) {
+	sslConfig, err := types.CreateIRODSSSLConfig("", "", 32, "AES-256-CBC", 8, 16)
+	failError(t, err)
+
+	sslConfig.MinVersion = tls.VersionTLS12
+
+	// legacy default
+	tlsConfig, err := sslConfig.GetTLSConfig("irods.example.org")
+	failError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.Equal(t, "irods.example.org", tlsConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+
+	sslConfig.VerifyServer = types.SSLVerifyServerHostname
+	sslConfig.ServerName = "ies.example.org"
+	tlsConfig, err = sslConfig.GetTLSConfig("irods.example.org")
+	failError(t, err)
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Equal(t, "ies.example.org", tlsConfig.ServerName)
+
+	sslConfig.VerifyServer = types.SSLVerifyServerCert
+	tlsConfig, err = sslConfig.GetTLSConfig("irods.example.org")
+	failError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.NotNil(t, tlsConfig.VerifyPeerCertificate)
+
+	sslConfig.VerifyServer = types.SSLVerifyServerNone
+	tlsConfig, err = sslConfig.GetTLSConfig("irods.example.org")
+	failError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.VerifyPeerCertificate)
+
+	sslConfig.VerifyServer = types.SSLVerifyServerCert
+
+	// missing client certificate
+	sslConfig.SetClientCertificate("/nonexistent/client.crt", "/nonexistent/client.key")
+	_, err = sslConfig.GetTLSConfig("irods.example.org")
+	assert.Error(t, err)
+
+	// custom config is used as it is
+	sslConfig.TLSConfig = &tls.Config{
+		MinVersion: tls.VersionTLS13,
+	}
+	tlsConfig, err = sslConfig.GetTLSConfig("irods.example.org")
+	failError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.Equal(t, "ies.example.org", tlsConfig.ServerName)
+	assert.Equal(t, "", sslConfig.TLSConfig.ServerName)
+}
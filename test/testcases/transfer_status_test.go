@@ -0,0 +1,86 @@
+package testcases
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferStatus(t *testing.T) {
+	t.Run("test UploadTransferStatusDir", testUploadTransferStatusDir)
+	t.Run("test UploadTransferStatusValidation", testUploadTransferStatusValidation)
+}
+
+func testUploadTransferStatusDir(t *testing.T) {
+	localPath := "/tmp/test_file"
+	iRODSPath := "/zone/home/test/test_file"
+
+	cacheDir, err := os.UserCacheDir()
+	if err == nil {
+		statusFilePath := irods_fs.GetDataObjectUploadTransferStatusFilePath("", localPath, iRODSPath)
+		assert.True(t, strings.HasPrefix(statusFilePath, cacheDir))
+	}
+
+	// uploads of a local file to different paths have different status files
+	statusFilePath1 := irods_fs.GetDataObjectUploadTransferStatusFilePath("/status", localPath, iRODSPath)
+	statusFilePath2 := irods_fs.GetDataObjectUploadTransferStatusFilePath("/status", localPath, iRODSPath+"2")
+	assert.Equal(t, "/status", filepath.Dir(statusFilePath1))
+	assert.NotEqual(t, statusFilePath1, statusFilePath2)
+}
+
+func testUploadTransferStatusValidation(t *testing.T) {
+	statusDir, err := os.MkdirTemp("", "go-irodsclient-test-status-")
+	failError(t, err)
+	defer os.RemoveAll(statusDir)
+
+	localPath := filepath.Join(statusDir, "test_file")
+	iRODSPath := "/zone/home/test/test_file"
+	size := int64(1024)
+	modTime := time.Now()
+
+	status := irods_fs.NewDataObjectUploadTransferStatusLocal(statusDir, localPath, iRODSPath, size, modTime, 2)
+	err = status.CreateStatusFile()
+	failError(t, err)
+
+	err = status.WriteHeader()
+	failError(t, err)
+
+	err = status.WriteStatus(&irods_fs.DataObjectTransferStatusEntry{
+		StartOffset:     0,
+		Length:          512,
+		CompletedLength: 256,
+	})
+	failError(t, err)
+
+	err = status.CloseStatusFile()
+	failError(t, err)
+
+	statusFilePath := status.GetStatus().StatusFilePath
+	if runtime.GOOS != "windows" {
+		stat, err := os.Stat(statusFilePath)
+		failError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	}
+
+	// same local file
+	status, err = irods_fs.GetOrNewDataObjectUploadTransferStatusLocal(statusDir, localPath, iRODSPath, size, modTime, 4)
+	failError(t, err)
+	assert.Equal(t, 2, status.GetStatus().Threads)
+	assert.Len(t, status.GetStatus().StatusMap, 1)
+
+	// modified local file
+	status, err = irods_fs.GetOrNewDataObjectUploadTransferStatusLocal(statusDir, localPath, iRODSPath, size, modTime.Add(time.Second), 4)
+	failError(t, err)
+	assert.Equal(t, 4, status.GetStatus().Threads)
+	assert.Empty(t, status.GetStatus().StatusMap)
+
+	status, err = irods_fs.GetOrNewDataObjectUploadTransferStatusLocal(statusDir, localPath, iRODSPath, size+1, modTime, 4)
+	failError(t, err)
+	assert.Empty(t, status.GetStatus().StatusMap)
+}
@@ -0,0 +1,160 @@
+package testcases
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/phdavis1027/go-irodsclient/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/connection"
+	irods_fs "github.com/phdavis1027/go-irodsclient/irods/fs"
+	"github.com/phdavis1027/go-irodsclient/irods/util"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	unregisterTestID = xid.New().String()
+)
+
+func TestUnregister(t *testing.T) {
+	setup()
+	defer shutdown()
+
+	makeHomeDir(t, unregisterTestID)
+
+	t.Run("test UnregisterFile", testUnregisterFile)
+	t.Run("test UnregisterDir", testUnregisterDir)
+	t.Run("test UnregisterFileReplica", testUnregisterFileReplica)
+}
+
+func testUnregisterFile(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(unregisterTestID)
+	irodsPath := fmt.Sprintf("%s/unregister_file.txt", homedir)
+
+	data := makeRandomContentTestDataBuf(1024)
+	err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), irodsPath, "", 0, false, nil)
+	failError(t, err)
+
+	metricsBefore := filesystem.GetMetrics()
+
+	err = filesystem.UnregisterFile(irodsPath)
+	failError(t, err)
+
+	metricsAfter := filesystem.GetMetrics()
+	assert.Equal(t, metricsBefore.GetCounterForDataObjectDelete()+1, metricsAfter.GetCounterForDataObjectDelete())
+	assert.Equal(t, metricsBefore.GetCounterForCollectionDelete(), metricsAfter.GetCounterForCollectionDelete())
+
+	assert.False(t, filesystem.ExistsFile(irodsPath))
+}
+
+func testUnregisterDir(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(unregisterTestID)
+	dirPath := fmt.Sprintf("%s/unregister_dir", homedir)
+
+	err = filesystem.MakeDir(dirPath+"/sub", true)
+	failError(t, err)
+
+	filePaths := []string{
+		dirPath + "/a.txt",
+		dirPath + "/sub/b.txt",
+	}
+
+	for _, filePath := range filePaths {
+		data := makeRandomContentTestDataBuf(1024)
+		err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), filePath, "", 0, false, nil)
+		failError(t, err)
+	}
+
+	// a non-empty collection is not unregistered without recurse
+	err = filesystem.UnregisterDir(dirPath, false)
+	assert.Error(t, err)
+	assert.True(t, filesystem.ExistsFile(filePaths[0]))
+
+	metricsBefore := filesystem.GetMetrics()
+
+	err = filesystem.UnregisterDir(dirPath, true)
+	failError(t, err)
+
+	metricsAfter := filesystem.GetMetrics()
+	assert.Equal(t, metricsBefore.GetCounterForDataObjectDelete()+uint64(len(filePaths)), metricsAfter.GetCounterForDataObjectDelete())
+	assert.Equal(t, metricsBefore.GetCounterForCollectionDelete()+2, metricsAfter.GetCounterForCollectionDelete())
+
+	assert.False(t, filesystem.ExistsDir(dirPath))
+	for _, filePath := range filePaths {
+		assert.False(t, filesystem.ExistsFile(filePath))
+	}
+}
+
+func testUnregisterFileReplica(t *testing.T) {
+	account := GetTestAccount()
+
+	account.ClientServerNegotiation = false
+
+	filesystem, err := fs.NewFileSystem(account, fs.NewFileSystemConfigWithDefault("go-irodsclient-test"))
+	failError(t, err)
+	defer filesystem.Release()
+
+	homedir := getHomeDir(unregisterTestID)
+	irodsPath := fmt.Sprintf("%s/unregister_replica.txt", homedir)
+
+	// replResc keeps two replicas
+	data := makeRandomContentTestDataBuf(1024)
+	err = filesystem.UploadFromReader(bytes.NewReader(data), int64(len(data)), irodsPath, "replResc", 0, false, nil)
+	failError(t, err)
+
+	replicas, err := filesystem.ListReplicas(irodsPath)
+	failError(t, err)
+	assert.Len(t, replicas, 2)
+
+	unregistered := replicas[1]
+
+	err = filesystem.UnregisterFileReplica(irodsPath, unregistered.Number)
+	failError(t, err)
+
+	// the catalog entry of the replica is gone, the other is kept
+	replicas, err = filesystem.ListReplicas(irodsPath)
+	failError(t, err)
+	assert.Len(t, replicas, 1)
+	assert.NotEqual(t, unregistered.Number, replicas[0].Number)
+
+	// the physical file is still in the resource
+	conn := connection.NewIRODSConnection(account, 300*time.Second, "go-irodsclient-test")
+	err = conn.Connect()
+	failError(t, err)
+	defer conn.Disconnect()
+
+	collection, err := irods_fs.GetCollection(conn, util.GetIRODSPathDirname(irodsPath))
+	failError(t, err)
+
+	dataObject, err := irods_fs.GetDataObject(conn, collection, util.GetIRODSPathFileName(irodsPath))
+	failError(t, err)
+
+	size, err := irods_fs.StatPhysicalReplica(conn, dataObject, unregistered)
+	failError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+
+	// unknown replica
+	err = filesystem.UnregisterFileReplica(irodsPath, unregistered.Number)
+	assert.Error(t, err)
+
+	err = filesystem.RemoveFile(irodsPath, true)
+	failError(t, err)
+}